@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+)
+
+func TestNewForecastDTO_JSON(t *testing.T) {
+	temp := entity.Temperature{Temp_c: 20, Temp_f: 68, Temp_k: 293}
+	forecast := &entity.Forecast{
+		City: "Curitiba",
+		Days: []entity.ForecastDay{{
+			Date:      "2026-10-17",
+			MinTemp:   temp,
+			MaxTemp:   temp,
+			AvgTemp:   temp,
+			Condition: "Sunny",
+			Hours:     []entity.ForecastHour{{Time: "2026-10-17 00:00", Temp: temp, Condition: "Clear"}},
+		}},
+	}
+
+	jsonData, err := json.Marshal(NewForecastDTO(forecast))
+	if err != nil {
+		t.Fatalf("Failed to marshal DTO: %v", err)
+	}
+
+	tempJSON := `{"temp_c":20,"temp_f":68,"temp_k":293}`
+	expectedJSON := `{"city":"Curitiba","days":[{"date":"2026-10-17","min":` + tempJSON + `,"max":` + tempJSON +
+		`,"avg":` + tempJSON + `,"condition":"Sunny","hours":[{"time":"2026-10-17 00:00","temp":` + tempJSON + `,"condition":"Clear"}]}]}`
+	if string(jsonData) != expectedJSON {
+		t.Errorf("Expected JSON %s, got %s", expectedJSON, string(jsonData))
+	}
+}
+
+func TestNewForecastDTO_OmitsHoursAndKeepsEmptyDays(t *testing.T) {
+	jsonData, _ := json.Marshal(NewForecastDTO(&entity.Forecast{City: "Recife"}))
+	if string(jsonData) != `{"city":"Recife","days":[]}` {
+		t.Errorf("Expected empty days list, got %s", string(jsonData))
+	}
+
+	jsonData, _ = json.Marshal(NewForecastDTO(&entity.Forecast{City: "Recife", Days: []entity.ForecastDay{{Date: "2026-10-17"}}}))
+	var rawJSON map[string][]map[string]any
+	json.Unmarshal(jsonData, &rawJSON)
+	if _, exists := rawJSON["days"][0]["hours"]; exists {
+		t.Errorf("Expected hours to be omitted, got %s", string(jsonData))
+	}
+}
@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+)
+
+// LocationDTO é o endereço da consulta. Os campos desconhecidos ficam de
+// fora: rua, bairro e código IBGE só existem nas consultas por CEP.
+type LocationDTO struct {
+	Street       string `json:"street,omitempty"`
+	Neighborhood string `json:"neighborhood,omitempty"`
+	City         string `json:"city,omitempty"`
+	State        string `json:"state,omitempty"`
+	IBGE         string `json:"ibge_code,omitempty"`
+	Timezone     string `json:"timezone,omitempty"`
+	// LocalTime está em RFC3339, com o deslocamento do fuso do local
+	LocalTime string `json:"local_time,omitempty"`
+}
+
+func NewLocationDTO(location *entity.Location) *LocationDTO {
+	locationDTO := &LocationDTO{
+		Street:       location.Street,
+		Neighborhood: location.Neighborhood,
+		City:         location.City,
+		State:        location.State,
+		IBGE:         location.IBGE,
+		Timezone:     location.Timezone,
+	}
+	if !location.LocalTime.IsZero() {
+		locationDTO.LocalTime = location.LocalTime.Format(time.RFC3339)
+	}
+	return locationDTO
+}
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+)
+
+func TestNewAddressSearchResponseDTO_JSON(t *testing.T) {
+	candidates := []entity.AddressCandidate{
+		{
+			Address: entity.Address{CEP: "01310100", Street: "Avenida Paulista", Complement: "de 612 a 1510 - lado par", Neighborhood: "Bela Vista", City: "São Paulo", State: "SP", IBGE: "3550308"},
+			Weather: entity.NewWeather("Sao Paulo", 20, 68, 293),
+		},
+		{
+			Address:      entity.Address{CEP: "01311000", Street: "Avenida Paulista", Neighborhood: "Bela Vista", City: "São Paulo", State: "SP", IBGE: "3550308"},
+			WeatherError: "Can not find zipcode",
+		},
+	}
+
+	jsonData, _ := json.Marshal(NewAddressSearchResponseDTO(candidates))
+
+	expectedJSON := `{"results":[` +
+		`{"cep":"01310100","street":"Avenida Paulista","complement":"de 612 a 1510 - lado par","neighborhood":"Bela Vista","city":"São Paulo","state":"SP","ibge_code":"3550308",` +
+		`"weather":{"city":"Sao Paulo","temp_c":20,"temp_f":68,"temp_k":293}},` +
+		`{"cep":"01311000","street":"Avenida Paulista","neighborhood":"Bela Vista","city":"São Paulo","state":"SP","ibge_code":"3550308","error":"Can not find zipcode"}]}`
+	if string(jsonData) != expectedJSON {
+		t.Errorf("Expected JSON %s, got %s", expectedJSON, string(jsonData))
+	}
+}
+
+func TestNewAddressSearchResponseDTO_Empty(t *testing.T) {
+	jsonData, _ := json.Marshal(NewAddressSearchResponseDTO(nil))
+
+	if string(jsonData) != `{"results":[]}` {
+		t.Errorf("Expected an empty list, got %s", string(jsonData))
+	}
+}
@@ -2,112 +2,178 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/infra/api"
 	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/infra/gateway"
 	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/usecase/weather"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
 
 	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
-
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
+	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/trace"
-
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
 )
 
+// defaultShutdownTimeout é o prazo padrão para drenar as requisições em
+// andamento; somado ao flush da telemetria cabe nos 10s do Cloud Run.
+const defaultShutdownTimeout = 5 * time.Second
+
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	shutdown, err := initProvider()
+	cfg, cfgErr := telemetry.ConfigFromEnv("ServiceA", os.Getenv)
+
+	// Com OTEL_LOGS_EXPORTER=otlp os logs também são enviados ao collector
+	var bridge []slog.Handler
+	if cfg.LogsEnabled {
+		bridge = append(bridge, otelslog.NewHandler("serviceA-logger"))
+	}
+	logger := telemetry.NewLogger(cfg.ServiceName, telemetry.ParseLevel(os.Getenv("LOG_LEVEL")), os.Stdout, bridge...)
+	if cfgErr != nil {
+		logger.Warn("invalid telemetry configuration, using defaults for the invalid settings", slog.Any("error", cfgErr))
+	}
+
+	healthCfg, err := telemetry.HealthConfigFromEnv(os.Getenv)
+	if err != nil {
+		logger.Warn("invalid health check configuration, using defaults for the invalid settings", slog.Any("error", err))
+	}
+
+	profilingCfg, err := telemetry.ProfilingConfigFromEnv(os.Getenv)
 	if err != nil {
-		log.Fatal(err)
+		logger.Warn("invalid profiling configuration, using defaults for the invalid settings", slog.Any("error", err))
 	}
-	defer func() {
-		if err := shutdown(ctx); err != nil {
-			log.Fatalf("Failed to shutdown TracerProvider: %v", err)
+	profiler := telemetry.NewProfiler(profilingCfg, logger)
+	go func() {
+		if err := profiler.ServeAdmin(ctx); err != nil {
+			logger.Error("pprof admin server failed", slog.Any("error", err))
 		}
 	}()
 
-	tracer := otel.Tracer("serviceA-tracer")
-
-	startServer(tracer)
-}
-
-func startServer(tracer trace.Tracer) {
-	weatherGateway := gateway.NewWeatherAPI()
-	weatherUseCase := weather.NewWeatherUseCase(weatherGateway, tracer)
-	weatherHandler := api.NewWeatherHandler(weatherUseCase, tracer)
+	batchCfg, err := weather.BatchConfigFromEnv(os.Getenv)
+	if err != nil {
+		logger.Warn("invalid batch configuration, using defaults for the invalid settings", slog.Any("error", err))
+	}
 
-	router := chi.NewRouter()
-	router.Use(middleware.Logger)
-	router.HandleFunc("/", weatherHandler.GetCurrentWeather)
+	shutdownTimeout := defaultShutdownTimeout
+	if value := os.Getenv("SHUTDOWN_TIMEOUT"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			logger.Warn("invalid SHUTDOWN_TIMEOUT, using default", slog.String("value", value), slog.Duration("default", defaultShutdownTimeout))
+		} else {
+			shutdownTimeout = parsed
+		}
+	}
 
-	fmt.Println("Starting web server on port", ":8080")
-	err := http.ListenAndServe(":8080", router)
+	shutdown, metricsHandler, err := telemetry.Setup(ctx, cfg)
 	if err != nil {
-		panic(err)
+		logger.Error("failed to initialize telemetry", slog.Any("error", err))
+		os.Exit(1)
 	}
-}
-
-func initProvider() (func(context.Context) error, error) {
-	ctx := context.Background()
 
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName("ServiceA"),
-		),
-	)
+	tracer := otel.Tracer("serviceA-tracer")
 
+	metrics, err := telemetry.NewMetrics(otel.Meter("serviceA-meter"))
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create Resource: %w", err)
+		logger.Error("failed to create metrics", slog.Any("error", err))
+		os.Exit(1)
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, time.Second)
-	defer cancel()
+	serverErr := startServer(ctx, shutdownTimeout, healthCfg, batchCfg, cfg.Exporter, cfg.DebugTraces, profiler, tracer, metrics, metricsHandler, telemetry.VersionHandler(cfg.Resource), logger)
+	if serverErr != nil {
+		logger.Error("web server failed", slog.Any("error", serverErr))
+	}
 
-	otelEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-	if otelEndpoint == "" {
-		otelEndpoint = "localhost:4317"
+	// Só depois de drenar as requisições os spans pendentes são exportados
+	logger.Info("flushing telemetry", slog.Duration("timeout", cfg.ShutdownTimeout))
+	if err := shutdown(context.Background()); err != nil {
+		logger.Error("failed to shutdown telemetry providers", slog.Any("error", err))
 	}
+	if serverErr != nil {
+		os.Exit(1)
+	}
+}
 
-	conn, err := grpc.NewClient(
-		otelEndpoint,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+func startServer(ctx context.Context, shutdownTimeout time.Duration, healthCfg telemetry.HealthConfig, batchCfg weather.BatchConfig, exporterCfg telemetry.ExporterConfig, debugTraces *telemetry.DebugTraceProcessor, profiler *telemetry.Profiler, tracer trace.Tracer, metrics *telemetry.Metrics, metricsHandler, versionHandler http.Handler, logger *slog.Logger) error {
+	weatherGateway := gateway.NewWeatherAPI(telemetry.NewHTTPClient(tracer, metrics), logger)
+	weatherUseCase := weather.NewWeatherUseCase(weatherGateway, tracer, logger)
+	weatherHandler := api.NewWeatherHandler(weatherUseCase, logger)
+	batchHandler := api.NewBatchHandler(weather.NewBatchWeatherUseCase(weatherGateway, tracer, logger, batchCfg), logger)
+
+	// O /readyz do serviço B executa as próprias verificações: o prazo daqui
+	// precisa cobrir o dele
+	serviceBCheck := weatherGateway.HealthCheck()
+	serviceBCheck.Timeout = healthCfg.Timeout + time.Second
+	health, err := telemetry.NewHealth(tracer, logger, healthCfg,
+		telemetry.HealthCheck{Name: "collector", Check: telemetry.CollectorCheck(exporterCfg)},
+		serviceBCheck,
 	)
-
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create gRPC connection to collector: %w", err)
+		return err
 	}
 
-	tracerExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
-	if err != nil {
-		return nil, fmt.Errorf("Failed to create the collector trace exporter: %w", err)
+	router := chi.NewRouter()
+	router.Use(telemetry.RequestLogger(logger))
+	router.Use(telemetry.DebugTraceMiddleware)
+	router.Use(telemetry.TracingMiddleware(tracer))
+	router.Use(telemetry.MetricsMiddleware(metrics))
+
+	// Os labels de pprof dependem da rota, resolvida só depois do roteamento
+	routes := router.With(profiler.Middleware)
+	routes.HandleFunc("/", weatherHandler.GetCurrentWeather)
+	routes.HandleFunc("/city", weatherHandler.GetCurrentWeatherByCity)
+	routes.HandleFunc("/coordinates", weatherHandler.GetCurrentWeatherByCoordinates)
+	routes.HandleFunc("/forecast", weatherHandler.GetForecast)
+	routes.HandleFunc("/batch", batchHandler.GetCurrentWeather)
+	routes.HandleFunc("/search", weatherHandler.SearchAddress)
+	// Com DEBUG_TRACES_ENABLED=true os últimos traces ficam visíveis sem o Zipkin
+	if debugTraces != nil {
+		routes.Handle("/debug/traces", debugTraces.Handler())
 	}
 
-	bsp := sdktrace.NewBatchSpanProcessor(tracerExporter)
-	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(bsp),
-	)
+	// Probes, /metrics e /version ficam fora do router e dos middlewares: as
+	// chamadas não geram logs de requisição, spans HTTP nem métricas HTTP. Só
+	// as verificações do /readyz geram o próprio trace health_report
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", health.LivenessHandler())
+	mux.Handle("/readyz", health.ReadinessHandler())
+	mux.Handle("/metrics", metricsHandler)
+	mux.Handle("/version", versionHandler)
+	mux.Handle("/", router)
+
+	server := &http.Server{Addr: ":8080", Handler: mux}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("starting web server", slog.String("addr", server.Addr))
+		errCh <- server.ListenAndServe()
+	}()
 
-	otel.SetTracerProvider(tracerProvider)
-	otel.SetTextMapPropagator(propagation.TraceContext{})
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
 
-	return tracerProvider.Shutdown, nil
+	// Para de aceitar conexões e aguarda as requisições em andamento
+	logger.Info("shutting down web server", slog.Duration("timeout", shutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("failed to drain in-flight requests: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// MinAddressTermLength é o mínimo de caracteres de cidade e logradouro
+// exigido pela busca de endereços do ViaCEP.
+const MinAddressTermLength = 3
+
+var ErrInvalidAddressSearch = errors.New("invalid address search")
+
+// AddressSearch é uma busca de CEPs a partir de um endereço parcial.
+type AddressSearch struct {
+	UF     string
+	City   string
+	Street string
+}
+
+// NewAddressSearch exige uma UF existente e cidade e logradouro com pelo
+// menos MinAddressTermLength caracteres.
+func NewAddressSearch(uf, city, street string) (AddressSearch, error) {
+	search := AddressSearch{
+		UF:     strings.ToUpper(strings.TrimSpace(uf)),
+		City:   strings.TrimSpace(city),
+		Street: strings.TrimSpace(street),
+	}
+	if _, ok := States[search.UF]; !ok {
+		return AddressSearch{}, ErrInvalidAddressSearch
+	}
+	for _, term := range []string{search.City, search.Street} {
+		length := utf8.RuneCountInString(term)
+		if length < MinAddressTermLength || length > MaxCityLength {
+			return AddressSearch{}, ErrInvalidAddressSearch
+		}
+	}
+	return search, nil
+}
+
+// Address é um CEP encontrado pela busca de endereços.
+type Address struct {
+	CEP          string
+	Street       string
+	Complement   string
+	Neighborhood string
+	City         string
+	State        string
+	IBGE         string
+}
+
+// AddressCandidate é um resultado da busca, com o clima atual quando pedido.
+// WeatherError explica por que o clima não veio.
+type AddressCandidate struct {
+	Address      Address
+	Weather      *Weather
+	WeatherError string
+}
@@ -0,0 +1,21 @@
+package entity
+
+import "errors"
+
+var (
+	ErrBatchEmpty    = errors.New("batch must have at least one zipcode")
+	ErrBatchTooLarge = errors.New("batch exceeds the zipcode limit")
+	// ErrBatchRejected é um 4xx do serviço B, ex.: lote acima do BATCH_MAX_CEPS dele
+	ErrBatchRejected = errors.New("batch rejected by service B")
+)
+
+// BatchItem é o resultado de um CEP do lote, como devolvido pelo serviço B:
+// Weather quando Status é 200, Error caso contrário.
+type BatchItem struct {
+	// Index é a posição do CEP no lote sem os repetidos
+	Index   int
+	CEP     string
+	Status  int
+	Weather *Weather
+	Error   string
+}
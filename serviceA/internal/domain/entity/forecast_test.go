@@ -0,0 +1,16 @@
+package entity
+
+import "testing"
+
+func TestValidateForecastDays(t *testing.T) {
+	for _, days := range []int{1, DefaultForecastDays, MaxForecastDays} {
+		if err := ValidateForecastDays(days); err != nil {
+			t.Errorf("Expected %d days to be valid, got %v", days, err)
+		}
+	}
+	for _, days := range []int{-1, 0, MaxForecastDays + 1} {
+		if err := ValidateForecastDays(days); err != ErrInvalidForecastDays {
+			t.Errorf("Expected ErrInvalidForecastDays for %d days, got %v", days, err)
+		}
+	}
+}
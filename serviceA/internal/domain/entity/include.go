@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	IncludeAirQuality = "aqi"
+	IncludeLocation   = "location"
+)
+
+var ErrInvalidInclude = errors.New("invalid include")
+
+// Include são os dados opcionais pedidos junto com o clima atual, lidos do
+// parâmetro include (ex.: include=aqi,location).
+type Include struct {
+	AirQuality bool
+	Location   bool
+}
+
+// ParseInclude lê uma lista separada por vírgulas. Vazio não inclui nada; um
+// valor desconhecido é ErrInvalidInclude.
+func ParseInclude(raw string) (Include, error) {
+	var include Include
+	for _, value := range strings.Split(raw, ",") {
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "":
+		case IncludeAirQuality:
+			include.AirQuality = true
+		case IncludeLocation:
+			include.Location = true
+		default:
+			return Include{}, ErrInvalidInclude
+		}
+	}
+	return include, nil
+}
+
+// String devolve include no formato aceito por ParseInclude.
+func (i Include) String() string {
+	var values []string
+	if i.AirQuality {
+		values = append(values, IncludeAirQuality)
+	}
+	if i.Location {
+		values = append(values, IncludeLocation)
+	}
+	return strings.Join(values, ",")
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// Location é o endereço resolvido da consulta. Street, Neighborhood e IBGE
+// só são conhecidos nas consultas por CEP.
+type Location struct {
+	Street       string
+	Neighborhood string
+	City         string
+	// State é a sigla da UF (ex.: "SP")
+	State string
+	IBGE  string
+	// Timezone é o identificador IANA (ex.: "America/Sao_Paulo")
+	Timezone  string
+	LocalTime time.Time
+}
@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNewCityQuery(t *testing.T) {
+	query, err := NewCityQuery("  Campinas ", "sp")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if query.City != "Campinas" || query.UF != "SP" || query.CEP != "" || query.Coordinates != nil {
+		t.Errorf("Unexpected query: %+v", query)
+	}
+}
+
+func TestNewCityQuery_Invalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		city string
+		uf   string
+	}{
+		{"EmptyCity", " ", "SP"},
+		{"LongCity", strings.Repeat("a", MaxCityLength+1), "SP"},
+		{"EmptyUF", "Campinas", ""},
+		{"UnknownUF", "Campinas", "XX"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewCityQuery(tc.city, tc.uf); err != ErrInvalidCity {
+				t.Errorf("Expected ErrInvalidCity, got %v", err)
+			}
+		})
+	}
+}
+
+func TestNewCoordinatesQuery(t *testing.T) {
+	query, err := NewCoordinatesQuery(-23.5505, -46.6333)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if query.Coordinates == nil || query.Coordinates.Lat != -23.5505 || query.Coordinates.Lon != -46.6333 {
+		t.Errorf("Unexpected query: %+v", query)
+	}
+}
+
+func TestNewCoordinatesQuery_Invalid(t *testing.T) {
+	testCases := []struct {
+		lat float64
+		lon float64
+	}{
+		{-90.1, 0},
+		{90.1, 0},
+		{0, -180.1},
+		{0, 180.1},
+		{math.NaN(), 0},
+	}
+
+	for _, tc := range testCases {
+		if _, err := NewCoordinatesQuery(tc.lat, tc.lon); err != ErrInvalidCoordinates {
+			t.Errorf("Expected ErrInvalidCoordinates for (%v, %v), got %v", tc.lat, tc.lon, err)
+		}
+	}
+}
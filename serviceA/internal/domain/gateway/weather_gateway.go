@@ -7,5 +7,10 @@ import (
 )
 
 type WeatherGateway interface {
-	GetCurrentWeather(ctx context.Context, cep string) (*entity.Weather, error)
+	GetCurrentWeather(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error)
+	GetForecast(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error)
+	SearchAddress(ctx context.Context, search entity.AddressSearch, withWeather bool, include entity.Include) ([]entity.AddressCandidate, error)
+	// GetBatchWeather chama yield para cada resultado do lote, assim que o
+	// serviço B o envia.
+	GetBatchWeather(ctx context.Context, ceps []string, include entity.Include, yield func(item entity.BatchItem)) error
 }
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/api/dto"
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// maxBatchBodySize limita o corpo do POST /batch, lido antes da validação do
+// tamanho do lote.
+const maxBatchBodySize = 1 << 20
+
+type BatchUseCaseInterface interface {
+	GetCurrentWeather(ctx context.Context, ceps []string, include entity.Include, yield func(item entity.BatchItem)) error
+}
+
+type BatchHandler struct {
+	usecase BatchUseCaseInterface
+	logger  *slog.Logger
+}
+
+func NewBatchHandler(useCase BatchUseCaseInterface, logger *slog.Logger) *BatchHandler {
+	return &BatchHandler{usecase: useCase, logger: logger}
+}
+
+// GetCurrentWeather responde POST /batch com {"ceps": [...]}. Por padrão
+// devolve todos os resultados de uma vez, na ordem do lote; com Accept:
+// application/x-ndjson, cada resultado é enviado em uma linha assim que o
+// serviço B o devolve. O status de cada CEP é o do serviço B.
+func (c *BatchHandler) GetCurrentWeather(w http.ResponseWriter, r *http.Request) {
+	// O span SERVER da requisição é criado pelo telemetry.TracingMiddleware
+	ctx := r.Context()
+
+	include, err := entity.ParseInclude(r.URL.Query().Get("include"))
+	if err != nil {
+		c.fail(ctx, w, err)
+		return
+	}
+
+	var batchRequest dto.BatchRequestDTO
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodySize)).Decode(&batchRequest); err != nil {
+		c.logger.WarnContext(ctx, "invalid batch request body", slog.Any("error", err))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid request body", http.StatusUnprocessableEntity)
+		return
+	}
+
+	newItem := func(item entity.BatchItem) dto.BatchItemDTO {
+		itemDTO := dto.BatchItemDTO{Index: item.Index, CEP: item.CEP, Status: item.Status, Error: item.Error}
+		if item.Weather != nil {
+			itemDTO.Weather = dto.NewWeatherDTO(item.Weather.City, item.Weather.Temp_c, item.Weather.Temp_f, item.Weather.Temp_k).
+				WithConditions(item.Weather.Conditions).
+				WithAirQuality(item.Weather.AirQuality).
+				WithLocation(item.Weather.Location)
+		}
+		return itemDTO
+	}
+
+	if acceptsNDJSON(r) {
+		// O status 200 só é enviado com o primeiro resultado, para que um lote
+		// inválido ainda possa responder com erro
+		encoder := json.NewEncoder(w)
+		controller := http.NewResponseController(w)
+		started := false
+		err := c.usecase.GetCurrentWeather(ctx, batchRequest.CEPs, include, func(item entity.BatchItem) {
+			if !started {
+				started = true
+				w.Header().Set("Content-Type", ndjsonContentType)
+				w.WriteHeader(http.StatusOK)
+			}
+			encoder.Encode(newItem(item))
+			controller.Flush()
+		})
+		switch {
+		case err != nil && started:
+			// O status já foi enviado: o cliente vê o lote incompleto
+			trace.SpanFromContext(ctx).RecordError(err)
+			c.logger.ErrorContext(ctx, "batch stream interrupted", slog.Any("error", err))
+		case err != nil:
+			c.fail(ctx, w, err)
+		}
+		return
+	}
+
+	response := dto.BatchResponseDTO{}
+	err = c.usecase.GetCurrentWeather(ctx, batchRequest.CEPs, include, func(item entity.BatchItem) {
+		if item.Index < 0 {
+			return
+		}
+		if item.Index >= len(response.Results) {
+			response.Results = append(response.Results, make([]dto.BatchItemDTO, item.Index+1-len(response.Results))...)
+		}
+		response.Results[item.Index] = newItem(item)
+	})
+	if err != nil {
+		c.fail(ctx, w, err)
+		return
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to marshal batch response", slog.Any("error", err))
+		http.Error(w, "Error marshalling batch data", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(responseJSON)
+}
+
+// fail responde 422 para um lote inválido, inclusive quando o serviço B o
+// rejeita com 4xx, e 502 quando o serviço B falha ou não responde.
+func (c *BatchHandler) fail(ctx context.Context, w http.ResponseWriter, err error) {
+	trace.SpanFromContext(ctx).RecordError(err)
+	c.logger.WarnContext(ctx, "rejected batch request", slog.Any("error", err))
+	status := http.StatusBadGateway
+	if errors.Is(err, entity.ErrBatchEmpty) || errors.Is(err, entity.ErrBatchTooLarge) ||
+		errors.Is(err, entity.ErrBatchRejected) || errors.Is(err, entity.ErrInvalidInclude) {
+		status = http.StatusUnprocessableEntity
+	}
+	http.Error(w, err.Error(), status)
+}
+
+func acceptsNDJSON(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accept); err == nil && mediaType == ndjsonContentType {
+			return true
+		}
+	}
+	return false
+}
@@ -4,26 +4,30 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 
 	"github.com/adalbertofjr/lab-2-go-service-a-otel/api/dto"
 	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type WeatherUseCaseInterface interface {
-	GetCurrentWeather(ctx context.Context, cep string) (*entity.Weather, error)
+	GetCurrentWeather(ctx context.Context, cep string, include entity.Include) (*entity.Weather, error)
+	GetCurrentWeatherByCity(ctx context.Context, city, uf string, include entity.Include) (*entity.Weather, error)
+	GetCurrentWeatherByCoordinates(ctx context.Context, lat, lon float64, include entity.Include) (*entity.Weather, error)
+	GetForecast(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error)
+	SearchAddress(ctx context.Context, uf, city, street string, withWeather bool, include entity.Include) ([]entity.AddressCandidate, error)
 }
 
 type WeatherHandler struct {
 	usecase WeatherUseCaseInterface
-	tracer  trace.Tracer
+	logger  *slog.Logger
 }
 
-func NewWeatherHandler(useCase WeatherUseCaseInterface, tracer trace.Tracer) *WeatherHandler {
-	return &WeatherHandler{usecase: useCase, tracer: tracer}
+func NewWeatherHandler(useCase WeatherUseCaseInterface, logger *slog.Logger) *WeatherHandler {
+	return &WeatherHandler{usecase: useCase, logger: logger}
 }
 
 type CEP struct {
@@ -31,12 +35,8 @@ type CEP struct {
 }
 
 func (c *WeatherHandler) GetCurrentWeather(w http.ResponseWriter, r *http.Request) {
-	carrier := propagation.HeaderCarrier(r.Header)
+	// O span SERVER da requisição é criado pelo telemetry.TracingMiddleware
 	ctx := r.Context()
-	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
-
-	ctx, spanStart := c.tracer.Start(ctx, "POST /cep")
-	defer spanStart.End()
 
 	req := r.Body
 	defer req.Close()
@@ -52,8 +52,33 @@ func (c *WeatherHandler) GetCurrentWeather(w http.ResponseWriter, r *http.Reques
 		panic(err)
 	}
 
-	currentWeather, err := c.usecase.GetCurrentWeather(ctx, cep.CEP)
+	ctx = telemetry.WithCEP(ctx, cep.CEP)
+
+	include, ok := c.parseInclude(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	currentWeather, err := c.usecase.GetCurrentWeather(ctx, cep.CEP, include)
+	c.writeWeather(ctx, w, currentWeather, err)
+}
+
+// parseInclude lê o parâmetro include; em caso de erro já responde 422.
+func (c *WeatherHandler) parseInclude(ctx context.Context, w http.ResponseWriter, r *http.Request) (entity.Include, bool) {
+	include, err := entity.ParseInclude(r.URL.Query().Get("include"))
 	if err != nil {
+		c.logger.InfoContext(ctx, "rejected invalid include", slog.String("include", r.URL.Query().Get("include")))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return entity.Include{}, false
+	}
+	return include, true
+}
+
+// writeWeather responde com o clima atual ou com o erro do caso de uso.
+func (c *WeatherHandler) writeWeather(ctx context.Context, w http.ResponseWriter, currentWeather *entity.Weather, err error) {
+	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		c.logger.WarnContext(ctx, "failed to get current weather", slog.Any("error", err))
 		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
@@ -63,10 +88,13 @@ func (c *WeatherHandler) GetCurrentWeather(w http.ResponseWriter, r *http.Reques
 		currentWeather.Temp_c,
 		currentWeather.Temp_f,
 		currentWeather.Temp_k,
-	)
+	).WithConditions(currentWeather.Conditions).
+		WithAirQuality(currentWeather.AirQuality).
+		WithLocation(currentWeather.Location)
 
 	weatherCurrentJSON, jsonErr := json.Marshal(weatherDTO)
 	if jsonErr != nil {
+		c.logger.ErrorContext(ctx, "failed to marshal weather response", slog.Any("error", jsonErr))
 		http.Error(w, "Error marshalling location data", http.StatusInternalServerError)
 		return
 	}
@@ -74,6 +102,4 @@ func (c *WeatherHandler) GetCurrentWeather(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(weatherCurrentJSON))
-
-	// spanStart.End()
 }
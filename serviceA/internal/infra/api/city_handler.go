@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+type CityRequest struct {
+	City string `json:"city"`
+	UF   string `json:"uf"`
+}
+
+// GetCurrentWeatherByCity responde POST /city com {"city": "...", "uf": "..."}.
+func (c *WeatherHandler) GetCurrentWeatherByCity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	var cityRequest CityRequest
+	if err := json.NewDecoder(r.Body).Decode(&cityRequest); err != nil {
+		c.logger.WarnContext(ctx, "invalid city request body", slog.Any("error", err))
+		http.Error(w, "invalid request body", http.StatusUnprocessableEntity)
+		return
+	}
+
+	include, ok := c.parseInclude(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	currentWeather, err := c.usecase.GetCurrentWeatherByCity(ctx, cityRequest.City, cityRequest.UF, include)
+	c.writeWeather(ctx, w, currentWeather, err)
+}
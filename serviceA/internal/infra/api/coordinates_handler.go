@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+)
+
+type CoordinatesRequest struct {
+	// Ponteiros para distinguir um valor ausente de 0
+	Lat *float64 `json:"lat"`
+	Lon *float64 `json:"lon"`
+}
+
+// GetCurrentWeatherByCoordinates responde POST /coordinates com
+// {"lat": -23.55, "lon": -46.63}.
+func (c *WeatherHandler) GetCurrentWeatherByCoordinates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	var coordinatesRequest CoordinatesRequest
+	if err := json.NewDecoder(r.Body).Decode(&coordinatesRequest); err != nil {
+		c.logger.WarnContext(ctx, "invalid coordinates request body", slog.Any("error", err))
+		http.Error(w, "invalid request body", http.StatusUnprocessableEntity)
+		return
+	}
+	if coordinatesRequest.Lat == nil || coordinatesRequest.Lon == nil {
+		c.writeWeather(ctx, w, nil, entity.ErrInvalidCoordinates)
+		return
+	}
+
+	include, ok := c.parseInclude(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	currentWeather, err := c.usecase.GetCurrentWeatherByCoordinates(ctx, *coordinatesRequest.Lat, *coordinatesRequest.Lon, include)
+	c.writeWeather(ctx, w, currentWeather, err)
+}
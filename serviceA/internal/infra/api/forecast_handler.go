@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/api/dto"
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ForecastRequest struct {
+	CEP string `json:"cep"`
+	// Days é opcional; sem ele são usados entity.DefaultForecastDays dias
+	Days   *int `json:"days"`
+	Hourly bool `json:"hourly"`
+}
+
+func (c *WeatherHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	// O span SERVER da requisição é criado pelo telemetry.TracingMiddleware
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	var forecastRequest ForecastRequest
+	if err := json.NewDecoder(r.Body).Decode(&forecastRequest); err != nil {
+		c.logger.WarnContext(ctx, "invalid forecast request body", slog.Any("error", err))
+		http.Error(w, "invalid request body", http.StatusUnprocessableEntity)
+		return
+	}
+
+	days := entity.DefaultForecastDays
+	if forecastRequest.Days != nil {
+		days = *forecastRequest.Days
+	}
+
+	ctx = telemetry.WithCEP(ctx, forecastRequest.CEP)
+
+	forecast, err := c.usecase.GetForecast(ctx, forecastRequest.CEP, days, forecastRequest.Hourly)
+	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		c.logger.WarnContext(ctx, "failed to get forecast", slog.Any("error", err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	forecastJSON, err := json.Marshal(dto.NewForecastDTO(forecast))
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to marshal forecast response", slog.Any("error", err))
+		http.Error(w, "Error marshalling forecast data", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(forecastJSON)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/api/dto"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type SearchRequest struct {
+	UF     string `json:"uf"`
+	City   string `json:"city"`
+	Street string `json:"street"`
+	// Weather pede o clima atual de cada CEP encontrado
+	Weather bool `json:"weather"`
+}
+
+// SearchAddress responde POST /search com {"uf": "...", "city": "...",
+// "street": "..."}.
+func (c *WeatherHandler) SearchAddress(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	defer r.Body.Close()
+
+	var searchRequest SearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&searchRequest); err != nil {
+		c.logger.WarnContext(ctx, "invalid search request body", slog.Any("error", err))
+		http.Error(w, "invalid request body", http.StatusUnprocessableEntity)
+		return
+	}
+
+	include, ok := c.parseInclude(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	candidates, err := c.usecase.SearchAddress(ctx, searchRequest.UF, searchRequest.City, searchRequest.Street, searchRequest.Weather, include)
+	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		c.logger.WarnContext(ctx, "failed to search address", slog.Any("error", err))
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	searchJSON, err := json.Marshal(dto.NewAddressSearchResponseDTO(candidates))
+	if err != nil {
+		c.logger.ErrorContext(ctx, "failed to marshal address search response", slog.Any("error", err))
+		http.Error(w, "Error marshalling address data", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(searchJSON)
+}
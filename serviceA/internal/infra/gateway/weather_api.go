@@ -1,21 +1,30 @@
 package gateway
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
 )
 
+// maxBatchErrorSize limita a mensagem de erro do serviço B repassada ao cliente.
+const maxBatchErrorSize = 1 << 10
+
 type WeatherAPI struct {
 	serviceBURL string
+	client      *http.Client
+	logger      *slog.Logger
 }
 
 type WeatherAPIResponse struct {
@@ -23,38 +32,198 @@ type WeatherAPIResponse struct {
 	Temp_c float64 `json:"temp_c"`
 	Temp_f float64 `json:"temp_f"`
 	Temp_k float64 `json:"temp_k"`
+	// Fica nil se o serviço B não enviar as condições atuais
+	*ConditionsResponse
+	AirQuality *AirQualityResponse `json:"air_quality"`
+	Location   *LocationResponse   `json:"location"`
+}
+
+type LocationResponse struct {
+	Street       string `json:"street"`
+	Neighborhood string `json:"neighborhood"`
+	City         string `json:"city"`
+	State        string `json:"state"`
+	IBGE         string `json:"ibge_code"`
+	Timezone     string `json:"timezone"`
+	LocalTime    string `json:"local_time"`
+}
+
+func (l *LocationResponse) entity() *entity.Location {
+	location := &entity.Location{
+		Street:       l.Street,
+		Neighborhood: l.Neighborhood,
+		City:         l.City,
+		State:        l.State,
+		IBGE:         l.IBGE,
+		Timezone:     l.Timezone,
+	}
+	// Mantém o deslocamento do fuso enviado pelo serviço B
+	if localTime, err := time.Parse(time.RFC3339, l.LocalTime); err == nil {
+		location.LocalTime = localTime
+	}
+	return location
+}
+
+type AddressSearchResponse struct {
+	Results []struct {
+		CEP          string              `json:"cep"`
+		Street       string              `json:"street"`
+		Complement   string              `json:"complement"`
+		Neighborhood string              `json:"neighborhood"`
+		City         string              `json:"city"`
+		State        string              `json:"state"`
+		IBGE         string              `json:"ibge_code"`
+		Weather      *WeatherAPIResponse `json:"weather"`
+		Error        string              `json:"error"`
+	} `json:"results"`
+}
+
+// BatchItemResponse é uma linha do NDJSON do POST /batch do serviço B.
+type BatchItemResponse struct {
+	Index   int                 `json:"index"`
+	CEP     string              `json:"cep"`
+	Status  int                 `json:"status"`
+	Weather *WeatherAPIResponse `json:"weather"`
+	Error   string              `json:"error"`
+}
+
+type AirQualityResponse struct {
+	CO           float64 `json:"co"`
+	NO2          float64 `json:"no2"`
+	O3           float64 `json:"o3"`
+	SO2          float64 `json:"so2"`
+	PM2_5        float64 `json:"pm2_5"`
+	PM10         float64 `json:"pm10"`
+	USEPAIndex   int     `json:"us_epa_index"`
+	GBDEFRAIndex int     `json:"gb_defra_index"`
+}
+
+func (a *AirQualityResponse) entity() *entity.AirQuality {
+	return &entity.AirQuality{
+		CO:           a.CO,
+		NO2:          a.NO2,
+		O3:           a.O3,
+		SO2:          a.SO2,
+		PM2_5:        a.PM2_5,
+		PM10:         a.PM10,
+		USEPAIndex:   a.USEPAIndex,
+		GBDEFRAIndex: a.GBDEFRAIndex,
+	}
+}
+
+type ConditionsResponse struct {
+	Humidity int `json:"humidity"`
+	Wind     struct {
+		SpeedKph  float64 `json:"speed_kph"`
+		SpeedMph  float64 `json:"speed_mph"`
+		Degree    int     `json:"degree"`
+		Direction string  `json:"direction"`
+	} `json:"wind"`
+	Pressure struct {
+		Mb float64 `json:"mb"`
+		In float64 `json:"in"`
+	} `json:"pressure"`
+	UV        float64             `json:"uv"`
+	FeelsLike TemperatureResponse `json:"feels_like"`
+	HeatIndex TemperatureResponse `json:"heat_index"`
+	Condition struct {
+		Text string `json:"text"`
+		Icon string `json:"icon"`
+	} `json:"condition"`
+	LastUpdated string `json:"last_updated"`
+}
+
+type TemperatureResponse struct {
+	Temp_c float64 `json:"temp_c"`
+	Temp_f float64 `json:"temp_f"`
+	Temp_k float64 `json:"temp_k"`
+}
+
+type ForecastAPIResponse struct {
+	City string `json:"city"`
+	Days []struct {
+		Date      string              `json:"date"`
+		Min       TemperatureResponse `json:"min"`
+		Max       TemperatureResponse `json:"max"`
+		Avg       TemperatureResponse `json:"avg"`
+		Condition string              `json:"condition"`
+		Hours     []struct {
+			Time      string              `json:"time"`
+			Temp      TemperatureResponse `json:"temp"`
+			Condition string              `json:"condition"`
+		} `json:"hours"`
+	} `json:"days"`
+}
+
+func (t TemperatureResponse) entity() entity.Temperature {
+	return entity.Temperature{Temp_c: t.Temp_c, Temp_f: t.Temp_f, Temp_k: t.Temp_k}
 }
 
-func NewWeatherAPI() *WeatherAPI {
+func NewWeatherAPI(client *http.Client, logger *slog.Logger) *WeatherAPI {
 	serviceBURL := os.Getenv("SERVICE_B_URL")
 	if serviceBURL == "" {
 		serviceBURL = "http://localhost:8000"
 	}
 	return &WeatherAPI{
 		serviceBURL: serviceBURL,
+		client:      client,
+		logger:      logger,
 	}
 }
 
-func (w *WeatherAPI) GetCurrentWeather(ctx context.Context, cep string) (*entity.Weather, error) {
-	url := fmt.Sprintf("%s/?cep=%s", w.serviceBURL, url.QueryEscape(cep))
+// HealthCheck consulta o /readyz do serviço B: se ele não está pronto, este
+// serviço também não está. Usa um client sem instrumentação para que as
+// probes não entrem nas métricas de upstream.
+func (w *WeatherAPI) HealthCheck() telemetry.HealthCheck {
+	return telemetry.HealthCheck{Name: "serviceB", Check: telemetry.HTTPCheck(http.DefaultClient, w.serviceBURL+"/readyz")}
+}
+
+// serviceBRequest escolhe o endpoint do serviço B para a consulta. A consulta
+// por CEP continua indo para POST /.
+func serviceBRequest(weatherQuery entity.WeatherQuery) (method, route string, query url.Values) {
+	query = url.Values{}
+	switch {
+	case weatherQuery.Coordinates != nil:
+		query.Set("lat", strconv.FormatFloat(weatherQuery.Coordinates.Lat, 'f', -1, 64))
+		query.Set("lon", strconv.FormatFloat(weatherQuery.Coordinates.Lon, 'f', -1, 64))
+		return http.MethodGet, "/coordinates", query
+	case weatherQuery.City != "":
+		query.Set("city", weatherQuery.City)
+		query.Set("uf", weatherQuery.UF)
+		return http.MethodGet, "/city", query
+	default:
+		query.Set("cep", weatherQuery.CEP)
+		return http.MethodPost, "/", query
+	}
+}
 
-	// Cria a requisição com contexto
-	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+func (w *WeatherAPI) GetCurrentWeather(ctx context.Context, weatherQuery entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+	method, route, query := serviceBRequest(weatherQuery)
+	if include != (entity.Include{}) {
+		query.Set("include", include.String())
+	}
+	url := fmt.Sprintf("%s%s?%s", w.serviceBURL, route, query.Encode())
+
+	// Cria a requisição com contexto; o transport injeta os headers de propagação
+	ctx = telemetry.WithUpstream(ctx, "serviceB", route)
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	// Injeta os headers de propagação de trace
-	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if telemetry.ForceSampled(ctx) {
+		req.Header.Set(telemetry.DebugTraceHeader, "1")
+	}
 
-	client := http.Client{}
-	resp, err := client.Do(req)
+	resp, err := w.client.Do(req)
 	if err != nil {
+		w.logger.ErrorContext(ctx, "service B request failed", slog.Any("error", err))
 		return nil, err
 	}
 
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
+		w.logger.WarnContext(ctx, "service B returned an error", slog.Int("http.response.status_code", resp.StatusCode))
 		return nil, fmt.Errorf("failed to get location data: status code %d", resp.StatusCode)
 	}
 
@@ -70,12 +239,236 @@ func (w *WeatherAPI) GetCurrentWeather(ctx context.Context, cep string) (*entity
 		return nil, err
 	}
 
-	weatherData := entity.NewWeather(
-		weatherResponse.City,
-		weatherResponse.Temp_c,
-		weatherResponse.Temp_f,
-		weatherResponse.Temp_k,
-	)
+	return weatherResponse.weather(), nil
+}
+
+// weather converte a resposta do serviço B; os blocos opcionais ausentes
+// ficam nil.
+func (r *WeatherAPIResponse) weather() *entity.Weather {
+	weatherData := entity.NewWeather(r.City, r.Temp_c, r.Temp_f, r.Temp_k)
+	if r.ConditionsResponse != nil {
+		weatherData.Conditions = r.ConditionsResponse.entity()
+	}
+	if r.AirQuality != nil {
+		weatherData.AirQuality = r.AirQuality.entity()
+	}
+	if r.Location != nil {
+		weatherData.Location = r.Location.entity()
+	}
+	return weatherData
+}
+
+func (c *ConditionsResponse) entity() *entity.Conditions {
+	conditions := &entity.Conditions{
+		Humidity: c.Humidity,
+		Wind: entity.Wind{
+			SpeedKph:  c.Wind.SpeedKph,
+			SpeedMph:  c.Wind.SpeedMph,
+			Degree:    c.Wind.Degree,
+			Direction: c.Wind.Direction,
+		},
+		Pressure:  entity.Pressure{Mb: c.Pressure.Mb, In: c.Pressure.In},
+		UV:        c.UV,
+		FeelsLike: c.FeelsLike.entity(),
+		HeatIndex: c.HeatIndex.entity(),
+		Text:      c.Condition.Text,
+		Icon:      c.Condition.Icon,
+	}
+	// Um last_updated inválido não invalida o restante da resposta
+	if lastUpdated, err := time.Parse(time.RFC3339, c.LastUpdated); err == nil {
+		conditions.LastUpdated = lastUpdated
+	}
+	return conditions
+}
+
+func (w *WeatherAPI) GetForecast(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error) {
+	query := url.Values{}
+	query.Set("cep", cep)
+	query.Set("days", strconv.Itoa(days))
+	query.Set("hourly", strconv.FormatBool(hourly))
+	url := fmt.Sprintf("%s/forecast?%s", w.serviceBURL, query.Encode())
+
+	ctx = telemetry.WithUpstream(ctx, "serviceB", "/forecast")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if telemetry.ForceSampled(ctx) {
+		req.Header.Set(telemetry.DebugTraceHeader, "1")
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		w.logger.ErrorContext(ctx, "service B request failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		w.logger.WarnContext(ctx, "service B returned an error", slog.Int("http.response.status_code", resp.StatusCode))
+		return nil, fmt.Errorf("failed to get forecast data: status code %d", resp.StatusCode)
+	}
+
+	var forecastResponse ForecastAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&forecastResponse); err != nil {
+		return nil, err
+	}
+
+	forecast := &entity.Forecast{City: forecastResponse.City}
+	for _, day := range forecastResponse.Days {
+		forecastDay := entity.ForecastDay{
+			Date:      day.Date,
+			MinTemp:   day.Min.entity(),
+			MaxTemp:   day.Max.entity(),
+			AvgTemp:   day.Avg.entity(),
+			Condition: day.Condition,
+		}
+		for _, hour := range day.Hours {
+			forecastDay.Hours = append(forecastDay.Hours, entity.ForecastHour{
+				Time:      hour.Time,
+				Temp:      hour.Temp.entity(),
+				Condition: hour.Condition,
+			})
+		}
+		forecast.Days = append(forecast.Days, forecastDay)
+	}
+
+	return forecast, nil
+}
+
+// SearchAddress repassa a busca de endereços para o GET /search do serviço B,
+// que faz a consulta ao ViaCEP e, com withWeather, o clima de cada candidato.
+func (w *WeatherAPI) SearchAddress(ctx context.Context, search entity.AddressSearch, withWeather bool, include entity.Include) ([]entity.AddressCandidate, error) {
+	query := url.Values{}
+	query.Set("uf", search.UF)
+	query.Set("city", search.City)
+	query.Set("street", search.Street)
+	if withWeather {
+		query.Set("weather", "true")
+	}
+	if include != (entity.Include{}) {
+		query.Set("include", include.String())
+	}
+	url := fmt.Sprintf("%s/search?%s", w.serviceBURL, query.Encode())
+
+	ctx = telemetry.WithUpstream(ctx, "serviceB", "/search")
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if telemetry.ForceSampled(ctx) {
+		req.Header.Set(telemetry.DebugTraceHeader, "1")
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		w.logger.ErrorContext(ctx, "service B request failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		w.logger.WarnContext(ctx, "service B returned an error", slog.Int("http.response.status_code", resp.StatusCode))
+		return nil, fmt.Errorf("failed to search address: status code %d", resp.StatusCode)
+	}
+
+	var searchResponse AddressSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&searchResponse); err != nil {
+		return nil, err
+	}
+
+	candidates := make([]entity.AddressCandidate, 0, len(searchResponse.Results))
+	for _, result := range searchResponse.Results {
+		candidate := entity.AddressCandidate{
+			Address: entity.Address{
+				CEP:          result.CEP,
+				Street:       result.Street,
+				Complement:   result.Complement,
+				Neighborhood: result.Neighborhood,
+				City:         result.City,
+				State:        result.State,
+				IBGE:         result.IBGE,
+			},
+			WeatherError: result.Error,
+		}
+		if result.Weather != nil {
+			candidate.Weather = result.Weather.weather()
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// GetBatchWeather repassa o lote ao POST /batch do serviço B pedindo NDJSON,
+// e chama yield para cada linha assim que ela chega. Os status de cada CEP são
+// os do serviço B.
+func (w *WeatherAPI) GetBatchWeather(ctx context.Context, ceps []string, include entity.Include, yield func(item entity.BatchItem)) error {
+	query := url.Values{}
+	if include != (entity.Include{}) {
+		query.Set("include", include.String())
+	}
+	url := fmt.Sprintf("%s/batch?%s", w.serviceBURL, query.Encode())
 
-	return weatherData, nil
+	body, err := json.Marshal(struct {
+		CEPs []string `json:"ceps"`
+	}{CEPs: ceps})
+	if err != nil {
+		return err
+	}
+
+	ctx = telemetry.WithUpstream(ctx, "serviceB", "/batch")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	if telemetry.ForceSampled(ctx) {
+		req.Header.Set(telemetry.DebugTraceHeader, "1")
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		w.logger.ErrorContext(ctx, "service B request failed", slog.Any("error", err))
+		return err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		w.logger.WarnContext(ctx, "service B returned an error", slog.Int("http.response.status_code", resp.StatusCode))
+		// Um 4xx é um problema do lote, não do serviço B: repassa a mensagem dele
+		if resp.StatusCode >= http.StatusBadRequest && resp.StatusCode < http.StatusInternalServerError {
+			message, _ := io.ReadAll(io.LimitReader(resp.Body, maxBatchErrorSize))
+			if text := strings.TrimSpace(string(message)); text != "" {
+				return fmt.Errorf("%w: %s", entity.ErrBatchRejected, text)
+			}
+			return fmt.Errorf("%w: status code %d", entity.ErrBatchRejected, resp.StatusCode)
+		}
+		return fmt.Errorf("failed to get batch data: status code %d", resp.StatusCode)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var itemResponse BatchItemResponse
+		if err := decoder.Decode(&itemResponse); err == io.EOF {
+			return nil
+		} else if err != nil {
+			w.logger.ErrorContext(ctx, "failed to read service B batch response", slog.Any("error", err))
+			return err
+		}
+
+		item := entity.BatchItem{
+			Index:  itemResponse.Index,
+			CEP:    itemResponse.CEP,
+			Status: itemResponse.Status,
+			Error:  itemResponse.Error,
+		}
+		if itemResponse.Weather != nil {
+			item.Weather = itemResponse.Weather.weather()
+		}
+		yield(item)
+	}
 }
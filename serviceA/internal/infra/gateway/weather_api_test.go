@@ -0,0 +1,360 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWeatherAPI_PropagatesContext(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Write([]byte(`{"city":"São Paulo","temp_c":25,"temp_f":77,"temp_k":298}`))
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	propagator, err := telemetry.PropagatorsFromEnv("")
+	require.NoError(t, err)
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagator)
+	defer otel.SetTextMapPropagator(previous)
+
+	metrics, err := telemetry.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	require.NoError(t, err)
+
+	member, err := baggage.NewMember("client.app", "mobile")
+	require.NoError(t, err)
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+	ctx := baggage.ContextWithBaggage(context.Background(), bag)
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	client := telemetry.NewHTTPClient(tracer, metrics)
+
+	weather, err := NewWeatherAPI(client, slog.New(slog.DiscardHandler)).GetCurrentWeather(ctx, entity.NewCEPQuery("01001000"), entity.Include{})
+	require.NoError(t, err)
+	assert.Equal(t, "São Paulo", weather.City)
+	assert.Equal(t, "client.app=mobile", received.Get("baggage"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "POST /", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Equal(t, spans[0].SpanContext().SpanID().String(), received.Get("traceparent")[36:52])
+}
+
+func TestWeatherAPI_GetCurrentWeatherConditions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"city":"São Paulo","temp_c":25,"temp_f":77,"temp_k":298,"humidity":65,` +
+			`"wind":{"speed_kph":14.4,"speed_mph":8.9,"degree":120,"direction":"ESE"},` +
+			`"pressure":{"mb":1015,"in":29.97},"uv":6,` +
+			`"feels_like":{"temp_c":27,"temp_f":80.6,"temp_k":300},` +
+			`"heat_index":{"temp_c":28,"temp_f":82.4,"temp_k":301},` +
+			`"condition":{"text":"Partly cloudy","icon":"https://cdn.weatherapi.com/weather/64x64/day/116.png"},` +
+			`"last_updated":"2026-10-17T14:30:00Z"}`))
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	weather, err := NewWeatherAPI(http.DefaultClient, slog.New(slog.DiscardHandler)).GetCurrentWeather(context.Background(), entity.NewCEPQuery("01001000"), entity.Include{})
+	require.NoError(t, err)
+	require.NotNil(t, weather.Conditions)
+
+	conditions := weather.Conditions
+	assert.Equal(t, 65, conditions.Humidity)
+	assert.Equal(t, entity.Wind{SpeedKph: 14.4, SpeedMph: 8.9, Degree: 120, Direction: "ESE"}, conditions.Wind)
+	assert.Equal(t, entity.Pressure{Mb: 1015, In: 29.97}, conditions.Pressure)
+	assert.Equal(t, 6.0, conditions.UV)
+	assert.Equal(t, 300.0, conditions.FeelsLike.Temp_k)
+	assert.Equal(t, 82.4, conditions.HeatIndex.Temp_f)
+	assert.Equal(t, "Partly cloudy", conditions.Text)
+	assert.Equal(t, "https://cdn.weatherapi.com/weather/64x64/day/116.png", conditions.Icon)
+	assert.True(t, conditions.LastUpdated.Equal(time.Date(2026, 10, 17, 14, 30, 0, 0, time.UTC)))
+}
+
+func TestWeatherAPI_GetCurrentWeatherAirQuality(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"city":"São Paulo","temp_c":25,"temp_f":77,"temp_k":298,"air_quality":` +
+			`{"co":290.4,"no2":21.8,"o3":68.7,"so2":5.4,"pm2_5":12.5,"pm10":17.9,"us_epa_index":1,"gb_defra_index":2}}`))
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	weather, err := NewWeatherAPI(http.DefaultClient, slog.New(slog.DiscardHandler)).GetCurrentWeather(context.Background(), entity.NewCEPQuery("01001000"), entity.Include{AirQuality: true})
+	require.NoError(t, err)
+	assert.Equal(t, "cep=01001000&include=aqi", query)
+	assert.Equal(t, &entity.AirQuality{CO: 290.4, NO2: 21.8, O3: 68.7, SO2: 5.4, PM2_5: 12.5, PM10: 17.9, USEPAIndex: 1, GBDEFRAIndex: 2}, weather.AirQuality)
+}
+
+func TestWeatherAPI_GetCurrentWeatherLocation(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"city":"São Paulo","temp_c":25,"temp_f":77,"temp_k":298,"location":` +
+			`{"street":"Praça da Sé","neighborhood":"Sé","city":"São Paulo","state":"SP","ibge_code":"3550308",` +
+			`"timezone":"America/Sao_Paulo","local_time":"2026-10-17T11:30:00-03:00"}}`))
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	weather, err := NewWeatherAPI(http.DefaultClient, slog.New(slog.DiscardHandler)).GetCurrentWeather(context.Background(), entity.NewCEPQuery("01001000"), entity.Include{AirQuality: true, Location: true})
+	require.NoError(t, err)
+	assert.Equal(t, "cep=01001000&include=aqi%2Clocation", query)
+	require.NotNil(t, weather.Location)
+
+	location := weather.Location
+	assert.Equal(t, "Praça da Sé", location.Street)
+	assert.Equal(t, "Sé", location.Neighborhood)
+	assert.Equal(t, "São Paulo", location.City)
+	assert.Equal(t, "SP", location.State)
+	assert.Equal(t, "3550308", location.IBGE)
+	assert.Equal(t, "America/Sao_Paulo", location.Timezone)
+	assert.Equal(t, "2026-10-17T11:30:00-03:00", location.LocalTime.Format(time.RFC3339))
+}
+
+// Um serviço B que ainda não envia as condições atuais continua compatível
+func TestWeatherAPI_GetCurrentWeatherWithoutConditions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"city":"São Paulo","temp_c":25,"temp_f":77,"temp_k":298}`))
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	weather, err := NewWeatherAPI(http.DefaultClient, slog.New(slog.DiscardHandler)).GetCurrentWeather(context.Background(), entity.NewCEPQuery("01001000"), entity.Include{})
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, weather.Temp_c)
+	assert.Nil(t, weather.Conditions)
+}
+
+func TestWeatherAPI_GetCurrentWeatherByQuery(t *testing.T) {
+	cityQuery, err := entity.NewCityQuery("São José dos Campos", "sp")
+	require.NoError(t, err)
+	coordinatesQuery, err := entity.NewCoordinatesQuery(-23.5505, -46.6333)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		query         entity.WeatherQuery
+		expectedSpan  string
+		expectedPath  string
+		expectedQuery string
+	}{
+		{"CEP", entity.NewCEPQuery("01001000"), "POST /", "/", "cep=01001000"},
+		{"City", cityQuery, "GET /city", "/city", "city=S%C3%A3o+Jos%C3%A9+dos+Campos&uf=SP"},
+		{"Coordinates", coordinatesQuery, "GET /coordinates", "/coordinates", "lat=-23.5505&lon=-46.6333"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var received *http.Request
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				w.Write([]byte(`{"city":"São Paulo","temp_c":25,"temp_f":77,"temp_k":298}`))
+			}))
+			defer server.Close()
+			t.Setenv("SERVICE_B_URL", server.URL)
+
+			metrics, err := telemetry.NewMetrics(noop.NewMeterProvider().Meter("test"))
+			require.NoError(t, err)
+			recorder := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+			client := telemetry.NewHTTPClient(tracer, metrics)
+
+			_, err = NewWeatherAPI(client, slog.New(slog.DiscardHandler)).GetCurrentWeather(context.Background(), tc.query, entity.Include{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPath, received.URL.Path)
+			assert.Equal(t, tc.expectedQuery, received.URL.RawQuery)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tc.expectedSpan, spans[0].Name())
+		})
+	}
+}
+
+func TestWeatherAPI_GetForecast(t *testing.T) {
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Clone(context.Background())
+		w.Write([]byte(`{"city":"São Paulo","days":[{"date":"2026-10-17",` +
+			`"min":{"temp_c":18,"temp_f":64.4,"temp_k":291},` +
+			`"max":{"temp_c":27,"temp_f":80.6,"temp_k":300},` +
+			`"avg":{"temp_c":22,"temp_f":71.6,"temp_k":295},` +
+			`"condition":"Sunny",` +
+			`"hours":[{"time":"2026-10-17 00:00","temp":{"temp_c":19,"temp_f":66.2,"temp_k":292},"condition":"Clear"}]}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	propagator, err := telemetry.PropagatorsFromEnv("")
+	require.NoError(t, err)
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagator)
+	defer otel.SetTextMapPropagator(previous)
+
+	metrics, err := telemetry.NewMetrics(noop.NewMeterProvider().Meter("test"))
+	require.NoError(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	client := telemetry.NewHTTPClient(tracer, metrics)
+
+	forecast, err := NewWeatherAPI(client, slog.New(slog.DiscardHandler)).GetForecast(context.Background(), "01001000", 1, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/forecast", received.URL.Path)
+	assert.Equal(t, "01001000", received.URL.Query().Get("cep"))
+	assert.Equal(t, "1", received.URL.Query().Get("days"))
+	assert.Equal(t, "true", received.URL.Query().Get("hourly"))
+
+	assert.Equal(t, "São Paulo", forecast.City)
+	require.Len(t, forecast.Days, 1)
+	assert.Equal(t, 27.0, forecast.Days[0].MaxTemp.Temp_c)
+	assert.Equal(t, 64.4, forecast.Days[0].MinTemp.Temp_f)
+	assert.Equal(t, "Sunny", forecast.Days[0].Condition)
+	require.Len(t, forecast.Days[0].Hours, 1)
+	assert.Equal(t, 292.0, forecast.Days[0].Hours[0].Temp.Temp_k)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /forecast", spans[0].Name())
+	assert.Equal(t, spans[0].SpanContext().SpanID().String(), received.Header.Get("traceparent")[36:52])
+}
+
+func TestWeatherAPI_GetForecastError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	_, err := NewWeatherAPI(http.DefaultClient, slog.New(slog.DiscardHandler)).GetForecast(context.Background(), "99999999", 3, false)
+	assert.Error(t, err)
+}
+
+func TestWeatherAPI_SearchAddress(t *testing.T) {
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		w.Write([]byte(`{"results":[` +
+			`{"cep":"01310100","street":"Avenida Paulista","complement":"de 612 a 1510 - lado par","neighborhood":"Bela Vista","city":"São Paulo","state":"SP","ibge_code":"3550308",` +
+			`"weather":{"city":"São Paulo","temp_c":25,"temp_f":77,"temp_k":298,"air_quality":{"us_epa_index":1}}},` +
+			`{"cep":"01311000","street":"Avenida Paulista","neighborhood":"Bela Vista","city":"São Paulo","state":"SP","ibge_code":"3550308","error":"Can not find zipcode"}]}`))
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	search, err := entity.NewAddressSearch("SP", "São Paulo", "Avenida Paulista")
+	require.NoError(t, err)
+
+	candidates, err := NewWeatherAPI(http.DefaultClient, slog.New(slog.DiscardHandler)).SearchAddress(context.Background(), search, true, entity.Include{AirQuality: true})
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodGet, received.Method)
+	assert.Equal(t, "/search", received.URL.Path)
+	assert.Equal(t, "city=S%C3%A3o+Paulo&include=aqi&street=Avenida+Paulista&uf=SP&weather=true", received.URL.RawQuery)
+	require.Len(t, candidates, 2)
+
+	assert.Equal(t, entity.Address{CEP: "01310100", Street: "Avenida Paulista", Complement: "de 612 a 1510 - lado par", Neighborhood: "Bela Vista", City: "São Paulo", State: "SP", IBGE: "3550308"}, candidates[0].Address)
+	require.NotNil(t, candidates[0].Weather)
+	assert.Equal(t, 25.0, candidates[0].Weather.Temp_c)
+	require.NotNil(t, candidates[0].Weather.AirQuality)
+	assert.Equal(t, 1, candidates[0].Weather.AirQuality.USEPAIndex)
+	assert.Nil(t, candidates[1].Weather)
+	assert.Equal(t, "Can not find zipcode", candidates[1].WeatherError)
+}
+
+func TestWeatherAPI_SearchAddressError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Can not find address", http.StatusNotFound)
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	search, err := entity.NewAddressSearch("SP", "São Paulo", "Rua Inexistente")
+	require.NoError(t, err)
+
+	candidates, err := NewWeatherAPI(http.DefaultClient, slog.New(slog.DiscardHandler)).SearchAddress(context.Background(), search, false, entity.Include{})
+	assert.EqualError(t, err, "failed to search address: status code 404")
+	assert.Nil(t, candidates)
+}
+
+func TestWeatherAPI_GetBatchWeather(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"index":1,"cep":"99999999","status":404,"error":"Can not find zipcode"}` + "\n" +
+			`{"index":0,"cep":"01001000","status":200,"weather":{"city":"São Paulo","temp_c":25,"temp_f":77,"temp_k":298}}` + "\n" +
+			`{"index":2,"cep":"123","status":422,"error":"Invalid zipcode"}` + "\n"))
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	var items []entity.BatchItem
+	err := NewWeatherAPI(http.DefaultClient, slog.New(slog.DiscardHandler)).GetBatchWeather(context.Background(), []string{"01001000", "99999999", "123"}, entity.Include{Location: true}, func(item entity.BatchItem) {
+		items = append(items, item)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, "/batch", received.URL.Path)
+	assert.Equal(t, "include=location", received.URL.RawQuery)
+	assert.Equal(t, "application/x-ndjson", received.Header.Get("Accept"))
+	assert.JSONEq(t, `{"ceps":["01001000","99999999","123"]}`, string(body))
+
+	require.Len(t, items, 3)
+	assert.Equal(t, entity.BatchItem{Index: 1, CEP: "99999999", Status: http.StatusNotFound, Error: "Can not find zipcode"}, items[0])
+	assert.Equal(t, 0, items[1].Index)
+	assert.Equal(t, http.StatusOK, items[1].Status)
+	require.NotNil(t, items[1].Weather)
+	assert.Equal(t, "São Paulo", items[1].Weather.City)
+	assert.Equal(t, http.StatusUnprocessableEntity, items[2].Status)
+}
+
+func TestWeatherAPI_GetBatchWeatherError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Batch exceeds the limit of 10 zipcodes", http.StatusUnprocessableEntity)
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	err := NewWeatherAPI(http.DefaultClient, slog.New(slog.DiscardHandler)).GetBatchWeather(context.Background(), []string{"01001000"}, entity.Include{}, func(entity.BatchItem) {
+		t.Error("yield should not be called when service B rejects the batch")
+	})
+	assert.ErrorIs(t, err, entity.ErrBatchRejected)
+	assert.EqualError(t, err, "batch rejected by service B: Batch exceeds the limit of 10 zipcodes")
+}
+
+func TestWeatherAPI_GetBatchWeatherUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	t.Setenv("SERVICE_B_URL", server.URL)
+
+	err := NewWeatherAPI(http.DefaultClient, slog.New(slog.DiscardHandler)).GetBatchWeather(context.Background(), []string{"01001000"}, entity.Include{}, func(entity.BatchItem) {})
+	assert.NotErrorIs(t, err, entity.ErrBatchRejected)
+	assert.EqualError(t, err, "failed to get batch data: status code 503")
+}
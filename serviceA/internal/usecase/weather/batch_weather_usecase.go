@@ -0,0 +1,80 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+	domainGateway "github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/gateway"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const DefaultBatchMaxCEPs = 100
+
+// BatchConfig reúne as variáveis BATCH_*.
+type BatchConfig struct {
+	// MaxCEPs é BATCH_MAX_CEPS: o tamanho máximo do lote, antes de remover os
+	// duplicados. Padrão: 100.
+	MaxCEPs int
+}
+
+// BatchConfigFromEnv lê BATCH_MAX_CEPS. Em caso de erro, devolve o padrão.
+func BatchConfigFromEnv(getenv func(string) string) (BatchConfig, error) {
+	cfg := BatchConfig{MaxCEPs: DefaultBatchMaxCEPs}
+
+	raw := getenv("BATCH_MAX_CEPS")
+	if raw == "" {
+		return cfg, nil
+	}
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed <= 0 {
+		return cfg, fmt.Errorf("invalid BATCH_MAX_CEPS %q: must be a positive integer", raw)
+	}
+	cfg.MaxCEPs = parsed
+	return cfg, nil
+}
+
+// BatchWeatherUseCase repassa o lote ao POST /batch do serviço B, que remove
+// os CEPs repetidos, consulta os demais em paralelo e devolve o status de cada
+// um.
+type BatchWeatherUseCase struct {
+	weatherGateway domainGateway.WeatherGateway
+	tracer         trace.Tracer
+	logger         *slog.Logger
+	cfg            BatchConfig
+}
+
+func NewBatchWeatherUseCase(gateway domainGateway.WeatherGateway, tracer trace.Tracer, logger *slog.Logger, cfg BatchConfig) *BatchWeatherUseCase {
+	if cfg.MaxCEPs <= 0 {
+		cfg.MaxCEPs = DefaultBatchMaxCEPs
+	}
+	return &BatchWeatherUseCase{weatherGateway: gateway, tracer: tracer, logger: logger, cfg: cfg}
+}
+
+// GetCurrentWeather valida o lote e chama yield uma vez por CEP único, na
+// ordem em que o serviço B termina as consultas. yield é sempre chamado na
+// goroutine de quem chamou; um lote inválido retorna o erro sem chamar yield.
+func (b *BatchWeatherUseCase) GetCurrentWeather(ctx context.Context, ceps []string, include entity.Include, yield func(item entity.BatchItem)) error {
+	var err error
+	switch {
+	case len(ceps) == 0:
+		err = entity.ErrBatchEmpty
+	case len(ceps) > b.cfg.MaxCEPs:
+		err = fmt.Errorf("%w (%d)", entity.ErrBatchTooLarge, b.cfg.MaxCEPs)
+	}
+	if err != nil {
+		b.logger.InfoContext(ctx, "rejected invalid batch", slog.Int("batch.size", len(ceps)))
+		return err
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("batch.size", len(ceps)))
+
+	// Span para chamada ao gateway; os spans batch_item ficam no serviço B
+	ctx, spanGetBatch := b.tracer.Start(ctx, "call_service_b")
+	err = b.weatherGateway.GetBatchWeather(ctx, ceps, include, yield)
+	telemetry.EndSpan(spanGetBatch, err)
+	return err
+}
@@ -0,0 +1,101 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func newBatchUseCase(gateway *MockWeatherGateway, tracer trace.Tracer, cfg BatchConfig) *BatchWeatherUseCase {
+	return NewBatchWeatherUseCase(gateway, tracer, slog.New(slog.DiscardHandler), cfg)
+}
+
+func runBatch(batch *BatchWeatherUseCase, ctx context.Context, ceps []string) ([]entity.BatchItem, error) {
+	var items []entity.BatchItem
+	err := batch.GetCurrentWeather(ctx, ceps, entity.Include{}, func(item entity.BatchItem) {
+		items = append(items, item)
+	})
+	return items, err
+}
+
+func TestBatchWeatherUseCase_ForwardsServiceBStatus(t *testing.T) {
+	// Arrange
+	mockGateway := new(MockWeatherGateway)
+	batch := newBatchUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), BatchConfig{MaxCEPs: 10})
+	ceps := []string{"01001-000", "99999999", "01001000", "123"}
+	serviceBItems := []entity.BatchItem{
+		{Index: 1, CEP: "99999999", Status: 404, Error: "Can not find zipcode"},
+		{Index: 0, CEP: "01001000", Status: 200, Weather: entity.NewWeather("São Paulo", 25, 77, 298)},
+		{Index: 2, CEP: "123", Status: 422, Error: "Invalid zipcode"},
+	}
+	mockGateway.On("GetBatchWeather", mock.Anything, ceps, entity.Include{}).Return(serviceBItems, nil).Once()
+
+	// Act
+	items, err := runBatch(batch, context.Background(), ceps)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, serviceBItems, items)
+	mockGateway.AssertExpectations(t)
+}
+
+func TestBatchWeatherUseCase_InvalidBatch(t *testing.T) {
+	mockGateway := new(MockWeatherGateway)
+	batch := newBatchUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), BatchConfig{MaxCEPs: 2})
+
+	items, err := runBatch(batch, context.Background(), nil)
+	assert.ErrorIs(t, err, entity.ErrBatchEmpty)
+	assert.Empty(t, items)
+
+	items, err = runBatch(batch, context.Background(), []string{"01001000", "01001000", "01001000"})
+	assert.ErrorIs(t, err, entity.ErrBatchTooLarge)
+	assert.EqualError(t, err, "batch exceeds the zipcode limit (2)")
+	assert.Empty(t, items)
+
+	mockGateway.AssertNotCalled(t, "GetBatchWeather", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBatchWeatherUseCase_GatewayError(t *testing.T) {
+	// Arrange
+	mockGateway := new(MockWeatherGateway)
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	batch := newBatchUseCase(mockGateway, tracer, BatchConfig{})
+	gatewayError := errors.New("failed to get batch data: status code 502")
+	mockGateway.On("GetBatchWeather", mock.Anything, []string{"01001000"}, entity.Include{}).Return(nil, gatewayError)
+
+	// Act
+	ctx, root := tracer.Start(context.Background(), "POST /batch")
+	items, err := runBatch(batch, ctx, []string{"01001000"})
+	root.End()
+
+	// Assert
+	assert.Equal(t, gatewayError, err)
+	assert.Empty(t, items)
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "call_service_b", spans[0].Name())
+	assert.Equal(t, root.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestBatchConfigFromEnv(t *testing.T) {
+	cfg, err := BatchConfigFromEnv(func(key string) string { return map[string]string{"BATCH_MAX_CEPS": "0"}[key] })
+	assert.Error(t, err)
+	assert.Equal(t, BatchConfig{MaxCEPs: DefaultBatchMaxCEPs}, cfg)
+
+	cfg, err = BatchConfigFromEnv(func(key string) string { return map[string]string{"BATCH_MAX_CEPS": "20"}[key] })
+	assert.NoError(t, err)
+	assert.Equal(t, BatchConfig{MaxCEPs: 20}, cfg)
+}
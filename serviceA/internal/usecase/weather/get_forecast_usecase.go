@@ -0,0 +1,35 @@
+package weather
+
+import (
+	"context"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/pkg/utility"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+)
+
+func (w *WeatherUseCase) GetForecast(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error) {
+	if err := entity.ValidateForecastDays(days); err != nil {
+		w.logger.InfoContext(ctx, "rejected invalid forecast days")
+		return nil, err
+	}
+
+	// Span para validação do CEP
+	_, spanValidateCep := w.tracer.Start(ctx, "validate_cep")
+	cepFormated, err := utility.CEPFormatter(cep)
+	telemetry.EndSpan(spanValidateCep, err)
+	if err != nil {
+		w.logger.InfoContext(ctx, "rejected invalid zipcode")
+		return nil, err
+	}
+
+	// Span para chamada ao gateway
+	ctx, spanGetForecast := w.tracer.Start(ctx, "call_service_b")
+	forecast, err := w.weatherGateway.GetForecast(ctx, cepFormated, days, hourly)
+	telemetry.EndSpan(spanGetForecast, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return forecast, nil
+}
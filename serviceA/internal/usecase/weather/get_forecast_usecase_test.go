@@ -0,0 +1,72 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestWeatherUseCase_GetForecast_Success(t *testing.T) {
+	// Arrange
+	mockGateway := new(MockWeatherGateway)
+	usecase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+	expectedForecast := &entity.Forecast{
+		City: "Test City",
+		Days: []entity.ForecastDay{{Date: "2026-10-17", Condition: "Sunny"}},
+	}
+
+	mockGateway.On("GetForecast", mock.Anything, "12345678", 5, true).Return(expectedForecast, nil)
+
+	// Act
+	forecast, err := usecase.GetForecast(context.Background(), "12345-678", 5, true)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, expectedForecast, forecast)
+	mockGateway.AssertExpectations(t)
+}
+
+func TestWeatherUseCase_GetForecast_InvalidDays(t *testing.T) {
+	mockGateway := new(MockWeatherGateway)
+	usecase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	for _, days := range []int{0, entity.MaxForecastDays + 1} {
+		forecast, err := usecase.GetForecast(context.Background(), "12345678", days, false)
+
+		assert.ErrorIs(t, err, entity.ErrInvalidForecastDays)
+		assert.Nil(t, forecast)
+	}
+	mockGateway.AssertNotCalled(t, "GetForecast", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWeatherUseCase_GetForecast_InvalidCEP(t *testing.T) {
+	mockGateway := new(MockWeatherGateway)
+	usecase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	forecast, err := usecase.GetForecast(context.Background(), "12345", entity.DefaultForecastDays, false)
+
+	assert.Error(t, err)
+	assert.Nil(t, forecast)
+	assert.Equal(t, "invalid zipcode", err.Error())
+	mockGateway.AssertNotCalled(t, "GetForecast", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWeatherUseCase_GetForecast_GatewayError(t *testing.T) {
+	mockGateway := new(MockWeatherGateway)
+	usecase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+	gatewayError := errors.New("gateway failed")
+
+	mockGateway.On("GetForecast", mock.Anything, "87654321", entity.DefaultForecastDays, false).Return(nil, gatewayError)
+
+	forecast, err := usecase.GetForecast(context.Background(), "87654321", entity.DefaultForecastDays, false)
+
+	assert.Equal(t, gatewayError, err)
+	assert.Nil(t, forecast)
+	mockGateway.AssertExpectations(t)
+}
@@ -2,35 +2,70 @@ package weather
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
 	domainGateway "github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/gateway"
 	"github.com/adalbertofjr/lab-2-go-service-a-otel/pkg/utility"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type WeatherUseCase struct {
 	weatherGateway domainGateway.WeatherGateway
 	tracer         trace.Tracer
+	logger         *slog.Logger
 }
 
-func NewWeatherUseCase(gateway domainGateway.WeatherGateway, tracer trace.Tracer) *WeatherUseCase {
-	return &WeatherUseCase{weatherGateway: gateway, tracer: tracer}
+func NewWeatherUseCase(gateway domainGateway.WeatherGateway, tracer trace.Tracer, logger *slog.Logger) *WeatherUseCase {
+	return &WeatherUseCase{weatherGateway: gateway, tracer: tracer, logger: logger}
 }
 
-func (w *WeatherUseCase) GetCurrentWeather(ctx context.Context, cep string) (*entity.Weather, error) {
+func (w *WeatherUseCase) GetCurrentWeather(ctx context.Context, cep string, include entity.Include) (*entity.Weather, error) {
 	// Span para validação do CEP
-	ctx, spanValidateCep := w.tracer.Start(ctx, "validate_cep")
+	_, spanValidateCep := w.tracer.Start(ctx, "validate_cep")
 	cepFormated, err := utility.CEPFormatter(cep)
-	spanValidateCep.End()
+	telemetry.EndSpan(spanValidateCep, err)
 	if err != nil {
+		w.logger.InfoContext(ctx, "rejected invalid zipcode")
 		return nil, err
 	}
 
+	return w.fetchCurrentWeather(ctx, entity.NewCEPQuery(cepFormated), include)
+}
+
+// GetCurrentWeatherByCity consulta o clima pelo nome da cidade e a UF.
+func (w *WeatherUseCase) GetCurrentWeatherByCity(ctx context.Context, city, uf string, include entity.Include) (*entity.Weather, error) {
+	_, spanValidateCity := w.tracer.Start(ctx, "validate_city")
+	query, err := entity.NewCityQuery(city, uf)
+	telemetry.EndSpan(spanValidateCity, err)
+	if err != nil {
+		w.logger.InfoContext(ctx, "rejected invalid city")
+		return nil, err
+	}
+
+	return w.fetchCurrentWeather(ctx, query, include)
+}
+
+// GetCurrentWeatherByCoordinates consulta o clima por latitude e longitude.
+func (w *WeatherUseCase) GetCurrentWeatherByCoordinates(ctx context.Context, lat, lon float64, include entity.Include) (*entity.Weather, error) {
+	_, spanValidateCoordinates := w.tracer.Start(ctx, "validate_coordinates")
+	query, err := entity.NewCoordinatesQuery(lat, lon)
+	telemetry.EndSpan(spanValidateCoordinates, err)
+	if err != nil {
+		w.logger.InfoContext(ctx, "rejected invalid coordinates")
+		return nil, err
+	}
+
+	return w.fetchCurrentWeather(ctx, query, include)
+}
+
+// fetchCurrentWeather é a parte comum das consultas, depois da validação.
+func (w *WeatherUseCase) fetchCurrentWeather(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
 	// Span para chamada ao gateway
 	ctx, spanGetWeather := w.tracer.Start(ctx, "call_service_b")
-	weatherData, err := w.weatherGateway.GetCurrentWeather(ctx, cepFormated)
-	spanGetWeather.End()
+	weatherData, err := w.weatherGateway.GetCurrentWeather(ctx, query, include)
+	telemetry.EndSpan(spanGetWeather, err)
 	if err != nil {
 		return nil, err
 	}
@@ -41,6 +76,9 @@ func (w *WeatherUseCase) GetCurrentWeather(ctx context.Context, cep string) (*en
 		weatherData.Temp_f,
 		weatherData.Temp_k,
 	)
+	currentWeather.Conditions = weatherData.Conditions
+	currentWeather.AirQuality = weatherData.AirQuality
+	currentWeather.Location = weatherData.Location
 
 	return currentWeather, nil
 }
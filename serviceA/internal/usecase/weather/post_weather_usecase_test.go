@@ -3,6 +3,7 @@ package weather
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"testing"
 
 	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
@@ -16,19 +17,45 @@ type MockWeatherGateway struct {
 	mock.Mock
 }
 
-func (m *MockWeatherGateway) GetCurrentWeather(ctx context.Context, cep string) (*entity.Weather, error) {
-	args := m.Called(ctx, cep)
+func (m *MockWeatherGateway) GetCurrentWeather(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+	args := m.Called(ctx, query, include)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*entity.Weather), args.Error(1)
 }
 
+func (m *MockWeatherGateway) GetForecast(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error) {
+	args := m.Called(ctx, cep, days, hourly)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Forecast), args.Error(1)
+}
+
+func (m *MockWeatherGateway) SearchAddress(ctx context.Context, search entity.AddressSearch, withWeather bool, include entity.Include) ([]entity.AddressCandidate, error) {
+	args := m.Called(ctx, search, withWeather, include)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]entity.AddressCandidate), args.Error(1)
+}
+
+func (m *MockWeatherGateway) GetBatchWeather(ctx context.Context, ceps []string, include entity.Include, yield func(item entity.BatchItem)) error {
+	args := m.Called(ctx, ceps, include)
+	if items, ok := args.Get(0).([]entity.BatchItem); ok {
+		for _, item := range items {
+			yield(item)
+		}
+	}
+	return args.Error(1)
+}
+
 func TestWeatherUseCase_GetCurrentWeather_Success(t *testing.T) {
 	// Arrange
 	mockGateway := new(MockWeatherGateway)
 	mockTracer := noop.NewTracerProvider().Tracer("test")
-	usecase := NewWeatherUseCase(mockGateway, mockTracer)
+	usecase := NewWeatherUseCase(mockGateway, mockTracer, slog.New(slog.DiscardHandler))
 	ctx := context.Background()
 	cep := "12345678"
 	expectedWeather := &entity.Weather{
@@ -38,10 +65,10 @@ func TestWeatherUseCase_GetCurrentWeather_Success(t *testing.T) {
 		Temp_k: 298.15,
 	}
 
-	mockGateway.On("GetCurrentWeather", mock.Anything, "12345678").Return(expectedWeather, nil)
+	mockGateway.On("GetCurrentWeather", mock.Anything, entity.NewCEPQuery("12345678"), entity.Include{}).Return(expectedWeather, nil)
 
 	// Act
-	weather, err := usecase.GetCurrentWeather(ctx, cep)
+	weather, err := usecase.GetCurrentWeather(ctx, cep, entity.Include{})
 
 	// Assert
 	assert.NoError(t, err)
@@ -55,33 +82,33 @@ func TestWeatherUseCase_GetCurrentWeather_InvalidCEP(t *testing.T) {
 	// Arrange
 	mockGateway := new(MockWeatherGateway)
 	mockTracer := noop.NewTracerProvider().Tracer("test")
-	usecase := NewWeatherUseCase(mockGateway, mockTracer)
+	usecase := NewWeatherUseCase(mockGateway, mockTracer, slog.New(slog.DiscardHandler))
 	ctx := context.Background()
 	cep := "12345" // Invalid CEP
 
 	// Act
-	weather, err := usecase.GetCurrentWeather(ctx, cep)
+	weather, err := usecase.GetCurrentWeather(ctx, cep, entity.Include{})
 
 	// Assert
 	assert.Error(t, err)
 	assert.Nil(t, weather)
 	assert.Equal(t, "invalid zipcode", err.Error())
-	mockGateway.AssertNotCalled(t, "GetCurrentWeather", mock.Anything)
+	mockGateway.AssertNotCalled(t, "GetCurrentWeather", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestWeatherUseCase_GetCurrentWeather_GatewayError(t *testing.T) {
 	// Arrange
 	mockGateway := new(MockWeatherGateway)
 	mockTracer := noop.NewTracerProvider().Tracer("test")
-	usecase := NewWeatherUseCase(mockGateway, mockTracer)
+	usecase := NewWeatherUseCase(mockGateway, mockTracer, slog.New(slog.DiscardHandler))
 	ctx := context.Background()
 	cep := "87654321"
 	gatewayError := errors.New("gateway failed")
 
-	mockGateway.On("GetCurrentWeather", "87654321").Return(nil, gatewayError)
+	mockGateway.On("GetCurrentWeather", mock.Anything, entity.NewCEPQuery("87654321"), entity.Include{}).Return(nil, gatewayError)
 
 	// Act
-	weather, err := usecase.GetCurrentWeather(ctx, cep)
+	weather, err := usecase.GetCurrentWeather(ctx, cep, entity.Include{})
 
 	// Assert
 	assert.Error(t, err)
@@ -89,3 +116,76 @@ func TestWeatherUseCase_GetCurrentWeather_GatewayError(t *testing.T) {
 	assert.Equal(t, gatewayError, err)
 	mockGateway.AssertExpectations(t)
 }
+
+func TestWeatherUseCase_GetCurrentWeather_KeepsOptionalData(t *testing.T) {
+	// Arrange
+	mockGateway := new(MockWeatherGateway)
+	usecase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+	include := entity.Include{AirQuality: true, Location: true}
+	conditions := &entity.Conditions{Humidity: 80, Text: "Light rain"}
+	airQuality := &entity.AirQuality{PM10: 20, USEPAIndex: 1}
+	location := &entity.Location{City: "Curitiba", State: "PR", Timezone: "America/Sao_Paulo"}
+	gatewayWeather := entity.NewWeather("Curitiba", 15, 59, 288)
+	gatewayWeather.Conditions = conditions
+	gatewayWeather.AirQuality = airQuality
+	gatewayWeather.Location = location
+
+	mockGateway.On("GetCurrentWeather", mock.Anything, entity.NewCEPQuery("80010000"), include).Return(gatewayWeather, nil)
+
+	// Act
+	weather, err := usecase.GetCurrentWeather(context.Background(), "80010-000", include)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Same(t, conditions, weather.Conditions)
+	assert.Same(t, airQuality, weather.AirQuality)
+	assert.Same(t, location, weather.Location)
+	mockGateway.AssertExpectations(t)
+}
+
+func TestWeatherUseCase_GetCurrentWeatherByCity(t *testing.T) {
+	// Arrange
+	mockGateway := new(MockWeatherGateway)
+	usecase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+	expectedQuery := entity.WeatherQuery{City: "Campinas", UF: "SP"}
+
+	mockGateway.On("GetCurrentWeather", mock.Anything, expectedQuery, entity.Include{}).Return(entity.NewWeather("Campinas", 22, 71.6, 295), nil)
+
+	// Act
+	weather, err := usecase.GetCurrentWeatherByCity(context.Background(), " Campinas ", "sp", entity.Include{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Campinas", weather.City)
+	mockGateway.AssertExpectations(t)
+}
+
+func TestWeatherUseCase_GetCurrentWeatherByCity_Invalid(t *testing.T) {
+	mockGateway := new(MockWeatherGateway)
+	usecase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	weather, err := usecase.GetCurrentWeatherByCity(context.Background(), "Campinas", "XX", entity.Include{})
+
+	assert.ErrorIs(t, err, entity.ErrInvalidCity)
+	assert.Nil(t, weather)
+	mockGateway.AssertNotCalled(t, "GetCurrentWeather", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWeatherUseCase_GetCurrentWeatherByCoordinates(t *testing.T) {
+	// Arrange
+	mockGateway := new(MockWeatherGateway)
+	usecase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+	expectedQuery := entity.WeatherQuery{Coordinates: &entity.Coordinates{Lat: -22.9, Lon: -47.06}}
+
+	mockGateway.On("GetCurrentWeather", mock.Anything, expectedQuery, entity.Include{}).Return(entity.NewWeather("Campinas", 22, 71.6, 295), nil)
+
+	// Act
+	weather, err := usecase.GetCurrentWeatherByCoordinates(context.Background(), -22.9, -47.06, entity.Include{})
+	_, invalidErr := usecase.GetCurrentWeatherByCoordinates(context.Background(), 0, 181, entity.Include{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Campinas", weather.City)
+	assert.ErrorIs(t, invalidErr, entity.ErrInvalidCoordinates)
+	mockGateway.AssertNumberOfCalls(t, "GetCurrentWeather", 1)
+}
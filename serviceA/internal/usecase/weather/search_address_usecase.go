@@ -0,0 +1,30 @@
+package weather
+
+import (
+	"context"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+)
+
+// SearchAddress valida a busca e repassa ao serviço B, que faz a consulta ao
+// ViaCEP e, com withWeather, o clima de cada candidato.
+func (w *WeatherUseCase) SearchAddress(ctx context.Context, uf, city, street string, withWeather bool, include entity.Include) ([]entity.AddressCandidate, error) {
+	_, spanValidateAddress := w.tracer.Start(ctx, "validate_address_search")
+	search, err := entity.NewAddressSearch(uf, city, street)
+	telemetry.EndSpan(spanValidateAddress, err)
+	if err != nil {
+		w.logger.InfoContext(ctx, "rejected invalid address search")
+		return nil, err
+	}
+
+	// Span para chamada ao gateway
+	ctx, spanSearchAddress := w.tracer.Start(ctx, "call_service_b")
+	candidates, err := w.weatherGateway.SearchAddress(ctx, search, withWeather, include)
+	telemetry.EndSpan(spanSearchAddress, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,60 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/adalbertofjr/lab-2-go-service-a-otel/internal/domain/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestWeatherUseCase_SearchAddress_Success(t *testing.T) {
+	// Arrange
+	mockGateway := new(MockWeatherGateway)
+	usecase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+	include := entity.Include{AirQuality: true}
+	expectedCandidates := []entity.AddressCandidate{
+		{Address: entity.Address{CEP: "01310100", Street: "Avenida Paulista"}, Weather: entity.NewWeather("São Paulo", 25, 77, 298)},
+		{Address: entity.Address{CEP: "01311000", Street: "Avenida Paulista"}, WeatherError: "Can not find zipcode"},
+	}
+
+	search := entity.AddressSearch{UF: "SP", City: "São Paulo", Street: "Avenida Paulista"}
+	mockGateway.On("SearchAddress", mock.Anything, search, true, include).Return(expectedCandidates, nil)
+
+	// Act
+	candidates, err := usecase.SearchAddress(context.Background(), "sp", " São Paulo ", "Avenida Paulista", true, include)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, expectedCandidates, candidates)
+	mockGateway.AssertExpectations(t)
+}
+
+func TestWeatherUseCase_SearchAddress_Invalid(t *testing.T) {
+	mockGateway := new(MockWeatherGateway)
+	usecase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	candidates, err := usecase.SearchAddress(context.Background(), "XX", "São Paulo", "Avenida Paulista", false, entity.Include{})
+
+	assert.ErrorIs(t, err, entity.ErrInvalidAddressSearch)
+	assert.Nil(t, candidates)
+	mockGateway.AssertNotCalled(t, "SearchAddress", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWeatherUseCase_SearchAddress_GatewayError(t *testing.T) {
+	mockGateway := new(MockWeatherGateway)
+	usecase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+	gatewayError := errors.New("gateway failed")
+
+	mockGateway.On("SearchAddress", mock.Anything, mock.Anything, false, entity.Include{}).Return(nil, gatewayError)
+
+	candidates, err := usecase.SearchAddress(context.Background(), "SP", "Campinas", "Rua Barão", false, entity.Include{})
+
+	assert.Equal(t, gatewayError, err)
+	assert.Nil(t, candidates)
+	mockGateway.AssertExpectations(t)
+}
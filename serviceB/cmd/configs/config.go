@@ -5,7 +5,9 @@ import "github.com/spf13/viper"
 type Conf struct {
 	WeatherAPIKey string `mapstructure:"WEATHERAPI_KEY"`
 	WebServerPort string `mapstructure:"WEB_SERVER_PORT"`
-	OtelEndpoint  string `mapstructure:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	LogLevel      string `mapstructure:"LOG_LEVEL"`
+	// ShutdownTimeout é o prazo para drenar as requisições no shutdown (ex.: 5s)
+	ShutdownTimeout string `mapstructure:"SHUTDOWN_TIMEOUT"`
 }
 
 func LoadConfig(path string) (*Conf, error) {
@@ -19,7 +21,8 @@ func LoadConfig(path string) (*Conf, error) {
 	// Bind explicitamente as variáveis de ambiente
 	viper.BindEnv("WEATHERAPI_KEY")
 	viper.BindEnv("WEB_SERVER_PORT")
-	viper.BindEnv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	viper.BindEnv("LOG_LEVEL")
+	viper.BindEnv("SHUTDOWN_TIMEOUT")
 
 	// Tenta ler .env, mas ignora se não existir
 	_ = viper.ReadInConfig()
@@ -30,3 +33,9 @@ func LoadConfig(path string) (*Conf, error) {
 	}
 	return cfg, err
 }
+
+// Getenv devolve a variável do ambiente ou do arquivo .env carregado por
+// LoadConfig. É usada pelo pacote telemetry para ler as variáveis OTEL_*.
+func (c *Conf) Getenv(key string) string {
+	return viper.GetString(key)
+}
@@ -2,33 +2,28 @@ package main
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/cmd/configs"
+	"go.opentelemetry.io/contrib/bridges/otelslog"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/trace"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/api"
 	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/gateway"
 	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/web"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
 
 	usecase "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/usecase/weather"
-
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
 func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
 	configs, err := configs.LoadConfig(".")
@@ -36,76 +31,119 @@ func main() {
 		panic(err)
 	}
 
-	shutdown, err := initProvider(configs.OtelEndpoint)
+	cfg, cfgErr := telemetry.ConfigFromEnv("ServiceB", configs.Getenv)
+
+	// Com OTEL_LOGS_EXPORTER=otlp os logs também são enviados ao collector
+	var bridge []slog.Handler
+	if cfg.LogsEnabled {
+		bridge = append(bridge, otelslog.NewHandler("serviceB-logger"))
+	}
+	logger := telemetry.NewLogger(cfg.ServiceName, telemetry.ParseLevel(configs.LogLevel), os.Stdout, bridge...)
+	if cfgErr != nil {
+		logger.Warn("invalid telemetry configuration, using defaults for the invalid settings", slog.Any("error", cfgErr))
+	}
+
+	healthCfg, err := telemetry.HealthConfigFromEnv(configs.Getenv)
+	if err != nil {
+		logger.Warn("invalid health check configuration, using defaults for the invalid settings", slog.Any("error", err))
+	}
+
+	profilingCfg, err := telemetry.ProfilingConfigFromEnv(configs.Getenv)
 	if err != nil {
-		log.Fatal(err)
+		logger.Warn("invalid profiling configuration, using defaults for the invalid settings", slog.Any("error", err))
 	}
-	defer func() {
-		if err := shutdown(ctx); err != nil {
-			log.Fatalf("Failed to shutdown TracerProvider: %v", err)
+	profiler := telemetry.NewProfiler(profilingCfg, logger)
+	go func() {
+		if err := profiler.ServeAdmin(ctx); err != nil {
+			logger.Error("pprof admin server failed", slog.Any("error", err))
 		}
 	}()
 
-	tracer := otel.Tracer("serviceB-tracer")
-
-	startServer(configs, tracer)
-}
-
-func startServer(configs *configs.Conf, tracer trace.Tracer) {
-	weatherGateway := gateway.NewWeatherAPI(configs.WeatherAPIKey, tracer)
-	weatherUseCase := usecase.NewWeatherUseCase(weatherGateway, tracer)
-	weatherHandler := api.NewWeatherHandler(weatherUseCase, tracer)
-	healthHandler := api.NewHealthCheck()
-
-	webserver := web.NewWebServer(configs.WebServerPort)
-	webserver.AddHandler("/", weatherHandler.GetWeather)
-	webserver.AddHandler("/health", healthHandler.HealthCheck)
-	webserver.Start()
-}
-
-func initProvider(otelEndpoint string) (func(context.Context) error, error) {
-	ctx := context.Background()
-
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName("ServiceB"),
-		),
-	)
+	batchCfg, err := usecase.BatchConfigFromEnv(configs.Getenv)
+	if err != nil {
+		logger.Warn("invalid batch configuration, using defaults for the invalid settings", slog.Any("error", err))
+	}
 
+	cepCfg, err := gateway.CEPProviderConfigFromEnv(configs.Getenv)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create Resource: %w", err)
+		logger.Warn("invalid CEP provider configuration, using defaults for the invalid settings", slog.Any("error", err))
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, time.Second)
-	defer cancel()
+	shutdownTimeout := web.DefaultShutdownTimeout
+	if configs.ShutdownTimeout != "" {
+		parsed, err := time.ParseDuration(configs.ShutdownTimeout)
+		if err != nil || parsed <= 0 {
+			logger.Warn("invalid SHUTDOWN_TIMEOUT, using default", slog.String("value", configs.ShutdownTimeout), slog.Duration("default", web.DefaultShutdownTimeout))
+		} else {
+			shutdownTimeout = parsed
+		}
+	}
 
-	if otelEndpoint == "" {
-		otelEndpoint = "localhost:4317"
+	shutdown, metricsHandler, err := telemetry.Setup(ctx, cfg)
+	if err != nil {
+		logger.Error("failed to initialize telemetry", slog.Any("error", err))
+		os.Exit(1)
 	}
 
-	conn, err := grpc.NewClient(
-		otelEndpoint,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	tracer := otel.Tracer("serviceB-tracer")
 
+	metrics, err := telemetry.NewMetrics(otel.Meter("serviceB-meter"))
 	if err != nil {
-		return nil, fmt.Errorf("Failed to create gRPC connection to collector: %w", err)
+		logger.Error("failed to create metrics", slog.Any("error", err))
+		os.Exit(1)
 	}
 
-	tracerExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
-	if err != nil {
-		return nil, fmt.Errorf("Failed to create the collector trace exporter: %w", err)
+	serverErr := startServer(ctx, shutdownTimeout, healthCfg, batchCfg, cepCfg, cfg.Exporter, cfg.DebugTraces, profiler, configs, tracer, metrics, metricsHandler, telemetry.VersionHandler(cfg.Resource), logger)
+	if serverErr != nil {
+		logger.Error("web server failed", slog.Any("error", serverErr))
 	}
 
-	bsp := sdktrace.NewBatchSpanProcessor(tracerExporter)
-	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
-		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(bsp),
-	)
+	// Só depois de drenar as requisições os spans pendentes são exportados
+	logger.Info("flushing telemetry", slog.Duration("timeout", cfg.ShutdownTimeout))
+	if err := shutdown(context.Background()); err != nil {
+		logger.Error("failed to shutdown telemetry providers", slog.Any("error", err))
+	}
+	if serverErr != nil {
+		os.Exit(1)
+	}
+}
 
-	otel.SetTracerProvider(tracerProvider)
-	otel.SetTextMapPropagator(propagation.TraceContext{})
+func startServer(ctx context.Context, shutdownTimeout time.Duration, healthCfg telemetry.HealthConfig, batchCfg usecase.BatchConfig, cepCfg gateway.CEPProviderConfig, exporterCfg telemetry.ExporterConfig, debugTraces *telemetry.DebugTraceProcessor, profiler *telemetry.Profiler, configs *configs.Conf, tracer trace.Tracer, metrics *telemetry.Metrics, metricsHandler, versionHandler http.Handler, logger *slog.Logger) error {
+	client := telemetry.NewHTTPClient(tracer, metrics)
+	cepProviders := gateway.NewCEPProviderChain(cepCfg, client, logger)
+	weatherGateway := gateway.NewWeatherAPI(configs.WeatherAPIKey, client, cepProviders, tracer, logger)
+	weatherUseCase := usecase.NewWeatherUseCase(weatherGateway, tracer, logger)
+	weatherHandler := api.NewWeatherHandler(weatherUseCase, logger)
+	batchUseCase := usecase.NewBatchWeatherUseCase(weatherUseCase, tracer, logger, batchCfg)
+	batchHandler := api.NewBatchHandler(batchUseCase, logger)
+	searchHandler := api.NewSearchHandler(usecase.NewSearchAddressUseCase(weatherGateway, batchUseCase, tracer, logger), logger)
+	checks := append([]telemetry.HealthCheck{{Name: "collector", Check: telemetry.CollectorCheck(exporterCfg)}, cepProviders.HealthCheck()}, weatherGateway.HealthChecks()...)
+	health, err := telemetry.NewHealth(tracer, logger, healthCfg, checks...)
+	if err != nil {
+		return err
+	}
 
-	return tracerProvider.Shutdown, nil
+	webserver := web.NewWebServer(configs.WebServerPort, logger)
+	webserver.ShutdownTimeout = shutdownTimeout
+	webserver.AddMiddleware(telemetry.DebugTraceMiddleware)
+	webserver.AddMiddleware(telemetry.TracingMiddleware(tracer))
+	webserver.AddMiddleware(telemetry.MetricsMiddleware(metrics))
+	webserver.AddRouteMiddleware(profiler.Middleware)
+	webserver.AddHandler("/", weatherHandler.GetWeather)
+	webserver.AddHandler("/city", weatherHandler.GetWeatherByCity)
+	webserver.AddHandler("/coordinates", weatherHandler.GetWeatherByCoordinates)
+	webserver.AddHandler("/forecast", weatherHandler.GetForecast)
+	webserver.AddHandler("/batch", batchHandler.GetWeather)
+	webserver.AddHandler("/search", searchHandler.SearchAddress)
+	// Com DEBUG_TRACES_ENABLED=true os últimos traces ficam visíveis sem o Zipkin
+	if debugTraces != nil {
+		webserver.AddHandler("/debug/traces", debugTraces.Handler().ServeHTTP)
+	}
+	// /health é o nome antigo do /healthz
+	webserver.AddOperationalHandler("/health", health.LivenessHandler())
+	webserver.AddOperationalHandler("/healthz", health.LivenessHandler())
+	webserver.AddOperationalHandler("/readyz", health.ReadinessHandler())
+	webserver.AddOperationalHandler("/metrics", metricsHandler)
+	webserver.AddOperationalHandler("/version", versionHandler)
+	return webserver.Start(ctx)
 }
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewAddressSearch(t *testing.T) {
+	search, err := NewAddressSearch(" sp ", " São Paulo", "Paulista ")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if search != (AddressSearch{UF: "SP", City: "São Paulo", Street: "Paulista"}) {
+		t.Errorf("Unexpected search: %+v", search)
+	}
+}
+
+func TestNewAddressSearch_Invalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		uf     string
+		city   string
+		street string
+	}{
+		{"UnknownUF", "XX", "São Paulo", "Paulista"},
+		{"ShortCity", "SP", "Sé", "Paulista"},
+		{"ShortStreet", "SP", "São Paulo", "Av"},
+		{"LongStreet", "SP", "São Paulo", strings.Repeat("a", MaxCityLength+1)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewAddressSearch(tc.uf, tc.city, tc.street); err != ErrInvalidAddressSearch {
+				t.Errorf("Expected ErrInvalidAddressSearch, got %v", err)
+			}
+		})
+	}
+}
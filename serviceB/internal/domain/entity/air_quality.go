@@ -0,0 +1,17 @@
+package entity
+
+// AirQuality é a qualidade do ar no local consultado. As concentrações estão
+// em μg/m³.
+type AirQuality struct {
+	CO    float64
+	NO2   float64
+	O3    float64
+	SO2   float64
+	PM2_5 float64
+	PM10  float64
+	// USEPAIndex vai de 1 (boa) a 6 (perigosa), na escala da US EPA
+	USEPAIndex int
+	// GBDEFRAIndex vai de 1 (baixa) a 10 (muito alta), na escala do DEFRA
+	// britânico
+	GBDEFRAIndex int
+}
@@ -0,0 +1,56 @@
+package entity
+
+import "errors"
+
+const (
+	DefaultForecastDays = 3
+	// MaxForecastDays é o limite do endpoint forecast.json da WeatherAPI
+	MaxForecastDays = 14
+)
+
+var ErrInvalidForecastDays = errors.New("invalid forecast days")
+
+// Temperature guarda a mesma temperatura nas três escalas, com as mesmas
+// conversões de Weather.
+type Temperature struct {
+	Temp_c float64
+	Temp_f float64
+	Temp_k float64
+}
+
+func NewTemperature(tempC float64) Temperature {
+	return Temperature{
+		Temp_c: tempC,
+		Temp_f: (tempC * 1.8) + 32,
+		Temp_k: tempC + 273,
+	}
+}
+
+type ForecastHour struct {
+	Time      string
+	Temp      Temperature
+	Condition string
+}
+
+type ForecastDay struct {
+	Date      string
+	MinTemp   Temperature
+	MaxTemp   Temperature
+	AvgTemp   Temperature
+	Condition string
+	// Hours só é preenchido quando a previsão horária foi pedida
+	Hours []ForecastHour
+}
+
+type Forecast struct {
+	City string
+	Days []ForecastDay
+}
+
+// ValidateForecastDays aceita de 1 a MaxForecastDays dias.
+func ValidateForecastDays(days int) error {
+	if days < 1 || days > MaxForecastDays {
+		return ErrInvalidForecastDays
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "testing"
+
+func TestNewTemperature(t *testing.T) {
+	temp := NewTemperature(25.0)
+
+	if temp.Temp_c != 25.0 {
+		t.Errorf("Expected temp_c 25.0, got %.1f", temp.Temp_c)
+	}
+	if temp.Temp_f != 77.0 {
+		t.Errorf("Expected temp_f 77.0, got %.1f", temp.Temp_f)
+	}
+	if temp.Temp_k != 298.0 {
+		t.Errorf("Expected temp_k 298.0, got %.1f", temp.Temp_k)
+	}
+}
+
+func TestValidateForecastDays(t *testing.T) {
+	for _, days := range []int{1, DefaultForecastDays, MaxForecastDays} {
+		if err := ValidateForecastDays(days); err != nil {
+			t.Errorf("Expected %d days to be valid, got %v", days, err)
+		}
+	}
+	for _, days := range []int{-1, 0, MaxForecastDays + 1} {
+		if err := ValidateForecastDays(days); err != ErrInvalidForecastDays {
+			t.Errorf("Expected ErrInvalidForecastDays for %d days, got %v", days, err)
+		}
+	}
+}
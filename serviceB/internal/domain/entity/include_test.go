@@ -0,0 +1,44 @@
+package entity
+
+import "testing"
+
+func TestParseInclude(t *testing.T) {
+	testCases := []struct {
+		raw      string
+		expected Include
+	}{
+		{"", Include{}},
+		{"aqi", Include{AirQuality: true}},
+		{" AQI ,", Include{AirQuality: true}},
+		{"location", Include{Location: true}},
+		{"Location,aqi", Include{AirQuality: true, Location: true}},
+	}
+
+	for _, tc := range testCases {
+		include, err := ParseInclude(tc.raw)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", tc.raw, err)
+		}
+		if include != tc.expected {
+			t.Errorf("Expected %+v for %q, got %+v", tc.expected, tc.raw, include)
+		}
+	}
+}
+
+func TestParseInclude_Unknown(t *testing.T) {
+	if _, err := ParseInclude("aqi,pollen"); err != ErrInvalidInclude {
+		t.Errorf("Expected ErrInvalidInclude, got %v", err)
+	}
+}
+
+func TestInclude_String(t *testing.T) {
+	if value := (Include{}).String(); value != "" {
+		t.Errorf("Expected empty include, got %q", value)
+	}
+	if value := (Include{AirQuality: true}).String(); value != "aqi" {
+		t.Errorf("Expected 'aqi', got %q", value)
+	}
+	if value := (Include{AirQuality: true, Location: true}).String(); value != "aqi,location" {
+		t.Errorf("Expected 'aqi,location', got %q", value)
+	}
+}
@@ -0,0 +1,120 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Location é o endereço resolvido da consulta. Street, Neighborhood e IBGE
+// só são conhecidos nas consultas por CEP.
+type Location struct {
+	Street       string
+	Neighborhood string
+	City         string
+	// State é a sigla da UF (ex.: "SP")
+	State string
+	IBGE  string
+	// Timezone é o identificador IANA (ex.: "America/Sao_Paulo")
+	Timezone  string
+	LocalTime time.Time
+}
+
+// ErrCEPNotFound indica que o provedor de CEP respondeu que o CEP não existe.
+// É uma resposta definitiva: os demais provedores não são consultados.
+var ErrCEPNotFound = errors.New("zipcode not found")
+
+// ErrLocationNotFound indica que o provedor respondeu que não conhece o local
+// ou o endereço consultado.
+var ErrLocationNotFound = errors.New("location not found")
+
+// ErrLocationMismatch indica que o provedor de clima respondeu para um local
+// fora do estado da consulta, por exemplo uma cidade homônima.
+var ErrLocationMismatch = errors.New("location mismatch")
+
+// Country é o país esperado nas respostas do provedor de clima.
+const Country = "Brazil"
+
+// StateMatch é o resultado da conferência entre a UF da consulta e a região
+// devolvida pelo provedor de clima.
+type StateMatch int
+
+const (
+	// StateUnknown indica uma região vazia ou não reconhecida: não dá para
+	// afirmar que o local é outro.
+	StateUnknown StateMatch = iota
+	StateMatches
+	// StateDiffers indica outro estado ou outro país, reconhecidos como tal.
+	StateDiffers
+)
+
+// countries são os nomes aceitos para o Brasil, já normalizados.
+var countries = map[string]bool{"brazil": true, "brasil": true}
+
+// stateAliases são grafias da região que não são o nome do estado em
+// português, já normalizadas.
+var stateAliases = map[string]string{"federal district": "DF"}
+
+// statePrefixes são removidos da região antes da comparação (ex.: "State of
+// Sao Paulo").
+var statePrefixes = []string{"state of ", "estado de ", "estado do ", "estado da "}
+
+// MatchState confere a região e o país devolvidos pelo provedor de clima
+// (ex.: "Sao Paulo", "Brazil") com a UF, ignorando acentos e maiúsculas. Só
+// um país diferente ou a região de outro estado conhecido contam como
+// divergência.
+func MatchState(uf, region, country string) StateMatch {
+	uf = strings.ToUpper(strings.TrimSpace(uf))
+	if _, ok := States[uf]; !ok {
+		return StateUnknown
+	}
+	if country := normalize(country); country != "" && !countries[country] {
+		return StateDiffers
+	}
+
+	regionUF := stateUF(region)
+	switch {
+	case regionUF == "":
+		return StateUnknown
+	case regionUF == uf:
+		return StateMatches
+	default:
+		return StateDiffers
+	}
+}
+
+// stateUF devolve a UF correspondente à região, aceitando a sigla, o nome do
+// estado e as grafias de stateAliases; vazio quando não reconhece.
+func stateUF(region string) string {
+	region = normalize(region)
+	for _, prefix := range statePrefixes {
+		region = strings.TrimPrefix(region, prefix)
+	}
+	if region == "" {
+		return ""
+	}
+	if uf, ok := stateAliases[region]; ok {
+		return uf
+	}
+	for uf, state := range States {
+		if region == strings.ToLower(uf) || region == normalize(state) {
+			return uf
+		}
+	}
+	return ""
+}
+
+// normalize remove os acentos (decompondo os caracteres e descartando as
+// marcas combinantes), os espaços extras e as maiúsculas.
+func normalize(value string) string {
+	withoutMarks, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), value)
+	if err != nil {
+		withoutMarks = value
+	}
+	return strings.ToLower(strings.Join(strings.Fields(withoutMarks), " "))
+}
@@ -0,0 +1,35 @@
+package entity
+
+import "testing"
+
+func TestMatchState(t *testing.T) {
+	testCases := []struct {
+		uf       string
+		region   string
+		country  string
+		expected StateMatch
+	}{
+		{"SP", "Sao Paulo", "Brazil", StateMatches},
+		{"sp", "São Paulo", "brazil", StateMatches},
+		{"PI", "Piauí", "Brasil", StateMatches},
+		{"DF", "Distrito Federal", "Brazil", StateMatches},
+		{"DF", "Federal District", "Brazil", StateMatches},
+		{"AP", "Amapá", "Brazil", StateMatches},
+		{"SC", "State of Santa Catarina", "Brazil", StateMatches},
+		{"RJ", "RJ", "", StateMatches},
+		{"PI", "Maranhão", "Brazil", StateDiffers},
+		{"RS", "Piaui", "", StateDiffers},
+		{"MG", "Minas Gerais", "Portugal", StateDiffers},
+		{"MG", "", "Portugal", StateDiffers},
+		{"XX", "Sao Paulo", "Brazil", StateUnknown},
+		{"SP", "", "", StateUnknown},
+		{"SP", "", "Brazil", StateUnknown},
+		{"SP", "Southeast Region", "Brazil", StateUnknown},
+	}
+
+	for _, tc := range testCases {
+		if match := MatchState(tc.uf, tc.region, tc.country); match != tc.expected {
+			t.Errorf("MatchState(%q, %q, %q): expected %v, got %v", tc.uf, tc.region, tc.country, tc.expected, match)
+		}
+	}
+}
@@ -1,10 +1,45 @@
 package entity
 
+import "time"
+
 type Weather struct {
 	City   string
 	Temp_c float64
 	Temp_f float64
 	Temp_k float64
+	// Conditions é nil quando o provedor não informa as condições atuais
+	Conditions *Conditions
+	// AirQuality só é preenchido quando pedido com include=aqi
+	AirQuality *AirQuality
+	// Location só é preenchido quando pedido com include=location
+	Location *Location
+}
+
+type Wind struct {
+	SpeedKph float64
+	SpeedMph float64
+	// Degree e Direction indicam de onde o vento vem (ex.: 90 e "E")
+	Degree    int
+	Direction string
+}
+
+type Pressure struct {
+	Mb float64
+	In float64
+}
+
+// Conditions são as condições atuais além da temperatura.
+type Conditions struct {
+	Humidity  int
+	Wind      Wind
+	Pressure  Pressure
+	UV        float64
+	FeelsLike Temperature
+	HeatIndex Temperature
+	Text      string
+	Icon      string
+	// LastUpdated é o horário da observação, não o da consulta
+	LastUpdated time.Time
 }
 
 func NewWeather(city string, tempC float64) *Weather {
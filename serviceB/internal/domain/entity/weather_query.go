@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxCityLength limita o nome da cidade aceito em uma consulta
+const MaxCityLength = 100
+
+var (
+	ErrInvalidCity        = errors.New("invalid city or state")
+	ErrInvalidCoordinates = errors.New("invalid coordinates")
+)
+
+// States são as unidades federativas aceitas em uma consulta por cidade,
+// com o nome usado na busca da WeatherAPI.
+var States = map[string]string{
+	"AC": "Acre", "AL": "Alagoas", "AP": "Amapá", "AM": "Amazonas",
+	"BA": "Bahia", "CE": "Ceará", "DF": "Distrito Federal", "ES": "Espírito Santo",
+	"GO": "Goiás", "MA": "Maranhão", "MT": "Mato Grosso", "MS": "Mato Grosso do Sul",
+	"MG": "Minas Gerais", "PA": "Pará", "PB": "Paraíba", "PR": "Paraná",
+	"PE": "Pernambuco", "PI": "Piauí", "RJ": "Rio de Janeiro", "RN": "Rio Grande do Norte",
+	"RS": "Rio Grande do Sul", "RO": "Rondônia", "RR": "Roraima", "SC": "Santa Catarina",
+	"SP": "São Paulo", "SE": "Sergipe", "TO": "Tocantins",
+}
+
+type Coordinates struct {
+	Lat float64
+	Lon float64
+}
+
+// WeatherQuery é o local de uma consulta de clima: um CEP, uma cidade com UF
+// ou um par de coordenadas. Só um dos modos é preenchido.
+type WeatherQuery struct {
+	CEP         string
+	City        string
+	UF          string
+	Coordinates *Coordinates
+}
+
+// NewCEPQuery recebe um CEP já validado e formatado.
+func NewCEPQuery(cep string) WeatherQuery {
+	return WeatherQuery{CEP: cep}
+}
+
+// NewCityQuery exige o nome da cidade e uma UF existente; a UF é aceita em
+// minúsculas.
+func NewCityQuery(city, uf string) (WeatherQuery, error) {
+	city = strings.TrimSpace(city)
+	uf = strings.ToUpper(strings.TrimSpace(uf))
+	if city == "" || utf8.RuneCountInString(city) > MaxCityLength {
+		return WeatherQuery{}, ErrInvalidCity
+	}
+	if _, ok := States[uf]; !ok {
+		return WeatherQuery{}, ErrInvalidCity
+	}
+	return WeatherQuery{City: city, UF: uf}, nil
+}
+
+// NewCoordinatesQuery exige latitude entre -90 e 90 e longitude entre -180 e
+// 180.
+func NewCoordinatesQuery(lat, lon float64) (WeatherQuery, error) {
+	if math.IsNaN(lat) || math.IsNaN(lon) || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return WeatherQuery{}, ErrInvalidCoordinates
+	}
+	return WeatherQuery{Coordinates: &Coordinates{Lat: lat, Lon: lon}}, nil
+}
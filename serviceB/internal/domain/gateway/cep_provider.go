@@ -0,0 +1,15 @@
+package gateway
+
+import (
+	"context"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+)
+
+// CEPProvider resolve um CEP no endereço correspondente. Um CEP inexistente
+// retorna entity.ErrCEPNotFound; os demais erros indicam que o provedor está
+// indisponível.
+type CEPProvider interface {
+	Name() string
+	GetLocation(ctx context.Context, cep string) (*entity.Location, error)
+}
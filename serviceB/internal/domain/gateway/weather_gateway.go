@@ -7,5 +7,7 @@ import (
 )
 
 type WeatherGateway interface {
-	GetCurrentWeather(ctx context.Context, cep string) (*entity.Weather, error)
+	GetCurrentWeather(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error)
+	GetForecast(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error)
+	SearchAddress(ctx context.Context, search entity.AddressSearch) ([]entity.Address, error)
 }
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/api/dto"
+	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// maxBatchBodySize limita o corpo do POST /batch, lido antes da validação do
+// tamanho do lote.
+const maxBatchBodySize = 1 << 20
+
+type BatchUseCaseInterface interface {
+	GetCurrentWeather(ctx context.Context, ceps []string, include entity.Include, yield func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError)) *internalerror.InternalError
+}
+
+type BatchHandler struct {
+	usecase BatchUseCaseInterface
+	logger  *slog.Logger
+}
+
+func NewBatchHandler(useCase BatchUseCaseInterface, logger *slog.Logger) *BatchHandler {
+	return &BatchHandler{usecase: useCase, logger: logger}
+}
+
+// GetWeather responde POST /batch com {"ceps": [...]}. Por padrão devolve
+// todos os resultados de uma vez, na ordem do lote; com Accept:
+// application/x-ndjson, cada resultado é enviado em uma linha assim que fica
+// pronto.
+func (h *BatchHandler) GetWeather(w http.ResponseWriter, r *http.Request) {
+	// O span SERVER da requisição é criado pelo telemetry.TracingMiddleware
+	ctx := r.Context()
+
+	include, includeErr := entity.ParseInclude(r.URL.Query().Get("include"))
+	if includeErr != nil {
+		h.fail(ctx, w, internalerror.IncludeInvalidError())
+		return
+	}
+
+	var batchRequest dto.BatchRequestDTO
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodySize)).Decode(&batchRequest); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.fail(ctx, w, internalerror.BatchBodyTooLargeError())
+			return
+		}
+		h.fail(ctx, w, internalerror.BatchInvalidError())
+		return
+	}
+
+	newItem := func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError) dto.BatchItemDTO {
+		if err != nil {
+			return dto.BatchItemDTO{Index: index, CEP: cep, Status: err.Code, Error: err.MSG}
+		}
+		weatherDTO := dto.NewWeatherDTO(weather.City, weather.Temp_c, weather.Temp_f, weather.Temp_k).
+			WithConditions(weather.Conditions).
+			WithAirQuality(weather.AirQuality).
+			WithLocation(weather.Location)
+		return dto.BatchItemDTO{Index: index, CEP: cep, Status: http.StatusOK, Weather: weatherDTO}
+	}
+
+	if acceptsNDJSON(r) {
+		// O status 200 só é enviado com o primeiro resultado, para que um lote
+		// inválido ainda possa responder com erro
+		encoder := json.NewEncoder(w)
+		controller := http.NewResponseController(w)
+		started := false
+		err := h.usecase.GetCurrentWeather(ctx, batchRequest.CEPs, include, func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError) {
+			if !started {
+				started = true
+				w.Header().Set("Content-Type", ndjsonContentType)
+				w.WriteHeader(http.StatusOK)
+			}
+			encoder.Encode(newItem(index, cep, weather, err))
+			controller.Flush()
+		})
+		if err != nil {
+			h.fail(ctx, w, err)
+		}
+		return
+	}
+
+	response := dto.BatchResponseDTO{}
+	err := h.usecase.GetCurrentWeather(ctx, batchRequest.CEPs, include, func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError) {
+		if index >= len(response.Results) {
+			response.Results = append(response.Results, make([]dto.BatchItemDTO, index+1-len(response.Results))...)
+		}
+		response.Results[index] = newItem(index, cep, weather, err)
+	})
+	if err != nil {
+		h.fail(ctx, w, err)
+		return
+	}
+
+	responseJSON, jsonErr := json.Marshal(response)
+	if jsonErr != nil {
+		h.logger.ErrorContext(ctx, "failed to marshal batch response", slog.Any("error", jsonErr))
+		http.Error(w, "Error marshalling batch data", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(responseJSON)
+}
+
+func (h *BatchHandler) fail(ctx context.Context, w http.ResponseWriter, err *internalerror.InternalError) {
+	trace.SpanFromContext(ctx).RecordError(err)
+	h.logger.WarnContext(ctx, "rejected batch request", slog.String("error", err.MSG), slog.Int("http.response.status_code", err.Code))
+	http.Error(w, err.MSG, err.Code)
+}
+
+func acceptsNDJSON(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accept); err == nil && mediaType == ndjsonContentType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/api/dto"
+	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
+)
+
+type MockBatchUseCase struct {
+	mockGetCurrentWeather func(ctx context.Context, ceps []string, include entity.Include, yield func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError)) *internalerror.InternalError
+}
+
+func (m *MockBatchUseCase) GetCurrentWeather(ctx context.Context, ceps []string, include entity.Include, yield func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError)) *internalerror.InternalError {
+	return m.mockGetCurrentWeather(ctx, ceps, include, yield)
+}
+
+// Os resultados chegam fora de ordem, como no worker pool
+func newOutOfOrderBatchUseCase() *MockBatchUseCase {
+	return &MockBatchUseCase{
+		mockGetCurrentWeather: func(ctx context.Context, ceps []string, include entity.Include, yield func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError)) *internalerror.InternalError {
+			yield(1, "99999999", nil, internalerror.CEPNotFoundError())
+			yield(0, "01001000", entity.NewWeather("São Paulo", 25), nil)
+			return nil
+		},
+	}
+}
+
+func TestBatchGetWeather_JSON(t *testing.T) {
+	handler := NewBatchHandler(newOutOfOrderBatchUseCase(), slog.New(slog.DiscardHandler))
+
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`{"ceps":["01001000","99999999"]}`))
+	w := httptest.NewRecorder()
+
+	handler.GetWeather(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected Content-Type 'application/json', got '%s'", contentType)
+	}
+	var response dto.BatchResponseDTO
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(response.Results))
+	}
+	if response.Results[0].CEP != "01001000" || response.Results[0].Status != 200 || response.Results[0].Weather.City != "São Paulo" {
+		t.Errorf("Unexpected first result: %+v", response.Results[0])
+	}
+	if response.Results[1].CEP != "99999999" || response.Results[1].Status != 404 || response.Results[1].Error != "Can not find zipcode" || response.Results[1].Weather != nil {
+		t.Errorf("Unexpected second result: %+v", response.Results[1])
+	}
+}
+
+func TestBatchGetWeather_NDJSON(t *testing.T) {
+	handler := NewBatchHandler(newOutOfOrderBatchUseCase(), slog.New(slog.DiscardHandler))
+
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`{"ceps":["01001000","99999999"]}`))
+	req.Header.Set("Accept", "application/json, application/x-ndjson")
+	w := httptest.NewRecorder()
+
+	handler.GetWeather(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("Expected Content-Type 'application/x-ndjson', got '%s'", contentType)
+	}
+	if !w.Flushed {
+		t.Error("Expected each line to be flushed")
+	}
+
+	var items []dto.BatchItemDTO
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var item dto.BatchItemDTO
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			t.Fatalf("Failed to unmarshal line %q: %v", scanner.Text(), err)
+		}
+		items = append(items, item)
+	}
+	// No streaming a ordem é a de conclusão
+	if len(items) != 2 || items[0].CEP != "99999999" || items[0].Index != 1 || items[1].CEP != "01001000" || items[1].Index != 0 {
+		t.Errorf("Unexpected items: %+v", items)
+	}
+}
+
+func TestBatchGetWeather_InvalidBatch(t *testing.T) {
+	mockUseCase := &MockBatchUseCase{
+		mockGetCurrentWeather: func(ctx context.Context, ceps []string, include entity.Include, yield func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError)) *internalerror.InternalError {
+			return internalerror.BatchEmptyError()
+		},
+	}
+	handler := NewBatchHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	testCases := []struct {
+		name   string
+		target string
+		body   string
+		accept string
+		msg    string
+	}{
+		{"Empty", "/batch", `{"ceps":[]}`, "", "Batch must have at least one zipcode"},
+		{"EmptyNDJSON", "/batch", `{"ceps":[]}`, "application/x-ndjson", "Batch must have at least one zipcode"},
+		{"InvalidBody", "/batch", `{"ceps":`, "", "Invalid batch request"},
+		{"InvalidInclude", "/batch?include=pollen", `{"ceps":["01001000"]}`, "", "Invalid include"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body))
+			req.Header.Set("Accept", tc.accept)
+			w := httptest.NewRecorder()
+
+			handler.GetWeather(w, req)
+
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("Expected status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+			}
+			if body := strings.TrimSpace(w.Body.String()); body != tc.msg {
+				t.Errorf("Expected body '%s', got '%s'", tc.msg, body)
+			}
+		})
+	}
+}
+
+func TestBatchGetWeather_BodyTooLarge(t *testing.T) {
+	mockUseCase := &MockBatchUseCase{
+		mockGetCurrentWeather: func(ctx context.Context, ceps []string, include entity.Include, yield func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError)) *internalerror.InternalError {
+			t.Error("The use case should not be called for an oversized body")
+			return nil
+		},
+	}
+	handler := NewBatchHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	body := `{"ceps":["` + strings.Repeat("0", maxBatchBodySize) + `"]}`
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.GetWeather(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status code %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != "Batch request body too large" {
+		t.Errorf("Expected body 'Batch request body too large', got '%s'", body)
+	}
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+
+// AirQualityDTO traz as concentrações em μg/m³ e os índices da US EPA (1 a
+// 6) e do DEFRA britânico (1 a 10).
+type AirQualityDTO struct {
+	CO           float64 `json:"co"`
+	NO2          float64 `json:"no2"`
+	O3           float64 `json:"o3"`
+	SO2          float64 `json:"so2"`
+	PM2_5        float64 `json:"pm2_5"`
+	PM10         float64 `json:"pm10"`
+	USEPAIndex   int     `json:"us_epa_index"`
+	GBDEFRAIndex int     `json:"gb_defra_index"`
+}
+
+func NewAirQualityDTO(airQuality *entity.AirQuality) *AirQualityDTO {
+	return &AirQualityDTO{
+		CO:           airQuality.CO,
+		NO2:          airQuality.NO2,
+		O3:           airQuality.O3,
+		SO2:          airQuality.SO2,
+		PM2_5:        airQuality.PM2_5,
+		PM10:         airQuality.PM10,
+		USEPAIndex:   airQuality.USEPAIndex,
+		GBDEFRAIndex: airQuality.GBDEFRAIndex,
+	}
+}
@@ -0,0 +1,21 @@
+package dto
+
+type BatchRequestDTO struct {
+	CEPs []string `json:"ceps"`
+}
+
+// BatchItemDTO é o resultado de um CEP do lote: weather quando status é
+// 200, error caso contrário.
+type BatchItemDTO struct {
+	// Index é a posição do CEP no lote sem os repetidos; no NDJSON os
+	// resultados chegam na ordem em que as consultas terminam.
+	Index   int         `json:"index"`
+	CEP     string      `json:"cep"`
+	Status  int         `json:"status"`
+	Weather *WeatherDTO `json:"weather,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type BatchResponseDTO struct {
+	Results []BatchItemDTO `json:"results"`
+}
@@ -0,0 +1,55 @@
+package dto
+
+import "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+
+type TemperatureDTO struct {
+	Temp_c float64 `json:"temp_c"`
+	Temp_f float64 `json:"temp_f"`
+	Temp_k float64 `json:"temp_k"`
+}
+
+type ForecastHourDTO struct {
+	Time      string         `json:"time"`
+	Temp      TemperatureDTO `json:"temp"`
+	Condition string         `json:"condition"`
+}
+
+type ForecastDayDTO struct {
+	Date      string            `json:"date"`
+	Min       TemperatureDTO    `json:"min"`
+	Max       TemperatureDTO    `json:"max"`
+	Avg       TemperatureDTO    `json:"avg"`
+	Condition string            `json:"condition"`
+	Hours     []ForecastHourDTO `json:"hours,omitempty"`
+}
+
+type ForecastDTO struct {
+	City string           `json:"city"`
+	Days []ForecastDayDTO `json:"days"`
+}
+
+func NewTemperatureDTO(temp entity.Temperature) TemperatureDTO {
+	return TemperatureDTO{Temp_c: temp.Temp_c, Temp_f: temp.Temp_f, Temp_k: temp.Temp_k}
+}
+
+func NewForecastDTO(forecast *entity.Forecast) *ForecastDTO {
+	forecastDTO := &ForecastDTO{City: forecast.City, Days: []ForecastDayDTO{}}
+	for _, day := range forecast.Days {
+		dayDTO := ForecastDayDTO{
+			Date:      day.Date,
+			Min:       NewTemperatureDTO(day.MinTemp),
+			Max:       NewTemperatureDTO(day.MaxTemp),
+			Avg:       NewTemperatureDTO(day.AvgTemp),
+			Condition: day.Condition,
+		}
+		for _, hour := range day.Hours {
+			dayDTO.Hours = append(dayDTO.Hours, ForecastHourDTO{
+				Time:      hour.Time,
+				Temp:      NewTemperatureDTO(hour.Temp),
+				Condition: hour.Condition,
+			})
+		}
+		forecastDTO.Days = append(forecastDTO.Days, dayDTO)
+	}
+	return forecastDTO
+}
@@ -0,0 +1,47 @@
+package dto
+
+import "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+
+// AddressCandidateDTO é um CEP encontrado pela busca. weather só vem quando
+// pedido; error explica por que o clima do candidato não veio.
+type AddressCandidateDTO struct {
+	CEP          string      `json:"cep"`
+	Street       string      `json:"street"`
+	Complement   string      `json:"complement,omitempty"`
+	Neighborhood string      `json:"neighborhood"`
+	City         string      `json:"city"`
+	State        string      `json:"state"`
+	IBGE         string      `json:"ibge_code"`
+	Weather      *WeatherDTO `json:"weather,omitempty"`
+	Error        string      `json:"error,omitempty"`
+}
+
+type AddressSearchResponseDTO struct {
+	Results []AddressCandidateDTO `json:"results"`
+}
+
+func NewAddressSearchResponseDTO(candidates []entity.AddressCandidate) *AddressSearchResponseDTO {
+	// Sem candidatos a resposta é uma lista vazia, não null
+	response := &AddressSearchResponseDTO{Results: make([]AddressCandidateDTO, 0, len(candidates))}
+	for _, candidate := range candidates {
+		address := candidate.Address
+		candidateDTO := AddressCandidateDTO{
+			CEP:          address.CEP,
+			Street:       address.Street,
+			Complement:   address.Complement,
+			Neighborhood: address.Neighborhood,
+			City:         address.City,
+			State:        address.State,
+			IBGE:         address.IBGE,
+			Error:        candidate.WeatherError,
+		}
+		if weather := candidate.Weather; weather != nil {
+			candidateDTO.Weather = NewWeatherDTO(weather.City, weather.Temp_c, weather.Temp_f, weather.Temp_k).
+				WithConditions(weather.Conditions).
+				WithAirQuality(weather.AirQuality).
+				WithLocation(weather.Location)
+		}
+		response.Results = append(response.Results, candidateDTO)
+	}
+	return response
+}
@@ -1,10 +1,49 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+)
+
 type WeatherDTO struct {
 	City   string  `json:"city"`
 	Temp_c float64 `json:"temp_c"`
 	Temp_f float64 `json:"temp_f"`
 	Temp_k float64 `json:"temp_k"`
+	// Os campos de ConditionsDTO aparecem no mesmo nível de city e temp_*,
+	// e só quando as condições atuais são conhecidas
+	*ConditionsDTO
+	AirQuality *AirQualityDTO `json:"air_quality,omitempty"`
+	Location   *LocationDTO   `json:"location,omitempty"`
+}
+
+type WindDTO struct {
+	SpeedKph  float64 `json:"speed_kph"`
+	SpeedMph  float64 `json:"speed_mph"`
+	Degree    int     `json:"degree"`
+	Direction string  `json:"direction"`
+}
+
+type PressureDTO struct {
+	Mb float64 `json:"mb"`
+	In float64 `json:"in"`
+}
+
+type ConditionDTO struct {
+	Text string `json:"text"`
+	Icon string `json:"icon"`
+}
+
+type ConditionsDTO struct {
+	Humidity    int            `json:"humidity"`
+	Wind        WindDTO        `json:"wind"`
+	Pressure    PressureDTO    `json:"pressure"`
+	UV          float64        `json:"uv"`
+	FeelsLike   TemperatureDTO `json:"feels_like"`
+	HeatIndex   TemperatureDTO `json:"heat_index"`
+	Condition   ConditionDTO   `json:"condition"`
+	LastUpdated string         `json:"last_updated,omitempty"`
 }
 
 func NewWeatherDTO(city string, tempC, tempF, tempK float64) *WeatherDTO {
@@ -15,3 +54,46 @@ func NewWeatherDTO(city string, tempC, tempF, tempK float64) *WeatherDTO {
 		Temp_k: tempK,
 	}
 }
+
+// WithConditions acrescenta as condições atuais; com nil, o DTO fica
+// inalterado.
+func (d *WeatherDTO) WithConditions(conditions *entity.Conditions) *WeatherDTO {
+	if conditions == nil {
+		return d
+	}
+	d.ConditionsDTO = &ConditionsDTO{
+		Humidity: conditions.Humidity,
+		Wind: WindDTO{
+			SpeedKph:  conditions.Wind.SpeedKph,
+			SpeedMph:  conditions.Wind.SpeedMph,
+			Degree:    conditions.Wind.Degree,
+			Direction: conditions.Wind.Direction,
+		},
+		Pressure:  PressureDTO{Mb: conditions.Pressure.Mb, In: conditions.Pressure.In},
+		UV:        conditions.UV,
+		FeelsLike: NewTemperatureDTO(conditions.FeelsLike),
+		HeatIndex: NewTemperatureDTO(conditions.HeatIndex),
+		Condition: ConditionDTO{Text: conditions.Text, Icon: conditions.Icon},
+	}
+	if !conditions.LastUpdated.IsZero() {
+		d.LastUpdated = conditions.LastUpdated.Format(time.RFC3339)
+	}
+	return d
+}
+
+// WithAirQuality acrescenta a qualidade do ar; com nil, o DTO fica
+// inalterado.
+func (d *WeatherDTO) WithAirQuality(airQuality *entity.AirQuality) *WeatherDTO {
+	if airQuality != nil {
+		d.AirQuality = NewAirQualityDTO(airQuality)
+	}
+	return d
+}
+
+// WithLocation acrescenta o endereço; com nil, o DTO fica inalterado.
+func (d *WeatherDTO) WithLocation(location *entity.Location) *WeatherDTO {
+	if location != nil {
+		d.Location = NewLocationDTO(location)
+	}
+	return d
+}
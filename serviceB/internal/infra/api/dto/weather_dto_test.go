@@ -3,6 +3,9 @@ package dto
 import (
 	"encoding/json"
 	"testing"
+	"time"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
 )
 
 func TestNewWeatherDTO(t *testing.T) {
@@ -143,3 +146,86 @@ func TestWeatherDTO_JSONRoundTrip(t *testing.T) {
 		t.Errorf("Temp_k changed after round trip")
 	}
 }
+
+func TestWeatherDTO_WithConditionsJSON(t *testing.T) {
+	conditions := &entity.Conditions{
+		Humidity:    65,
+		Wind:        entity.Wind{SpeedKph: 14.4, SpeedMph: 8.9, Degree: 120, Direction: "ESE"},
+		Pressure:    entity.Pressure{Mb: 1015, In: 29.97},
+		UV:          6,
+		FeelsLike:   entity.NewTemperature(27),
+		HeatIndex:   entity.NewTemperature(28),
+		Text:        "Partly cloudy",
+		Icon:        "https://cdn.weatherapi.com/weather/64x64/day/116.png",
+		LastUpdated: time.Date(2026, 10, 17, 14, 30, 0, 0, time.UTC),
+	}
+
+	jsonData, err := json.Marshal(NewWeatherDTO("São Paulo", 25.0, 77.0, 298.0).WithConditions(conditions))
+	if err != nil {
+		t.Fatalf("Failed to marshal DTO: %v", err)
+	}
+
+	expectedJSON := `{"city":"São Paulo","temp_c":25,"temp_f":77,"temp_k":298,"humidity":65,` +
+		`"wind":{"speed_kph":14.4,"speed_mph":8.9,"degree":120,"direction":"ESE"},` +
+		`"pressure":{"mb":1015,"in":29.97},"uv":6,` +
+		`"feels_like":{"temp_c":27,"temp_f":80.6,"temp_k":300},` +
+		`"heat_index":{"temp_c":28,"temp_f":82.4,"temp_k":301},` +
+		`"condition":{"text":"Partly cloudy","icon":"https://cdn.weatherapi.com/weather/64x64/day/116.png"},` +
+		`"last_updated":"2026-10-17T14:30:00Z"}`
+	if string(jsonData) != expectedJSON {
+		t.Errorf("Expected JSON %s, got %s", expectedJSON, string(jsonData))
+	}
+}
+
+// Sem condições, a resposta continua com os mesmos campos de antes
+func TestWeatherDTO_WithNilConditions(t *testing.T) {
+	jsonData, _ := json.Marshal(NewWeatherDTO("Test", 20.0, 68.0, 293.0).WithConditions(nil))
+
+	expectedJSON := `{"city":"Test","temp_c":20,"temp_f":68,"temp_k":293}`
+	if string(jsonData) != expectedJSON {
+		t.Errorf("Expected JSON %s, got %s", expectedJSON, string(jsonData))
+	}
+}
+
+func TestWeatherDTO_WithAirQualityJSON(t *testing.T) {
+	airQuality := &entity.AirQuality{CO: 290.4, NO2: 21.8, O3: 68.7, SO2: 5.4, PM2_5: 12.5, PM10: 17.9, USEPAIndex: 1, GBDEFRAIndex: 2}
+
+	jsonData, _ := json.Marshal(NewWeatherDTO("Test", 20.0, 68.0, 293.0).WithAirQuality(airQuality))
+
+	expectedJSON := `{"city":"Test","temp_c":20,"temp_f":68,"temp_k":293,"air_quality":` +
+		`{"co":290.4,"no2":21.8,"o3":68.7,"so2":5.4,"pm2_5":12.5,"pm10":17.9,"us_epa_index":1,"gb_defra_index":2}}`
+	if string(jsonData) != expectedJSON {
+		t.Errorf("Expected JSON %s, got %s", expectedJSON, string(jsonData))
+	}
+}
+
+func TestWeatherDTO_WithLocationJSON(t *testing.T) {
+	localTime := time.Date(2026, 10, 17, 11, 30, 0, 0, time.FixedZone("-03", -3*60*60))
+	location := &entity.Location{
+		Street:       "Praça da Sé",
+		Neighborhood: "Sé",
+		City:         "São Paulo",
+		State:        "SP",
+		IBGE:         "3550308",
+		Timezone:     "America/Sao_Paulo",
+		LocalTime:    localTime,
+	}
+
+	jsonData, _ := json.Marshal(NewWeatherDTO("Sao Paulo", 20.0, 68.0, 293.0).WithLocation(location))
+
+	expectedJSON := `{"city":"Sao Paulo","temp_c":20,"temp_f":68,"temp_k":293,"location":` +
+		`{"street":"Praça da Sé","neighborhood":"Sé","city":"São Paulo","state":"SP","ibge_code":"3550308",` +
+		`"timezone":"America/Sao_Paulo","local_time":"2026-10-17T11:30:00-03:00"}}`
+	if string(jsonData) != expectedJSON {
+		t.Errorf("Expected JSON %s, got %s", expectedJSON, string(jsonData))
+	}
+}
+
+func TestWeatherDTO_WithLocationOmitsUnknownFields(t *testing.T) {
+	jsonData, _ := json.Marshal(NewWeatherDTO("Test", 20.0, 68.0, 293.0).WithLocation(&entity.Location{City: "Test"}).WithLocation(nil))
+
+	expectedJSON := `{"city":"Test","temp_c":20,"temp_f":68,"temp_k":293,"location":{"city":"Test"}}`
+	if string(jsonData) != expectedJSON {
+		t.Errorf("Expected JSON %s, got %s", expectedJSON, string(jsonData))
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/api/dto"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GetForecast responde GET /forecast?cep=...&days=3&hourly=true.
+func (h *WeatherHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	cep := query.Get("cep")
+	ctx = telemetry.WithCEP(ctx, cep)
+
+	days := entity.DefaultForecastDays
+	if raw := query.Get("days"); raw != "" {
+		// Um valor não numérico vira 0 e é rejeitado pelo caso de uso
+		days, _ = strconv.Atoi(raw)
+	}
+	hourly, _ := strconv.ParseBool(query.Get("hourly"))
+
+	forecast, err := h.usecase.GetForecast(ctx, cep, days, hourly)
+	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		h.logger.WarnContext(ctx, "failed to get forecast", slog.String("error", err.MSG), slog.Int("http.response.status_code", err.Code))
+		http.Error(w, err.MSG, err.Code)
+		return
+	}
+
+	forecastJSON, jsonErr := json.Marshal(dto.NewForecastDTO(forecast))
+	if jsonErr != nil {
+		h.logger.ErrorContext(ctx, "failed to marshal forecast response", slog.Any("error", jsonErr))
+		http.Error(w, "Error marshalling forecast data", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(forecastJSON)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
+)
+
+func TestGetForecast_Success(t *testing.T) {
+	var gotDays int
+	var gotHourly bool
+	mockUseCase := &MockWeatherUseCase{
+		mockGetForecast: func(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, *internalerror.InternalError) {
+			gotDays, gotHourly = days, hourly
+			return &entity.Forecast{
+				City: "São Paulo",
+				Days: []entity.ForecastDay{{
+					Date:      "2025-01-10",
+					MinTemp:   entity.NewTemperature(18),
+					MaxTemp:   entity.NewTemperature(28),
+					AvgTemp:   entity.NewTemperature(23),
+					Condition: "Patchy rain possible",
+					Hours:     []entity.ForecastHour{{Time: "2025-01-10 00:00", Temp: entity.NewTemperature(19), Condition: "Clear"}},
+				}},
+			}, nil
+		},
+	}
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	req := httptest.NewRequest(http.MethodGet, "/forecast?cep=04446-160&days=5&hourly=true", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetForecast(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if gotDays != 5 || !gotHourly {
+		t.Errorf("Expected 5 days with hourly forecast, got %d and %v", gotDays, gotHourly)
+	}
+
+	var response struct {
+		City string `json:"city"`
+		Days []struct {
+			Date      string             `json:"date"`
+			Min       map[string]float64 `json:"min"`
+			Max       map[string]float64 `json:"max"`
+			Condition string             `json:"condition"`
+			Hours     []struct {
+				Time string             `json:"time"`
+				Temp map[string]float64 `json:"temp"`
+			} `json:"hours"`
+		} `json:"days"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.City != "São Paulo" || len(response.Days) != 1 {
+		t.Fatalf("Expected one day for São Paulo, got %+v", response)
+	}
+	day := response.Days[0]
+	if day.Min["temp_c"] != 18 || day.Max["temp_f"] != 82.4 || day.Max["temp_k"] != 301 {
+		t.Errorf("Unexpected temperatures: min %v, max %v", day.Min, day.Max)
+	}
+	if day.Condition != "Patchy rain possible" {
+		t.Errorf("Expected condition 'Patchy rain possible', got '%s'", day.Condition)
+	}
+	if len(day.Hours) != 1 || day.Hours[0].Temp["temp_c"] != 19 {
+		t.Errorf("Expected one hourly entry at 19°C, got %+v", day.Hours)
+	}
+}
+
+func TestGetForecast_DefaultDays(t *testing.T) {
+	var gotDays int
+	mockUseCase := &MockWeatherUseCase{
+		mockGetForecast: func(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, *internalerror.InternalError) {
+			gotDays = days
+			return &entity.Forecast{City: "São Paulo"}, nil
+		},
+	}
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	w := httptest.NewRecorder()
+	handler.GetForecast(w, httptest.NewRequest(http.MethodGet, "/forecast?cep=04446-160", nil))
+
+	if gotDays != entity.DefaultForecastDays {
+		t.Errorf("Expected %d days by default, got %d", entity.DefaultForecastDays, gotDays)
+	}
+	if w.Body.String() != "{\"city\":\"São Paulo\",\"days\":[]}" {
+		t.Errorf("Expected empty days list without hours, got %s", w.Body.String())
+	}
+}
+
+func TestGetForecast_InvalidDays(t *testing.T) {
+	mockUseCase := &MockWeatherUseCase{
+		mockGetForecast: func(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, *internalerror.InternalError) {
+			if days != 0 {
+				t.Errorf("Expected non-numeric days to reach the use case as 0, got %d", days)
+			}
+			return nil, internalerror.ForecastDaysInvalidError()
+		},
+	}
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	w := httptest.NewRecorder()
+	handler.GetForecast(w, httptest.NewRequest(http.MethodGet, "/forecast?cep=04446-160&days=week", nil))
+
+	if w.Code != 422 {
+		t.Errorf("Expected status code 422, got %d", w.Code)
+	}
+}
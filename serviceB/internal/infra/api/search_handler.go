@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/api/dto"
+	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type SearchAddressUseCaseInterface interface {
+	SearchAddress(ctx context.Context, uf, city, street string, withWeather bool, include entity.Include) ([]entity.AddressCandidate, *internalerror.InternalError)
+}
+
+type SearchHandler struct {
+	usecase SearchAddressUseCaseInterface
+	logger  *slog.Logger
+}
+
+func NewSearchHandler(useCase SearchAddressUseCaseInterface, logger *slog.Logger) *SearchHandler {
+	return &SearchHandler{usecase: useCase, logger: logger}
+}
+
+// SearchAddress responde GET /search?uf=SP&city=...&street=...&weather=true.
+func (h *SearchHandler) SearchAddress(w http.ResponseWriter, r *http.Request) {
+	// O span SERVER da requisição é criado pelo telemetry.TracingMiddleware
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	include, includeErr := entity.ParseInclude(query.Get("include"))
+	if includeErr != nil {
+		h.fail(ctx, w, internalerror.IncludeInvalidError())
+		return
+	}
+	withWeather, _ := strconv.ParseBool(query.Get("weather"))
+
+	candidates, err := h.usecase.SearchAddress(ctx, query.Get("uf"), query.Get("city"), query.Get("street"), withWeather, include)
+	if err != nil {
+		h.fail(ctx, w, err)
+		return
+	}
+
+	searchJSON, jsonErr := json.Marshal(dto.NewAddressSearchResponseDTO(candidates))
+	if jsonErr != nil {
+		h.logger.ErrorContext(ctx, "failed to marshal address search response", slog.Any("error", jsonErr))
+		http.Error(w, "Error marshalling address data", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(searchJSON)
+}
+
+func (h *SearchHandler) fail(ctx context.Context, w http.ResponseWriter, err *internalerror.InternalError) {
+	trace.SpanFromContext(ctx).RecordError(err)
+	h.logger.WarnContext(ctx, "failed to search address", slog.String("error", err.MSG), slog.Int("http.response.status_code", err.Code))
+	http.Error(w, err.MSG, err.Code)
+}
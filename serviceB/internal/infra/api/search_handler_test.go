@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/api/dto"
+	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
+)
+
+type MockSearchAddressUseCase struct {
+	mockSearchAddress func(ctx context.Context, uf, city, street string, withWeather bool, include entity.Include) ([]entity.AddressCandidate, *internalerror.InternalError)
+}
+
+func (m *MockSearchAddressUseCase) SearchAddress(ctx context.Context, uf, city, street string, withWeather bool, include entity.Include) ([]entity.AddressCandidate, *internalerror.InternalError) {
+	return m.mockSearchAddress(ctx, uf, city, street, withWeather, include)
+}
+
+func TestSearchAddress_Success(t *testing.T) {
+	mockUseCase := &MockSearchAddressUseCase{
+		mockSearchAddress: func(ctx context.Context, uf, city, street string, withWeather bool, include entity.Include) ([]entity.AddressCandidate, *internalerror.InternalError) {
+			if uf != "SP" || city != "São Paulo" || street != "Avenida Paulista" || !withWeather || !include.AirQuality {
+				t.Errorf("Unexpected search %s, %s, %s, %v, %+v", uf, city, street, withWeather, include)
+			}
+			return []entity.AddressCandidate{
+				{Address: entity.Address{CEP: "01310100", Street: "Avenida Paulista"}, Weather: entity.NewWeather("São Paulo", 25)},
+				{Address: entity.Address{CEP: "01311000", Street: "Avenida Paulista"}, WeatherError: "Can not find zipcode"},
+			}, nil
+		},
+	}
+	handler := NewSearchHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	req := httptest.NewRequest(http.MethodGet, "/search?uf=SP&city=S%C3%A3o+Paulo&street=Avenida+Paulista&weather=true&include=aqi", nil)
+	w := httptest.NewRecorder()
+
+	handler.SearchAddress(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected Content-Type 'application/json', got '%s'", contentType)
+	}
+	var response dto.AddressSearchResponseDTO
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(response.Results))
+	}
+	if response.Results[0].CEP != "01310100" || response.Results[0].Weather == nil || response.Results[0].Weather.City != "São Paulo" {
+		t.Errorf("Unexpected first result: %+v", response.Results[0])
+	}
+	if response.Results[1].Weather != nil || response.Results[1].Error != "Can not find zipcode" {
+		t.Errorf("Unexpected second result: %+v", response.Results[1])
+	}
+}
+
+func TestSearchAddress_Errors(t *testing.T) {
+	testCases := []struct {
+		name         string
+		url          string
+		err          *internalerror.InternalError
+		expectedCode int
+		expectedBody string
+	}{
+		{"InvalidInclude", "/search?uf=SP&city=Campinas&street=Rua&include=foo", nil, http.StatusUnprocessableEntity, "Invalid include"},
+		{"InvalidSearch", "/search?uf=XX&city=Campinas&street=Rua", internalerror.AddressSearchInvalidError(), http.StatusUnprocessableEntity, "Invalid address search"},
+		{"NotFound", "/search?uf=SP&city=Campinas&street=Rua", internalerror.AddressNotFoundError(), http.StatusNotFound, "Can not find address"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUseCase := &MockSearchAddressUseCase{
+				mockSearchAddress: func(ctx context.Context, uf, city, street string, withWeather bool, include entity.Include) ([]entity.AddressCandidate, *internalerror.InternalError) {
+					if tc.err == nil {
+						t.Error("Use case should not be called")
+					}
+					return nil, tc.err
+				},
+			}
+			handler := NewSearchHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.SearchAddress(w, req)
+
+			if w.Code != tc.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tc.expectedCode, w.Code)
+			}
+			if body := strings.TrimSpace(w.Body.String()); body != tc.expectedBody {
+				t.Errorf("Expected body '%s', got '%s'", tc.expectedBody, body)
+			}
+		})
+	}
+}
@@ -3,40 +3,98 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
 	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/api/dto"
 	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type WeatherUseCaseInterface interface {
-	GetCurrentWeather(ctx context.Context, cep string) (*entity.Weather, *internalerror.InternalError)
+	GetCurrentWeather(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError)
+	GetCurrentWeatherByCity(ctx context.Context, city, uf string, include entity.Include) (*entity.Weather, *internalerror.InternalError)
+	GetCurrentWeatherByCoordinates(ctx context.Context, lat, lon float64, include entity.Include) (*entity.Weather, *internalerror.InternalError)
+	GetForecast(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, *internalerror.InternalError)
 }
 
 type WeatherHandler struct {
 	usecase WeatherUseCaseInterface
-	tracer  trace.Tracer
+	logger  *slog.Logger
 }
 
-func NewWeatherHandler(useCase WeatherUseCaseInterface, tracer trace.Tracer) *WeatherHandler {
-	return &WeatherHandler{usecase: useCase, tracer: tracer}
+func NewWeatherHandler(useCase WeatherUseCaseInterface, logger *slog.Logger) *WeatherHandler {
+	return &WeatherHandler{usecase: useCase, logger: logger}
 }
 
 func (h *WeatherHandler) GetWeather(w http.ResponseWriter, r *http.Request) {
-	carrier := propagation.HeaderCarrier(r.Header)
+	// O span SERVER da requisição é criado pelo telemetry.TracingMiddleware
 	ctx := r.Context()
-	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
-
 	cep := r.URL.Query().Get("cep")
-	ctx, spanStart := h.tracer.Start(ctx, "Get /?cep="+cep)
-	defer spanStart.End()
+	ctx = telemetry.WithCEP(ctx, cep)
+
+	include, ok := h.parseInclude(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	weatherCurrent, err := h.usecase.GetCurrentWeather(ctx, cep, include)
+	h.writeWeather(ctx, w, weatherCurrent, err)
+}
+
+// GetWeatherByCity responde GET /city?city=...&uf=...
+func (h *WeatherHandler) GetWeatherByCity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	include, ok := h.parseInclude(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	weatherCurrent, err := h.usecase.GetCurrentWeatherByCity(ctx, query.Get("city"), query.Get("uf"), include)
+	h.writeWeather(ctx, w, weatherCurrent, err)
+}
+
+// GetWeatherByCoordinates responde GET /coordinates?lat=...&lon=...
+func (h *WeatherHandler) GetWeatherByCoordinates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	include, ok := h.parseInclude(ctx, w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	lat, latErr := strconv.ParseFloat(query.Get("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(query.Get("lon"), 64)
+	if latErr != nil || lonErr != nil {
+		h.writeWeather(ctx, w, nil, internalerror.CoordinatesInvalidError())
+		return
+	}
+
+	weatherCurrent, err := h.usecase.GetCurrentWeatherByCoordinates(ctx, lat, lon, include)
+	h.writeWeather(ctx, w, weatherCurrent, err)
+}
+
+// parseInclude lê o parâmetro include; em caso de erro já responde 422.
+func (h *WeatherHandler) parseInclude(ctx context.Context, w http.ResponseWriter, r *http.Request) (entity.Include, bool) {
+	include, includeErr := entity.ParseInclude(r.URL.Query().Get("include"))
+	if includeErr != nil {
+		err := internalerror.IncludeInvalidError()
+		h.logger.InfoContext(ctx, "rejected invalid include", slog.String("include", r.URL.Query().Get("include")))
+		http.Error(w, err.MSG, err.Code)
+		return entity.Include{}, false
+	}
+	return include, true
+}
 
-	weatherCurrent, err := h.usecase.GetCurrentWeather(ctx, cep)
+// writeWeather responde com o clima atual ou com o erro do caso de uso.
+func (h *WeatherHandler) writeWeather(ctx context.Context, w http.ResponseWriter, weatherCurrent *entity.Weather, err *internalerror.InternalError) {
 	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		h.logger.WarnContext(ctx, "failed to get current weather", slog.String("error", err.MSG), slog.Int("http.response.status_code", err.Code))
 		http.Error(w, err.MSG, err.Code)
 		return
 	}
@@ -46,10 +104,13 @@ func (h *WeatherHandler) GetWeather(w http.ResponseWriter, r *http.Request) {
 		weatherCurrent.Temp_c,
 		weatherCurrent.Temp_f,
 		weatherCurrent.Temp_k,
-	)
+	).WithConditions(weatherCurrent.Conditions).
+		WithAirQuality(weatherCurrent.AirQuality).
+		WithLocation(weatherCurrent.Location)
 
 	weatherCurrentJSON, jsonErr := json.Marshal(weatherDTO)
 	if jsonErr != nil {
+		h.logger.ErrorContext(ctx, "failed to marshal weather response", slog.Any("error", jsonErr))
 		http.Error(w, "Error marshalling location data", http.StatusInternalServerError)
 		return
 	}
@@ -3,31 +3,46 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
 	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
-	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type MockWeatherUseCase struct {
-	mockGetCurrentWeather func(ctx context.Context, cep string) (*entity.Weather, *internalerror.InternalError)
+	mockGetCurrentWeather func(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError)
+	mockGetForecast       func(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, *internalerror.InternalError)
+	mockGetByCity         func(ctx context.Context, city, uf string, include entity.Include) (*entity.Weather, *internalerror.InternalError)
+	mockGetByCoordinates  func(ctx context.Context, lat, lon float64, include entity.Include) (*entity.Weather, *internalerror.InternalError)
 }
 
-func (m *MockWeatherUseCase) GetCurrentWeather(ctx context.Context, cep string) (*entity.Weather, *internalerror.InternalError) {
-	return m.mockGetCurrentWeather(ctx, cep)
+func (m *MockWeatherUseCase) GetCurrentWeather(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+	return m.mockGetCurrentWeather(ctx, cep, include)
+}
+
+func (m *MockWeatherUseCase) GetCurrentWeatherByCity(ctx context.Context, city, uf string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+	return m.mockGetByCity(ctx, city, uf, include)
+}
+
+func (m *MockWeatherUseCase) GetCurrentWeatherByCoordinates(ctx context.Context, lat, lon float64, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+	return m.mockGetByCoordinates(ctx, lat, lon, include)
+}
+
+func (m *MockWeatherUseCase) GetForecast(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, *internalerror.InternalError) {
+	return m.mockGetForecast(ctx, cep, days, hourly)
 }
 
 func TestGetWeather_Success(t *testing.T) {
 	mockUseCase := &MockWeatherUseCase{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, *internalerror.InternalError) {
+		mockGetCurrentWeather: func(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
 			return entity.NewWeather("São Paulo", 25.5), nil
 		},
 	}
-	mockTracer := noop.NewTracerProvider().Tracer("test")
-	handler := NewWeatherHandler(mockUseCase, mockTracer)
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
 
 	req := httptest.NewRequest(http.MethodGet, "/weather?cep=04446-160", nil)
 	w := httptest.NewRecorder()
@@ -65,12 +80,11 @@ func TestGetWeather_Success(t *testing.T) {
 
 func TestGetWeather_InvalidCEP(t *testing.T) {
 	mockUseCase := &MockWeatherUseCase{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, *internalerror.InternalError) {
+		mockGetCurrentWeather: func(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
 			return nil, internalerror.CEPInvalidError()
 		},
 	}
-	mockTracer := noop.NewTracerProvider().Tracer("test")
-	handler := NewWeatherHandler(mockUseCase, mockTracer)
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
 
 	req := httptest.NewRequest(http.MethodGet, "/weather?cep=123", nil)
 	w := httptest.NewRecorder()
@@ -90,12 +104,11 @@ func TestGetWeather_InvalidCEP(t *testing.T) {
 
 func TestGetWeather_CEPNotFound(t *testing.T) {
 	mockUseCase := &MockWeatherUseCase{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, *internalerror.InternalError) {
+		mockGetCurrentWeather: func(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
 			return nil, internalerror.CEPNotFoundError()
 		},
 	}
-	mockTracer := noop.NewTracerProvider().Tracer("test")
-	handler := NewWeatherHandler(mockUseCase, mockTracer)
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
 
 	req := httptest.NewRequest(http.MethodGet, "/weather?cep=99999-999", nil)
 	w := httptest.NewRecorder()
@@ -115,15 +128,14 @@ func TestGetWeather_CEPNotFound(t *testing.T) {
 
 func TestGetWeather_MissingCEPParameter(t *testing.T) {
 	mockUseCase := &MockWeatherUseCase{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, *internalerror.InternalError) {
+		mockGetCurrentWeather: func(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
 			if cep == "" {
 				return nil, internalerror.CEPInvalidError()
 			}
 			return entity.NewWeather("Test", 20.0), nil
 		},
 	}
-	mockTracer := noop.NewTracerProvider().Tracer("test")
-	handler := NewWeatherHandler(mockUseCase, mockTracer)
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
 
 	req := httptest.NewRequest(http.MethodGet, "/weather", nil)
 	w := httptest.NewRecorder()
@@ -137,12 +149,11 @@ func TestGetWeather_MissingCEPParameter(t *testing.T) {
 
 func TestGetWeather_JSONStructure(t *testing.T) {
 	mockUseCase := &MockWeatherUseCase{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, *internalerror.InternalError) {
+		mockGetCurrentWeather: func(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
 			return entity.NewWeather("Rio de Janeiro", 30.0), nil
 		},
 	}
-	mockTracer := noop.NewTracerProvider().Tracer("test")
-	handler := NewWeatherHandler(mockUseCase, mockTracer)
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
 
 	req := httptest.NewRequest(http.MethodGet, "/weather?cep=20000-000", nil)
 	w := httptest.NewRecorder()
@@ -184,12 +195,11 @@ func TestGetWeather_DifferentTemperatures(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockUseCase := &MockWeatherUseCase{
-				mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, *internalerror.InternalError) {
+				mockGetCurrentWeather: func(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
 					return entity.NewWeather(tc.city, tc.tempC), nil
 				},
 			}
-			mockTracer := noop.NewTracerProvider().Tracer("test")
-			handler := NewWeatherHandler(mockUseCase, mockTracer)
+			handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
 
 			req := httptest.NewRequest(http.MethodGet, "/weather?cep=12345-678", nil)
 			w := httptest.NewRecorder()
@@ -230,12 +240,11 @@ func TestGetWeather_CEPFormats(t *testing.T) {
 	for _, cep := range cepFormats {
 		t.Run("CEP_"+cep, func(t *testing.T) {
 			mockUseCase := &MockWeatherUseCase{
-				mockGetCurrentWeather: func(ctx context.Context, receivedCEP string) (*entity.Weather, *internalerror.InternalError) {
+				mockGetCurrentWeather: func(ctx context.Context, receivedCEP string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
 					return entity.NewWeather("Test City", 22.0), nil
 				},
 			}
-			mockTracer := noop.NewTracerProvider().Tracer("test")
-			handler := NewWeatherHandler(mockUseCase, mockTracer)
+			handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
 
 			req := httptest.NewRequest(http.MethodGet, "/weather?cep="+cep, nil)
 			w := httptest.NewRecorder()
@@ -248,3 +257,141 @@ func TestGetWeather_CEPFormats(t *testing.T) {
 		})
 	}
 }
+
+func TestGetWeather_IncludeAirQuality(t *testing.T) {
+	mockUseCase := &MockWeatherUseCase{
+		mockGetCurrentWeather: func(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+			if !include.AirQuality {
+				t.Errorf("Expected air quality to be included, got %+v", include)
+			}
+			weather := entity.NewWeather("São Paulo", 25.5)
+			weather.AirQuality = &entity.AirQuality{PM2_5: 12.5, USEPAIndex: 1, GBDEFRAIndex: 2}
+			return weather, nil
+		},
+	}
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	req := httptest.NewRequest(http.MethodGet, "/weather?cep=04446-160&include=aqi", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetWeather(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	var response struct {
+		AirQuality map[string]float64 `json:"air_quality"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.AirQuality["pm2_5"] != 12.5 || response.AirQuality["us_epa_index"] != 1 || response.AirQuality["gb_defra_index"] != 2 {
+		t.Errorf("Unexpected air_quality: %v", response.AirQuality)
+	}
+}
+
+func TestGetWeather_InvalidInclude(t *testing.T) {
+	mockUseCase := &MockWeatherUseCase{
+		mockGetCurrentWeather: func(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+			t.Error("Use case should not be called with an invalid include")
+			return nil, nil
+		},
+	}
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	req := httptest.NewRequest(http.MethodGet, "/weather?cep=04446-160&include=pollen", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetWeather(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != "Invalid include" {
+		t.Errorf("Expected body 'Invalid include', got '%s'", body)
+	}
+}
+
+func TestGetWeatherByCity(t *testing.T) {
+	mockUseCase := &MockWeatherUseCase{
+		mockGetByCity: func(ctx context.Context, city, uf string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+			if city != "São José dos Campos" || uf != "SP" || !include.AirQuality {
+				t.Errorf("Unexpected arguments: %q %q %+v", city, uf, include)
+			}
+			return entity.NewWeather("Sao Jose Dos Campos", 21), nil
+		},
+	}
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	req := httptest.NewRequest(http.MethodGet, "/city?city=S%C3%A3o+Jos%C3%A9+dos+Campos&uf=SP&include=aqi", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetWeatherByCity(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"city":"Sao Jose Dos Campos"`) {
+		t.Errorf("Unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestGetWeatherByCity_Error(t *testing.T) {
+	mockUseCase := &MockWeatherUseCase{
+		mockGetByCity: func(ctx context.Context, city, uf string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+			return nil, internalerror.CityInvalidError()
+		},
+	}
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	w := httptest.NewRecorder()
+	handler.GetWeatherByCity(w, httptest.NewRequest(http.MethodGet, "/city?city=Campinas", nil))
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if body := strings.TrimSpace(w.Body.String()); body != "Invalid city or state" {
+		t.Errorf("Unexpected body: %q", body)
+	}
+}
+
+func TestGetWeatherByCoordinates(t *testing.T) {
+	mockUseCase := &MockWeatherUseCase{
+		mockGetByCoordinates: func(ctx context.Context, lat, lon float64, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+			if lat != -23.5505 || lon != -46.6333 {
+				t.Errorf("Unexpected coordinates: %v, %v", lat, lon)
+			}
+			return entity.NewWeather("Sao Paulo", 25), nil
+		},
+	}
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	w := httptest.NewRecorder()
+	handler.GetWeatherByCoordinates(w, httptest.NewRequest(http.MethodGet, "/coordinates?lat=-23.5505&lon=-46.6333", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestGetWeatherByCoordinates_NotANumber(t *testing.T) {
+	mockUseCase := &MockWeatherUseCase{
+		mockGetByCoordinates: func(ctx context.Context, lat, lon float64, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+			t.Error("Use case should not be called with invalid coordinates")
+			return nil, nil
+		},
+	}
+	handler := NewWeatherHandler(mockUseCase, slog.New(slog.DiscardHandler))
+
+	for _, target := range []string{"/coordinates?lat=abc&lon=-46.6", "/coordinates?lat=-23.5"} {
+		w := httptest.NewRecorder()
+		handler.GetWeatherByCoordinates(w, httptest.NewRequest(http.MethodGet, target, nil))
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: expected status code %d, got %d", target, http.StatusUnprocessableEntity, w.Code)
+		}
+		if body := strings.TrimSpace(w.Body.String()); body != "Invalid coordinates" {
+			t.Errorf("%s: unexpected body %q", target, body)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+)
+
+const awesomeAPIURL = "https://cep.awesomeapi.com.br"
+
+type AwesomeAPIResponse struct {
+	CEP      string `json:"cep"`
+	Address  string `json:"address"`
+	District string `json:"district"`
+	City     string `json:"city"`
+	State    string `json:"state"`
+	CityIBGE string `json:"city_ibge"`
+}
+
+// AwesomeAPI é o provedor de CEP da cep.awesomeapi.com.br.
+type AwesomeAPI struct {
+	client *http.Client
+	logger *slog.Logger
+	// Substituída nos testes por um servidor local
+	url string
+}
+
+func NewAwesomeAPI(client *http.Client, logger *slog.Logger) *AwesomeAPI {
+	return &AwesomeAPI{client: client, logger: logger, url: awesomeAPIURL}
+}
+
+func (a *AwesomeAPI) Name() string { return "awesomeapi" }
+
+func (a *AwesomeAPI) healthURL() string { return a.url + "/" }
+
+func (a *AwesomeAPI) GetLocation(ctx context.Context, cep string) (*entity.Location, error) {
+	ctx = telemetry.WithUpstream(ctx, "awesomeapi", "/json/{cep}")
+	var location AwesomeAPIResponse
+	if err := getCEPJSON(ctx, a.client, a.logger, a.Name(), fmt.Sprintf("%s/json/%s", a.url, url.PathEscape(cep)), &location); err != nil {
+		return nil, err
+	}
+	return &entity.Location{
+		Street:       location.Address,
+		Neighborhood: location.District,
+		City:         location.City,
+		State:        location.State,
+		IBGE:         location.CityIBGE,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+)
+
+const brasilAPIURL = "https://brasilapi.com.br"
+
+type BrasilAPIResponse struct {
+	CEP          string `json:"cep"`
+	State        string `json:"state"`
+	City         string `json:"city"`
+	Neighborhood string `json:"neighborhood"`
+	Street       string `json:"street"`
+}
+
+// BrasilAPI é o provedor de CEP da brasilapi.com.br, que não informa o
+// código IBGE.
+type BrasilAPI struct {
+	client *http.Client
+	logger *slog.Logger
+	// Substituída nos testes por um servidor local
+	url string
+}
+
+func NewBrasilAPI(client *http.Client, logger *slog.Logger) *BrasilAPI {
+	return &BrasilAPI{client: client, logger: logger, url: brasilAPIURL}
+}
+
+func (b *BrasilAPI) Name() string { return "brasilapi" }
+
+func (b *BrasilAPI) healthURL() string { return b.url + "/" }
+
+func (b *BrasilAPI) GetLocation(ctx context.Context, cep string) (*entity.Location, error) {
+	ctx = telemetry.WithUpstream(ctx, "brasilapi", "/api/cep/v1/{cep}")
+	var location BrasilAPIResponse
+	if err := getCEPJSON(ctx, b.client, b.logger, b.Name(), fmt.Sprintf("%s/api/cep/v1/%s", b.url, url.PathEscape(cep)), &location); err != nil {
+		return nil, err
+	}
+	return &entity.Location{
+		Street:       location.Street,
+		Neighborhood: location.Neighborhood,
+		City:         location.City,
+		State:        location.State,
+	}, nil
+}
@@ -0,0 +1,207 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	domainGateway "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/gateway"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const DefaultCEPProviderTimeout = 2 * time.Second
+
+// DefaultCEPProviders é a ordem usada quando CEP_PROVIDERS não é definida.
+var DefaultCEPProviders = []string{"viacep", "brasilapi", "awesomeapi"}
+
+// CEPProviderConfig reúne as variáveis CEP_PROVIDER*.
+type CEPProviderConfig struct {
+	// Providers é CEP_PROVIDERS: os provedores de CEP na ordem em que são
+	// consultados. Padrão: viacep,brasilapi,awesomeapi.
+	Providers []string
+	// Timeout é CEP_PROVIDER_TIMEOUT: o prazo de cada provedor antes de passar
+	// para o próximo. Padrão: 2s.
+	Timeout time.Duration
+}
+
+// CEPProviderConfigFromEnv lê CEP_PROVIDERS e CEP_PROVIDER_TIMEOUT. Em caso
+// de erro, devolve os padrões para os valores inválidos; nomes desconhecidos
+// ou repetidos são ignorados.
+func CEPProviderConfigFromEnv(getenv func(string) string) (CEPProviderConfig, error) {
+	cfg := CEPProviderConfig{Providers: DefaultCEPProviders, Timeout: DefaultCEPProviderTimeout}
+
+	var errs []error
+	if raw := getenv("CEP_PROVIDER_TIMEOUT"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			errs = append(errs, fmt.Errorf("invalid CEP_PROVIDER_TIMEOUT %q: must be a positive duration", raw))
+		} else {
+			cfg.Timeout = parsed
+		}
+	}
+
+	if raw := getenv("CEP_PROVIDERS"); raw != "" {
+		var providers []string
+		seen := map[string]bool{}
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if _, ok := cepProviders[name]; !ok {
+				errs = append(errs, fmt.Errorf("unknown CEP provider %q in CEP_PROVIDERS", name))
+				continue
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			providers = append(providers, name)
+		}
+		if len(providers) > 0 {
+			cfg.Providers = providers
+		}
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+// cepProvider é um CEPProvider HTTP, com a URL usada no /readyz.
+type cepProvider interface {
+	domainGateway.CEPProvider
+	healthURL() string
+}
+
+var cepProviders = map[string]func(client *http.Client, logger *slog.Logger) cepProvider{
+	"viacep":     func(client *http.Client, logger *slog.Logger) cepProvider { return NewViaCEP(client, logger) },
+	"brasilapi":  func(client *http.Client, logger *slog.Logger) cepProvider { return NewBrasilAPI(client, logger) },
+	"awesomeapi": func(client *http.Client, logger *slog.Logger) cepProvider { return NewAwesomeAPI(client, logger) },
+}
+
+// CEPProviderChain consulta os provedores de CEP em ordem, passando para o
+// próximo quando um deles falha ou estoura o prazo. Um CEP inexistente
+// encerra a consulta.
+type CEPProviderChain struct {
+	providers []cepProvider
+	timeout   time.Duration
+	logger    *slog.Logger
+}
+
+func NewCEPProviderChain(cfg CEPProviderConfig, client *http.Client, logger *slog.Logger) *CEPProviderChain {
+	if len(cfg.Providers) == 0 {
+		cfg.Providers = DefaultCEPProviders
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultCEPProviderTimeout
+	}
+	chain := &CEPProviderChain{timeout: cfg.Timeout, logger: logger}
+	for _, name := range cfg.Providers {
+		if newProvider, ok := cepProviders[name]; ok {
+			chain.providers = append(chain.providers, newProvider(client, logger))
+		}
+	}
+	return chain
+}
+
+func (c *CEPProviderChain) Name() string {
+	names := make([]string, len(c.providers))
+	for i, provider := range c.providers {
+		names[i] = provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// GetLocation registra no span atual o provedor que respondeu (cep.provider)
+// e um evento cep_provider_fallback para cada provedor que falhou.
+func (c *CEPProviderChain) GetLocation(ctx context.Context, cep string) (*entity.Location, error) {
+	span := trace.SpanFromContext(ctx)
+
+	var errs []error
+	for _, provider := range c.providers {
+		providerCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		location, err := provider.GetLocation(providerCtx, cep)
+		cancel()
+		if err == nil || errors.Is(err, entity.ErrCEPNotFound) {
+			span.SetAttributes(attribute.String("cep.provider", provider.Name()))
+			return location, err
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+		// Quem chamou desistiu: não adianta tentar os demais provedores
+		if ctx.Err() != nil {
+			break
+		}
+		span.AddEvent("cep_provider_fallback", trace.WithAttributes(
+			attribute.String("cep.provider", provider.Name()),
+			attribute.String("error.message", err.Error()),
+		))
+		c.logger.WarnContext(ctx, "CEP provider failed, trying the next one", slog.String("cep.provider", provider.Name()), slog.Any("error", err))
+	}
+	return nil, fmt.Errorf("all CEP providers failed: %w", errors.Join(errs...))
+}
+
+// HealthCheck passa quando ao menos um dos provedores está acessível, já que
+// os demais cobrem a falha de um deles. Usa um client sem instrumentação para
+// que as probes não entrem nas métricas de upstream.
+func (c *CEPProviderChain) HealthCheck() telemetry.HealthCheck {
+	return telemetry.HealthCheck{Name: "cep_providers", Check: func(ctx context.Context) error {
+		errs := make([]error, len(c.providers))
+		var wg sync.WaitGroup
+		for i, provider := range c.providers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := telemetry.HTTPCheck(http.DefaultClient, provider.healthURL())(ctx); err != nil {
+					errs[i] = fmt.Errorf("%s: %w", provider.Name(), err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			if err == nil {
+				return nil
+			}
+		}
+		return errors.Join(errs...)
+	}}
+}
+
+// getCEPJSON faz o GET em endpoint e decodifica o JSON em target. 400 e 404
+// viram entity.ErrCEPNotFound; as demais respostas fora de 2xx, um erro que
+// leva ao próximo provedor.
+func getCEPJSON(ctx context.Context, client *http.Client, logger *slog.Logger, provider, endpoint string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// A URL contém o CEP sem mascarar: remove-a do erro antes de registrá-lo ou propagá-lo
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = fmt.Errorf("CEP provider request failed: %w", urlErr.Err)
+		}
+		logger.ErrorContext(ctx, "CEP provider request failed", slog.String("cep.provider", provider), slog.Any("error", err))
+		return err
+	}
+
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest:
+		return fmt.Errorf("%w: status code %d", entity.ErrCEPNotFound, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		logger.WarnContext(ctx, "CEP provider returned an error", slog.String("cep.provider", provider), slog.Int("http.response.status_code", resp.StatusCode))
+		return fmt.Errorf("failed to get location data: status code %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
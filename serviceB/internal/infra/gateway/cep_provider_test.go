@@ -0,0 +1,278 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestCEPServer(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func respondWith(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
+
+func newTestViaCEP(url string) *ViaCEP {
+	provider := NewViaCEP(http.DefaultClient, slog.New(slog.DiscardHandler))
+	provider.url = url
+	return provider
+}
+
+func newTestBrasilAPI(url string) *BrasilAPI {
+	provider := NewBrasilAPI(http.DefaultClient, slog.New(slog.DiscardHandler))
+	provider.url = url
+	return provider
+}
+
+func newTestAwesomeAPI(url string) *AwesomeAPI {
+	provider := NewAwesomeAPI(http.DefaultClient, slog.New(slog.DiscardHandler))
+	provider.url = url
+	return provider
+}
+
+func TestCEPProviders_GetLocation(t *testing.T) {
+	expected := entity.Location{Street: "Praça da Sé", Neighborhood: "Sé", City: "São Paulo", State: "SP", IBGE: "3550308"}
+	var gotPath string
+	record := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			w.Write([]byte(body))
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		provider     func(url string) cepProvider
+		body         string
+		expectedPath string
+		expected     entity.Location
+	}{
+		{
+			"ViaCEP",
+			func(url string) cepProvider { return newTestViaCEP(url) },
+			`{"cep": "01001-000", "logradouro": "Praça da Sé", "bairro": "Sé", "localidade": "São Paulo", "uf": "SP", "ibge": "3550308"}`,
+			"/ws/01001000/json/",
+			expected,
+		},
+		{
+			"BrasilAPI",
+			func(url string) cepProvider { return newTestBrasilAPI(url) },
+			`{"cep": "01001000", "state": "SP", "city": "São Paulo", "neighborhood": "Sé", "street": "Praça da Sé", "service": "open-cep"}`,
+			"/api/cep/v1/01001000",
+			entity.Location{Street: "Praça da Sé", Neighborhood: "Sé", City: "São Paulo", State: "SP"},
+		},
+		{
+			"AwesomeAPI",
+			func(url string) cepProvider { return newTestAwesomeAPI(url) },
+			`{"cep": "01001000", "address_type": "Praça", "address_name": "da Sé", "address": "Praça da Sé", "state": "SP", "district": "Sé", "city": "São Paulo", "city_ibge": "3550308", "ddd": "11"}`,
+			"/json/01001000",
+			expected,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := tc.provider(newTestCEPServer(t, record(tc.body)))
+
+			location, err := provider.GetLocation(context.Background(), "01001000")
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if gotPath != tc.expectedPath {
+				t.Errorf("Expected path %s, got %s", tc.expectedPath, gotPath)
+			}
+			if *location != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, *location)
+			}
+		})
+	}
+}
+
+func TestCEPProviders_NotFound(t *testing.T) {
+	testCases := []struct {
+		name     string
+		provider cepProvider
+	}{
+		{"ViaCEPErroBool", newTestViaCEP(newTestCEPServer(t, respondWith(http.StatusOK, `{"erro": true}`)))},
+		{"ViaCEPErroString", newTestViaCEP(newTestCEPServer(t, respondWith(http.StatusOK, `{"erro": "true"}`)))},
+		{"ViaCEPBadRequest", newTestViaCEP(newTestCEPServer(t, respondWith(http.StatusBadRequest, ``)))},
+		{"BrasilAPI", newTestBrasilAPI(newTestCEPServer(t, respondWith(http.StatusNotFound, `{"name": "CepPromiseError"}`)))},
+		{"AwesomeAPI", newTestAwesomeAPI(newTestCEPServer(t, respondWith(http.StatusNotFound, `{"code": "not_found"}`)))},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.provider.GetLocation(context.Background(), "99999999"); !errors.Is(err, entity.ErrCEPNotFound) {
+				t.Errorf("Expected ErrCEPNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCEPProviderChain_FallsBack(t *testing.T) {
+	slow := newTestCEPServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	unavailable := newTestCEPServer(t, respondWith(http.StatusServiceUnavailable, ``))
+	available := newTestCEPServer(t, respondWith(http.StatusOK, `{"cep": "01001000", "state": "SP", "city": "São Paulo"}`))
+	chain := &CEPProviderChain{
+		providers: []cepProvider{newTestViaCEP(slow), newTestAwesomeAPI(unavailable), newTestBrasilAPI(available)},
+		timeout:   50 * time.Millisecond,
+		logger:    slog.New(slog.DiscardHandler),
+	}
+	recorder := tracetest.NewSpanRecorder()
+	ctx, span := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "fetch_cep_location")
+
+	location, err := chain.GetLocation(ctx, "01001000")
+	span.End()
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if location.City != "São Paulo" || location.State != "SP" {
+		t.Errorf("Unexpected location %+v", location)
+	}
+
+	ended := recorder.Ended()[0]
+	if provider := attributeValue(ended.Attributes(), "cep.provider"); provider != "brasilapi" {
+		t.Errorf("Expected cep.provider brasilapi, got %q", provider)
+	}
+	var failed []string
+	for _, event := range ended.Events() {
+		if event.Name == "cep_provider_fallback" {
+			failed = append(failed, attributeValue(event.Attributes, "cep.provider"))
+			// O erro do timeout não pode levar a URL com o CEP sem mascarar
+			if message := attributeValue(event.Attributes, "error.message"); strings.Contains(message, "01001000") {
+				t.Errorf("Expected the CEP to be redacted from the fallback event, got %q", message)
+			}
+		}
+	}
+	if !reflect.DeepEqual(failed, []string{"viacep", "awesomeapi"}) {
+		t.Errorf("Expected fallback events for viacep and awesomeapi, got %v", failed)
+	}
+}
+
+func TestCEPProviderChain_NotFoundStops(t *testing.T) {
+	chain := &CEPProviderChain{
+		providers: []cepProvider{
+			newTestViaCEP(newTestCEPServer(t, respondWith(http.StatusOK, `{"erro": "true"}`))),
+			newTestBrasilAPI(newTestCEPServer(t, func(w http.ResponseWriter, r *http.Request) {
+				t.Error("The next provider should not be called for a CEP not found")
+			})),
+		},
+		timeout: time.Second,
+		logger:  slog.New(slog.DiscardHandler),
+	}
+
+	if _, err := chain.GetLocation(context.Background(), "99999999"); !errors.Is(err, entity.ErrCEPNotFound) {
+		t.Errorf("Expected ErrCEPNotFound, got %v", err)
+	}
+}
+
+func TestCEPProviderChain_AllFail(t *testing.T) {
+	unavailable := newTestCEPServer(t, respondWith(http.StatusInternalServerError, ``))
+	chain := &CEPProviderChain{
+		providers: []cepProvider{newTestViaCEP(unavailable), newTestBrasilAPI(unavailable)},
+		timeout:   time.Second,
+		logger:    slog.New(slog.DiscardHandler),
+	}
+
+	_, err := chain.GetLocation(context.Background(), "01001000")
+
+	if err == nil || errors.Is(err, entity.ErrCEPNotFound) {
+		t.Fatalf("Expected an unavailable error, got %v", err)
+	}
+	if err.Error() != "all CEP providers failed: viacep: failed to get location data: status code 500\nbrasilapi: failed to get location data: status code 500" {
+		t.Errorf("Unexpected error message %q", err.Error())
+	}
+}
+
+func TestCEPProviderChain_HealthCheck(t *testing.T) {
+	up := newTestCEPServer(t, respondWith(http.StatusOK, ``))
+	down := newTestCEPServer(t, respondWith(http.StatusServiceUnavailable, ``))
+	logger := slog.New(slog.DiscardHandler)
+
+	chain := &CEPProviderChain{providers: []cepProvider{newTestViaCEP(down), newTestBrasilAPI(up)}, logger: logger}
+	if err := chain.HealthCheck().Check(context.Background()); err != nil {
+		t.Errorf("Expected the check to pass with one provider up, got %v", err)
+	}
+
+	chain = &CEPProviderChain{providers: []cepProvider{newTestViaCEP(down), newTestBrasilAPI(down)}, logger: logger}
+	if err := chain.HealthCheck().Check(context.Background()); err == nil {
+		t.Error("Expected the check to fail with every provider down")
+	}
+}
+
+func TestCEPProviderConfigFromEnv(t *testing.T) {
+	testCases := []struct {
+		name      string
+		env       map[string]string
+		expected  CEPProviderConfig
+		expectErr bool
+	}{
+		{"Defaults", map[string]string{}, CEPProviderConfig{Providers: DefaultCEPProviders, Timeout: DefaultCEPProviderTimeout}, false},
+		{
+			"Custom",
+			map[string]string{"CEP_PROVIDERS": " BrasilAPI, viacep,brasilapi", "CEP_PROVIDER_TIMEOUT": "500ms"},
+			CEPProviderConfig{Providers: []string{"brasilapi", "viacep"}, Timeout: 500 * time.Millisecond},
+			false,
+		},
+		{
+			"UnknownProvider",
+			map[string]string{"CEP_PROVIDERS": "correios,awesomeapi"},
+			CEPProviderConfig{Providers: []string{"awesomeapi"}, Timeout: DefaultCEPProviderTimeout},
+			true,
+		},
+		{
+			"Invalid",
+			map[string]string{"CEP_PROVIDERS": "correios", "CEP_PROVIDER_TIMEOUT": "-1s"},
+			CEPProviderConfig{Providers: DefaultCEPProviders, Timeout: DefaultCEPProviderTimeout},
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := CEPProviderConfigFromEnv(func(key string) string { return tc.env[key] })
+
+			if (err != nil) != tc.expectErr {
+				t.Errorf("Expected error %v, got %v", tc.expectErr, err)
+			}
+			if !reflect.DeepEqual(cfg, tc.expected) {
+				t.Errorf("Expected %+v, got %+v", tc.expected, cfg)
+			}
+		})
+	}
+}
+
+func attributeValue(attributes []attribute.KeyValue, key attribute.Key) string {
+	for _, kv := range attributes {
+		if kv.Key == key {
+			return kv.Value.AsString()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+)
+
+const viaCEPURL = "https://viacep.com.br"
+
+type ViaCEPResponse struct {
+	CEP         string `json:"cep"`
+	Logradouro  string `json:"logradouro"`
+	Complemento string `json:"complemento"`
+	Bairro      string `json:"bairro"`
+	Localidade  string `json:"localidade"`
+	UF          string `json:"uf"`
+	IBGE        string `json:"ibge"`
+	// O ViaCEP responde 200 com "erro" para um CEP inexistente; o tipo varia
+	// entre true e "true" conforme a versão da API
+	Erro any `json:"erro"`
+}
+
+func (v *ViaCEPResponse) entity() *entity.Location {
+	return &entity.Location{
+		Street:       v.Logradouro,
+		Neighborhood: v.Bairro,
+		City:         v.Localidade,
+		State:        v.UF,
+		IBGE:         v.IBGE,
+	}
+}
+
+// ViaCEP é o provedor de CEP do viacep.com.br.
+type ViaCEP struct {
+	client *http.Client
+	logger *slog.Logger
+	// Substituída nos testes por um servidor local
+	url string
+}
+
+func NewViaCEP(client *http.Client, logger *slog.Logger) *ViaCEP {
+	return &ViaCEP{client: client, logger: logger, url: viaCEPURL}
+}
+
+func (v *ViaCEP) Name() string { return "viacep" }
+
+func (v *ViaCEP) healthURL() string { return v.url + "/" }
+
+func (v *ViaCEP) GetLocation(ctx context.Context, cep string) (*entity.Location, error) {
+	ctx = telemetry.WithUpstream(ctx, "viacep", "/ws/{cep}/json/")
+	var location ViaCEPResponse
+	if err := getCEPJSON(ctx, v.client, v.logger, v.Name(), fmt.Sprintf("%s/ws/%s/json/", v.url, url.PathEscape(cep)), &location); err != nil {
+		return nil, err
+	}
+	if location.Erro != nil {
+		return nil, entity.ErrCEPNotFound
+	}
+	return location.entity(), nil
+}
@@ -3,22 +3,37 @@ package gateway
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	// A imagem alpine não tem o banco de fusos usado na hora local do
+	// include=location
+	_ "time/tzdata"
 
 	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	domainGateway "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/gateway"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
-type WeatherAPI struct {
-	APIKey string
-	tracer trace.Tracer
-}
+const weatherAPIURL = "https://api.weatherapi.com"
 
-type ViaCEPResponse struct {
-	Localidade string `json:"localidade"`
+type WeatherAPI struct {
+	APIKey      string
+	client      *http.Client
+	cepProvider domainGateway.CEPProvider
+	tracer      trace.Tracer
+	logger      *slog.Logger
+	// Substituídos nos testes por servidores locais
+	viaCEPURL     string
+	weatherAPIURL string
 }
 
 type WeatherAPIResponse struct {
@@ -27,85 +42,388 @@ type WeatherAPIResponse struct {
 }
 
 type Location struct {
-	Name string `json:"name"`
+	Name           string `json:"name"`
+	Region         string `json:"region"`
+	Country        string `json:"country"`
+	TzID           string `json:"tz_id"`
+	LocaltimeEpoch int64  `json:"localtime_epoch"`
 }
 
 type Current struct {
-	Temp_c float64 `json:"temp_c"`
-	Temp_f float64 `json:"temp_f"`
-	Temp_k float64 `json:"temp_k"`
+	Temp_c           float64   `json:"temp_c"`
+	Temp_f           float64   `json:"temp_f"`
+	Temp_k           float64   `json:"temp_k"`
+	LastUpdatedEpoch int64     `json:"last_updated_epoch"`
+	Condition        Condition `json:"condition"`
+	Humidity         int       `json:"humidity"`
+	Wind_kph         float64   `json:"wind_kph"`
+	Wind_mph         float64   `json:"wind_mph"`
+	WindDegree       int       `json:"wind_degree"`
+	WindDir          string    `json:"wind_dir"`
+	Pressure_mb      float64   `json:"pressure_mb"`
+	Pressure_in      float64   `json:"pressure_in"`
+	UV               float64   `json:"uv"`
+	FeelsLike_c      float64   `json:"feelslike_c"`
+	HeatIndex_c      float64   `json:"heatindex_c"`
+	// Só vem na resposta com aqi=yes
+	AirQuality *AirQuality `json:"air_quality"`
+}
+
+type AirQuality struct {
+	CO           float64 `json:"co"`
+	NO2          float64 `json:"no2"`
+	O3           float64 `json:"o3"`
+	SO2          float64 `json:"so2"`
+	PM2_5        float64 `json:"pm2_5"`
+	PM10         float64 `json:"pm10"`
+	USEPAIndex   int     `json:"us-epa-index"`
+	GBDEFRAIndex int     `json:"gb-defra-index"`
+}
+
+func (a *AirQuality) entity() *entity.AirQuality {
+	return &entity.AirQuality{
+		CO:           a.CO,
+		NO2:          a.NO2,
+		O3:           a.O3,
+		SO2:          a.SO2,
+		PM2_5:        a.PM2_5,
+		PM10:         a.PM10,
+		USEPAIndex:   a.USEPAIndex,
+		GBDEFRAIndex: a.GBDEFRAIndex,
+	}
+}
+
+type WeatherAPIForecastResponse struct {
+	Location Location `json:"location"`
+	Forecast struct {
+		ForecastDay []ForecastDay `json:"forecastday"`
+	} `json:"forecast"`
 }
 
-func NewWeatherAPI(apikey string, tracer trace.Tracer) *WeatherAPI {
-	return &WeatherAPI{APIKey: apikey, tracer: tracer}
+type Condition struct {
+	Text string `json:"text"`
+	Icon string `json:"icon"`
 }
 
-func (w *WeatherAPI) getLocation(ctx context.Context, cep string) (*ViaCEPResponse, error) {
+type ForecastDay struct {
+	Date string `json:"date"`
+	Day  struct {
+		MaxTemp_c float64   `json:"maxtemp_c"`
+		MinTemp_c float64   `json:"mintemp_c"`
+		AvgTemp_c float64   `json:"avgtemp_c"`
+		Condition Condition `json:"condition"`
+	} `json:"day"`
+	Hour []struct {
+		Time      string    `json:"time"`
+		Temp_c    float64   `json:"temp_c"`
+		Condition Condition `json:"condition"`
+	} `json:"hour"`
+}
+
+func NewWeatherAPI(apikey string, client *http.Client, cepProvider domainGateway.CEPProvider, tracer trace.Tracer, logger *slog.Logger) *WeatherAPI {
+	return &WeatherAPI{
+		APIKey:        apikey,
+		client:        client,
+		cepProvider:   cepProvider,
+		tracer:        tracer,
+		logger:        logger,
+		viaCEPURL:     viaCEPURL,
+		weatherAPIURL: weatherAPIURL,
+	}
+}
+
+// HealthChecks verifica se a WeatherAPI está acessível; os provedores de CEP
+// têm a própria verificação em CEPProviderChain. Usa um client sem
+// instrumentação para que as probes não entrem nas métricas de upstream.
+func (w *WeatherAPI) HealthChecks() []telemetry.HealthCheck {
+	return []telemetry.HealthCheck{
+		{Name: "weatherapi", Check: telemetry.HTTPCheck(http.DefaultClient, w.weatherAPIURL+"/v1/current.json")},
+	}
+}
+
+func (w *WeatherAPI) getLocation(ctx context.Context, cep string) (_ *entity.Location, err error) {
 	ctx, spanFetchCepLocation := w.tracer.Start(ctx, "fetch_cep_location")
-	defer spanFetchCepLocation.End()
+	defer func() { telemetry.EndSpan(spanFetchCepLocation, err) }()
 
-	url := fmt.Sprintf("http://viacep.com.br/ws/%s/json/", url.QueryEscape(cep))
-	client := http.Client{}
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
+	return w.cepProvider.GetLocation(ctx, cep)
+}
+
+// resolveQuery monta o parâmetro q da WeatherAPI e o endereço já conhecido
+// da consulta. Só a consulta por CEP precisa passar pelo provedor de CEP.
+func (w *WeatherAPI) resolveQuery(ctx context.Context, query entity.WeatherQuery) (string, *entity.Location, error) {
+	switch {
+	case query.Coordinates != nil:
+		q := strconv.FormatFloat(query.Coordinates.Lat, 'f', -1, 64) + "," + strconv.FormatFloat(query.Coordinates.Lon, 'f', -1, 64)
+		return q, &entity.Location{}, nil
+	case query.City != "":
+		return stateQuery(query.City, query.UF), &entity.Location{City: query.City, State: query.UF}, nil
+	default:
+		location, err := w.getLocation(ctx, query.CEP)
+		if err != nil {
+			return "", nil, err
+		}
+		return stateQuery(location.City, location.State), location, nil
 	}
+}
 
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get location data: status code %d", resp.StatusCode)
+// stateQuery qualifica a cidade com o estado e o país, para que a WeatherAPI
+// não escolha uma cidade homônima de outro estado ou país.
+func stateQuery(city, uf string) string {
+	state, ok := entity.States[strings.ToUpper(uf)]
+	if !ok {
+		return city
 	}
+	return fmt.Sprintf("%s, %s, %s", city, state, entity.Country)
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+// checkLocation confere se a WeatherAPI respondeu para o estado da consulta.
+// Só outro estado ou outro país reconhecidos viram o evento location_mismatch
+// no span e entity.ErrLocationMismatch; uma região vazia ou desconhecida vira
+// o evento location_unverified e o clima é devolvido. Sem UF não há o que
+// conferir.
+func (w *WeatherAPI) checkLocation(ctx context.Context, uf string, location Location) error {
+	if uf == "" {
+		return nil
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("location.expected_state", uf),
+		attribute.String("location.region", location.Region),
+		attribute.String("location.country", location.Country),
 	}
 
-	var location ViaCEPResponse
-	err = json.Unmarshal(body, &location)
-	if err != nil {
-		return nil, err
+	switch entity.MatchState(uf, location.Region, location.Country) {
+	case entity.StateMatches:
+		return nil
+	case entity.StateUnknown:
+		trace.SpanFromContext(ctx).AddEvent("location_unverified", trace.WithAttributes(attrs...))
+		w.logger.WarnContext(ctx, "WeatherAPI returned a location that could not be checked against the expected state",
+			slog.String("location.expected_state", uf),
+			slog.String("location.region", location.Region),
+			slog.String("location.country", location.Country),
+		)
+		return nil
 	}
 
-	return &location, nil
+	trace.SpanFromContext(ctx).AddEvent("location_mismatch", trace.WithAttributes(attrs...))
+	w.logger.WarnContext(ctx, "WeatherAPI returned a location outside the expected state",
+		slog.String("location.expected_state", uf),
+		slog.String("location.region", location.Region),
+		slog.String("location.country", location.Country),
+	)
+	return fmt.Errorf("%w: expected %s, got %q, %q", entity.ErrLocationMismatch, uf, location.Region, location.Country)
 }
 
-func (w *WeatherAPI) GetCurrentWeather(ctx context.Context, cep string) (*entity.Weather, error) {
-	location, err := w.getLocation(ctx, cep)
+// SearchAddress busca os CEPs de um logradouro no ViaCEP, que devolve até 50
+// resultados.
+func (w *WeatherAPI) SearchAddress(ctx context.Context, search entity.AddressSearch) (_ []entity.Address, err error) {
+	ctx, spanSearchAddress := w.tracer.Start(ctx, "search_address")
+	defer func() { telemetry.EndSpan(spanSearchAddress, err) }()
+
+	ctx = telemetry.WithUpstream(ctx, "viacep", "/ws/{uf}/{city}/{street}/json/")
+	endpoint := fmt.Sprintf("%s/ws/%s/%s/%s/json/", w.viaCEPURL, url.PathEscape(search.UF), url.PathEscape(search.City), url.PathEscape(search.Street))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx, spanFetchCurrentWeather := w.tracer.Start(ctx, "fetch_current_weather")
-	defer spanFetchCurrentWeather.End()
-	url := fmt.Sprintf("https://api.weatherapi.com/v1/current.json?key=%s&q=%s&aqi=no", w.APIKey, url.QueryEscape(location.Localidade))
-	client := http.Client{}
-	resp, err := client.Get(url)
+	resp, err := w.client.Do(req)
 	if err != nil {
+		// A URL contém a UF, a cidade e o logradouro buscados: remove-a do erro
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = fmt.Errorf("ViaCEP request failed: %w", urlErr.Err)
+		}
+		w.logger.ErrorContext(ctx, "ViaCEP request failed", slog.Any("error", err))
 		return nil, err
 	}
 
 	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get location data: status code %d", resp.StatusCode)
+	switch {
+	// O ViaCEP responde 400 para uma busca que ele não aceita
+	case resp.StatusCode == http.StatusBadRequest:
+		return nil, fmt.Errorf("%w: status code %d", entity.ErrLocationNotFound, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		w.logger.WarnContext(ctx, "ViaCEP returned an error", slog.Int("http.response.status_code", resp.StatusCode))
+		return nil, fmt.Errorf("failed to search address: status code %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	var results []ViaCEPResponse
+	if err = json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, err
+	}
 
+	addresses := make([]entity.Address, 0, len(results))
+	for _, result := range results {
+		addresses = append(addresses, entity.Address{
+			CEP:          strings.ReplaceAll(result.CEP, "-", ""),
+			Street:       result.Logradouro,
+			Complement:   result.Complemento,
+			Neighborhood: result.Bairro,
+			City:         result.Localidade,
+			State:        result.UF,
+			IBGE:         result.IBGE,
+		})
+	}
+	return addresses, nil
+}
+
+func (w *WeatherAPI) GetCurrentWeather(ctx context.Context, weatherQuery entity.WeatherQuery, include entity.Include) (_ *entity.Weather, err error) {
+	q, location, err := w.resolveQuery(ctx, weatherQuery)
 	if err != nil {
 		return nil, err
 	}
 
+	ctx, spanFetchCurrentWeather := w.tracer.Start(ctx, "fetch_current_weather")
+	defer func() { telemetry.EndSpan(spanFetchCurrentWeather, err) }()
+
+	query := url.Values{"q": {q}, "aqi": {"no"}}
+	if include.AirQuality {
+		query.Set("aqi", "yes")
+	}
 	var weatherResponse WeatherAPIResponse
-	err = json.Unmarshal(body, &weatherResponse)
+	err = w.getWeatherAPI(ctx, "/v1/current.json", query, &weatherResponse)
 	if err != nil {
 		return nil, err
 	}
+	if err = w.checkLocation(ctx, location.State, weatherResponse.Location); err != nil {
+		return nil, err
+	}
 
 	weatherData := entity.NewWeather(
 		weatherResponse.Location.Name,
 		weatherResponse.Current.Temp_c,
 	)
+	weatherData.Conditions = weatherResponse.Current.conditions()
+	if include.AirQuality && weatherResponse.Current.AirQuality != nil {
+		weatherData.AirQuality = weatherResponse.Current.AirQuality.entity()
+	}
+	if include.Location {
+		weatherData.Location = weatherResponse.Location.complete(location)
+	}
 
 	return weatherData, nil
 }
+
+// complete acrescenta ao endereço da consulta o fuso e a hora local
+// informados pela WeatherAPI; a cidade só é usada se ainda não for conhecida.
+func (l Location) complete(location *entity.Location) *entity.Location {
+	if location.City == "" {
+		location.City = l.Name
+	}
+	location.Timezone = l.TzID
+	if l.LocaltimeEpoch > 0 {
+		location.LocalTime = time.Unix(l.LocaltimeEpoch, 0).UTC()
+		if tz, err := time.LoadLocation(l.TzID); err == nil {
+			location.LocalTime = location.LocalTime.In(tz)
+		}
+	}
+	return location
+}
+
+func (c Current) conditions() *entity.Conditions {
+	conditions := &entity.Conditions{
+		Humidity: c.Humidity,
+		Wind: entity.Wind{
+			SpeedKph:  c.Wind_kph,
+			SpeedMph:  c.Wind_mph,
+			Degree:    c.WindDegree,
+			Direction: c.WindDir,
+		},
+		Pressure:  entity.Pressure{Mb: c.Pressure_mb, In: c.Pressure_in},
+		UV:        c.UV,
+		FeelsLike: entity.NewTemperature(c.FeelsLike_c),
+		HeatIndex: entity.NewTemperature(c.HeatIndex_c),
+		Text:      c.Condition.Text,
+		Icon:      c.Condition.Icon,
+	}
+	// A WeatherAPI devolve o ícone sem esquema (//cdn.weatherapi.com/...)
+	if strings.HasPrefix(conditions.Icon, "//") {
+		conditions.Icon = "https:" + conditions.Icon
+	}
+	if c.LastUpdatedEpoch > 0 {
+		conditions.LastUpdated = time.Unix(c.LastUpdatedEpoch, 0).UTC()
+	}
+	return conditions
+}
+
+func (w *WeatherAPI) GetForecast(ctx context.Context, cep string, days int, hourly bool) (_ *entity.Forecast, err error) {
+	location, err := w.getLocation(ctx, cep)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, spanFetchForecast := w.tracer.Start(ctx, "fetch_forecast")
+	defer func() { telemetry.EndSpan(spanFetchForecast, err) }()
+
+	query := url.Values{"q": {stateQuery(location.City, location.State)}, "days": {strconv.Itoa(days)}, "aqi": {"no"}, "alerts": {"no"}}
+	var forecastResponse WeatherAPIForecastResponse
+	err = w.getWeatherAPI(ctx, "/v1/forecast.json", query, &forecastResponse)
+	if err != nil {
+		return nil, err
+	}
+	if err = w.checkLocation(ctx, location.State, forecastResponse.Location); err != nil {
+		return nil, err
+	}
+
+	forecast := &entity.Forecast{City: forecastResponse.Location.Name}
+	for _, forecastDay := range forecastResponse.Forecast.ForecastDay {
+		day := entity.ForecastDay{
+			Date:      forecastDay.Date,
+			MinTemp:   entity.NewTemperature(forecastDay.Day.MinTemp_c),
+			MaxTemp:   entity.NewTemperature(forecastDay.Day.MaxTemp_c),
+			AvgTemp:   entity.NewTemperature(forecastDay.Day.AvgTemp_c),
+			Condition: forecastDay.Day.Condition.Text,
+		}
+		if hourly {
+			for _, hour := range forecastDay.Hour {
+				day.Hours = append(day.Hours, entity.ForecastHour{
+					Time:      hour.Time,
+					Temp:      entity.NewTemperature(hour.Temp_c),
+					Condition: hour.Condition.Text,
+				})
+			}
+		}
+		forecast.Days = append(forecast.Days, day)
+	}
+
+	return forecast, nil
+}
+
+// getWeatherAPI faz o GET autenticado em path e decodifica o JSON em target.
+func (w *WeatherAPI) getWeatherAPI(ctx context.Context, path string, query url.Values, target any) error {
+	ctx = telemetry.WithUpstream(ctx, "weatherapi", path)
+	query.Set("key", w.APIKey)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.weatherAPIURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		// A URL contém a API key: remove-a do erro antes de registrá-lo ou propagá-lo
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = fmt.Errorf("WeatherAPI request failed: %w", urlErr.Err)
+		}
+		w.logger.ErrorContext(ctx, "WeatherAPI request failed", slog.Any("error", err))
+		return err
+	}
+
+	defer resp.Body.Close()
+	switch {
+	// A WeatherAPI responde 400 quando nenhum local corresponde ao parâmetro q;
+	// 401 e 403 são problemas da API key
+	case resp.StatusCode == http.StatusBadRequest:
+		return fmt.Errorf("%w: status code %d", entity.ErrLocationNotFound, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		w.logger.WarnContext(ctx, "WeatherAPI returned an error", slog.Int("http.response.status_code", resp.StatusCode))
+		return fmt.Errorf("failed to get weather data: status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, target)
+}
@@ -0,0 +1,460 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func newTestWeatherAPI(t *testing.T, weatherAPI http.HandlerFunc) *WeatherAPI {
+	t.Helper()
+	viaCEP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"localidade":"São Paulo","uf":"SP"}`))
+	}))
+	t.Cleanup(viaCEP.Close)
+	weather := httptest.NewServer(weatherAPI)
+	t.Cleanup(weather.Close)
+
+	api := NewWeatherAPI("secret-key", http.DefaultClient, nil, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+	setViaCEP(api, viaCEP.URL)
+	api.weatherAPIURL = weather.URL
+	return api
+}
+
+// setViaCEP aponta a busca de endereços e a consulta de CEP para o servidor
+// local em url.
+func setViaCEP(api *WeatherAPI, url string) {
+	viaCEP := NewViaCEP(http.DefaultClient, slog.New(slog.DiscardHandler))
+	viaCEP.url = url
+	api.viaCEPURL = url
+	api.cepProvider = viaCEP
+}
+
+func TestWeatherAPI_GetCurrentWeatherConditions(t *testing.T) {
+	api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/current.json" {
+			t.Errorf("Expected path /v1/current.json, got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{
+			"location": {"name": "Sao Paulo", "region": "Sao Paulo", "country": "Brazil"},
+			"current": {
+				"last_updated_epoch": 1792247400,
+				"temp_c": 25,
+				"condition": {"text": "Partly cloudy", "icon": "//cdn.weatherapi.com/weather/64x64/day/116.png"},
+				"wind_mph": 8.9, "wind_kph": 14.4, "wind_degree": 120, "wind_dir": "ESE",
+				"pressure_mb": 1015, "pressure_in": 29.97,
+				"humidity": 65, "feelslike_c": 27, "heatindex_c": 28, "uv": 6
+			}
+		}`))
+	})
+
+	weather, err := api.GetCurrentWeather(context.Background(), entity.NewCEPQuery("01001000"), entity.Include{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if weather.City != "Sao Paulo" || weather.Temp_c != 25 {
+		t.Errorf("Unexpected weather: %+v", weather)
+	}
+	conditions := weather.Conditions
+	if conditions == nil {
+		t.Fatal("Expected conditions, got nil")
+	}
+	if conditions.Humidity != 65 || conditions.UV != 6 || conditions.Pressure.Mb != 1015 || conditions.Pressure.In != 29.97 {
+		t.Errorf("Unexpected conditions: %+v", conditions)
+	}
+	if conditions.Wind.SpeedKph != 14.4 || conditions.Wind.SpeedMph != 8.9 || conditions.Wind.Degree != 120 || conditions.Wind.Direction != "ESE" {
+		t.Errorf("Unexpected wind: %+v", conditions.Wind)
+	}
+	if conditions.FeelsLike.Temp_k != 300 || conditions.HeatIndex.Temp_f != 82.4 {
+		t.Errorf("Unexpected feels-like/heat index: %+v %+v", conditions.FeelsLike, conditions.HeatIndex)
+	}
+	if conditions.Text != "Partly cloudy" || conditions.Icon != "https://cdn.weatherapi.com/weather/64x64/day/116.png" {
+		t.Errorf("Unexpected condition: %q %q", conditions.Text, conditions.Icon)
+	}
+	if !conditions.LastUpdated.Equal(time.Unix(1792247400, 0)) {
+		t.Errorf("Unexpected last_updated: %v", conditions.LastUpdated)
+	}
+}
+
+func TestWeatherAPI_GetCurrentWeatherAirQuality(t *testing.T) {
+	var aqi string
+	api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		aqi = r.URL.Query().Get("aqi")
+		w.Write([]byte(`{
+			"location": {"name": "Sao Paulo", "region": "Sao Paulo", "country": "Brazil"},
+			"current": {
+				"temp_c": 25,
+				"air_quality": {"co": 290.4, "no2": 21.8, "o3": 68.7, "so2": 5.4, "pm2_5": 12.5, "pm10": 17.9, "us-epa-index": 1, "gb-defra-index": 2}
+			}
+		}`))
+	})
+
+	weather, err := api.GetCurrentWeather(context.Background(), entity.NewCEPQuery("01001000"), entity.Include{AirQuality: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if aqi != "yes" {
+		t.Errorf("Expected aqi=yes, got %q", aqi)
+	}
+	expected := entity.AirQuality{CO: 290.4, NO2: 21.8, O3: 68.7, SO2: 5.4, PM2_5: 12.5, PM10: 17.9, USEPAIndex: 1, GBDEFRAIndex: 2}
+	if weather.AirQuality == nil || *weather.AirQuality != expected {
+		t.Errorf("Expected air quality %+v, got %+v", expected, weather.AirQuality)
+	}
+
+	weather, err = api.GetCurrentWeather(context.Background(), entity.NewCEPQuery("01001000"), entity.Include{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if aqi != "no" {
+		t.Errorf("Expected aqi=no, got %q", aqi)
+	}
+	if weather.AirQuality != nil {
+		t.Errorf("Expected no air quality, got %+v", weather.AirQuality)
+	}
+}
+
+func TestWeatherAPI_GetCurrentWeatherQuery(t *testing.T) {
+	cityQuery, _ := entity.NewCityQuery("Campinas", "SP")
+	coordinatesQuery, _ := entity.NewCoordinatesQuery(-23.5505, -46.6333)
+	testCases := []struct {
+		name     string
+		query    entity.WeatherQuery
+		expected string
+	}{
+		{"CEP", entity.NewCEPQuery("01001000"), "São Paulo, São Paulo, Brazil"},
+		{"City", cityQuery, "Campinas, São Paulo, Brazil"},
+		{"Coordinates", coordinatesQuery, "-23.5505,-46.6333"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var q string
+			api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				q = r.URL.Query().Get("q")
+				w.Write([]byte(`{"location": {"name": "Sao Paulo", "region": "Sao Paulo", "country": "Brazil"}, "current": {"temp_c": 25}}`))
+			})
+
+			if _, err := api.GetCurrentWeather(context.Background(), tc.query, entity.Include{}); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if q != tc.expected {
+				t.Errorf("Expected q=%q, got %q", tc.expected, q)
+			}
+		})
+	}
+}
+
+func TestWeatherAPI_GetCurrentWeatherLocation(t *testing.T) {
+	api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"location": {"name": "Sao Paulo", "region": "Sao Paulo", "country": "Brazil", "tz_id": "America/Sao_Paulo", "localtime_epoch": 1792247400},
+			"current": {"temp_c": 25}
+		}`))
+	})
+	viaCEP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cep": "01001-000", "logradouro": "Praça da Sé", "bairro": "Sé", "localidade": "São Paulo", "uf": "SP", "ibge": "3550308", "ddd": "11"}`))
+	}))
+	defer viaCEP.Close()
+	setViaCEP(api, viaCEP.URL)
+
+	weather, err := api.GetCurrentWeather(context.Background(), entity.NewCEPQuery("01001000"), entity.Include{Location: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	location := weather.Location
+	if location == nil {
+		t.Fatal("Expected location, got nil")
+	}
+	if location.Street != "Praça da Sé" || location.Neighborhood != "Sé" || location.City != "São Paulo" || location.State != "SP" || location.IBGE != "3550308" {
+		t.Errorf("Unexpected address: %+v", location)
+	}
+	if location.Timezone != "America/Sao_Paulo" || location.LocalTime.Format(time.RFC3339) != "2026-10-17T11:30:00-03:00" {
+		t.Errorf("Unexpected timezone or local time: %q %v", location.Timezone, location.LocalTime)
+	}
+
+	coordinatesQuery, _ := entity.NewCoordinatesQuery(-23.5505, -46.6333)
+	weather, err = api.GetCurrentWeather(context.Background(), coordinatesQuery, entity.Include{Location: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if weather.Location == nil || weather.Location.City != "Sao Paulo" || weather.Location.Street != "" {
+		t.Errorf("Expected the WeatherAPI city for a coordinates query, got %+v", weather.Location)
+	}
+
+	weather, err = api.GetCurrentWeather(context.Background(), entity.NewCEPQuery("01001000"), entity.Include{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if weather.Location != nil {
+		t.Errorf("Expected no location without include, got %+v", weather.Location)
+	}
+}
+
+func TestWeatherAPI_GetForecast(t *testing.T) {
+	var query string
+	api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/forecast.json" {
+			t.Errorf("Expected path /v1/forecast.json, got %s", r.URL.Path)
+		}
+		query = r.URL.RawQuery
+		w.Write([]byte(`{
+			"location": {"name": "Sao Paulo", "region": "Sao Paulo", "country": "Brazil"},
+			"forecast": {"forecastday": [{
+				"date": "2025-01-10",
+				"day": {"maxtemp_c": 28, "mintemp_c": 18, "avgtemp_c": 23, "condition": {"text": "Patchy rain possible"}},
+				"hour": [{"time": "2025-01-10 00:00", "temp_c": 19, "condition": {"text": "Clear"}}]
+			}]}
+		}`))
+	})
+
+	forecast, err := api.GetForecast(context.Background(), "01001000", 2, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(query, "days=2") || !strings.Contains(query, "key=secret-key") || !strings.Contains(query, "q=S%C3%A3o+Paulo%2C+S%C3%A3o+Paulo%2C+Brazil") {
+		t.Errorf("Unexpected WeatherAPI query: %s", query)
+	}
+	if forecast.City != "Sao Paulo" || len(forecast.Days) != 1 {
+		t.Fatalf("Expected one day for Sao Paulo, got %+v", forecast)
+	}
+	day := forecast.Days[0]
+	if day.MinTemp.Temp_c != 18 || day.MaxTemp.Temp_f != 82.4 || day.AvgTemp.Temp_k != 296 {
+		t.Errorf("Unexpected temperatures: %+v", day)
+	}
+	if day.Condition != "Patchy rain possible" || len(day.Hours) != 1 || day.Hours[0].Temp.Temp_c != 19 {
+		t.Errorf("Unexpected day: %+v", day)
+	}
+
+	forecast, err = api.GetForecast(context.Background(), "01001000", 2, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(forecast.Days[0].Hours) != 0 {
+		t.Errorf("Expected no hourly forecast, got %+v", forecast.Days[0].Hours)
+	}
+}
+
+// A API key faz parte da URL e não pode aparecer no erro propagado
+func TestWeatherAPI_RedactsKeyFromErrors(t *testing.T) {
+	api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	_, err := api.GetForecast(context.Background(), "01001000", 2, false)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if strings.Contains(err.Error(), "secret-key") {
+		t.Errorf("Expected API key to be redacted, got %v", err)
+	}
+}
+
+// Bom Jesus existe em vários estados: uma resposta de outro estado não pode
+// ser aceita como clima do CEP.
+func TestWeatherAPI_LocationMismatch(t *testing.T) {
+	var q string
+	api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		q = r.URL.Query().Get("q")
+		w.Write([]byte(`{
+			"location": {"name": "Bom Jesus", "region": "Rio Grande do Sul", "country": "Brazil"},
+			"current": {"temp_c": 12},
+			"forecast": {"forecastday": []}
+		}`))
+	})
+	viaCEP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"localidade": "Bom Jesus", "uf": "PI"}`))
+	}))
+	defer viaCEP.Close()
+	setViaCEP(api, viaCEP.URL)
+	recorder := tracetest.NewSpanRecorder()
+	api.tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, err := api.GetCurrentWeather(context.Background(), entity.NewCEPQuery("64900000"), entity.Include{})
+	if !errors.Is(err, entity.ErrLocationMismatch) {
+		t.Fatalf("Expected ErrLocationMismatch, got %v", err)
+	}
+	if q != "Bom Jesus, Piauí, Brazil" {
+		t.Errorf("Expected a state-qualified query, got %q", q)
+	}
+	_, err = api.GetForecast(context.Background(), "64900000", 1, false)
+	if !errors.Is(err, entity.ErrLocationMismatch) {
+		t.Fatalf("Expected ErrLocationMismatch for the forecast, got %v", err)
+	}
+
+	events := 0
+	for _, span := range recorder.Ended() {
+		for _, event := range span.Events() {
+			if event.Name != "location_mismatch" {
+				continue
+			}
+			events++
+			if span.Name() != "fetch_current_weather" && span.Name() != "fetch_forecast" {
+				t.Errorf("Unexpected span for location_mismatch: %s", span.Name())
+			}
+			if span.Status().Code != codes.Error {
+				t.Errorf("Expected %s to have error status, got %v", span.Name(), span.Status())
+			}
+		}
+	}
+	if events != 2 {
+		t.Errorf("Expected 2 location_mismatch events, got %d", events)
+	}
+}
+
+func TestWeatherAPI_UnverifiedLocationReturnsWeather(t *testing.T) {
+	for _, region := range []string{"", "Southeast Region"} {
+		t.Run(region, func(t *testing.T) {
+			api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"location": {"name": "Bom Jesus", "region": %q, "country": "Brazil"}, "current": {"temp_c": 30}}`, region)
+			})
+			viaCEP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"localidade": "Bom Jesus", "uf": "PI"}`))
+			}))
+			defer viaCEP.Close()
+			setViaCEP(api, viaCEP.URL)
+			recorder := tracetest.NewSpanRecorder()
+			api.tracer = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+			weather, err := api.GetCurrentWeather(context.Background(), entity.NewCEPQuery("64900000"), entity.Include{})
+			if err != nil || weather.City != "Bom Jesus" {
+				t.Fatalf("Expected the weather for an unverified region, got %+v (%v)", weather, err)
+			}
+
+			events := 0
+			for _, span := range recorder.Ended() {
+				for _, event := range span.Events() {
+					switch event.Name {
+					case "location_unverified":
+						events++
+					case "location_mismatch":
+						t.Errorf("Unexpected location_mismatch event in %s", span.Name())
+					}
+				}
+			}
+			if events != 1 {
+				t.Errorf("Expected 1 location_unverified event, got %d", events)
+			}
+		})
+	}
+}
+
+func TestWeatherAPI_CoordinatesSkipLocationCheck(t *testing.T) {
+	api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"location": {"name": "Montevideo", "region": "Montevideo", "country": "Uruguay"}, "current": {"temp_c": 12}}`))
+	})
+	query, _ := entity.NewCoordinatesQuery(-34.9, -56.16)
+
+	weather, err := api.GetCurrentWeather(context.Background(), query, entity.Include{})
+	if err != nil || weather.City != "Montevideo" {
+		t.Errorf("Expected the weather for the coordinates, got %+v (%v)", weather, err)
+	}
+}
+
+func TestWeatherAPI_SearchAddress(t *testing.T) {
+	var gotPath string
+	api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("WeatherAPI should not be called by the address search")
+	})
+	viaCEP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		w.Write([]byte(`[
+			{"cep": "01310-100", "logradouro": "Avenida Paulista", "complemento": "de 612 a 1510 - lado par", "bairro": "Bela Vista", "localidade": "São Paulo", "uf": "SP", "ibge": "3550308"},
+			{"cep": "01311-000", "logradouro": "Avenida Paulista", "complemento": "", "bairro": "Bela Vista", "localidade": "São Paulo", "uf": "SP", "ibge": "3550308"}
+		]`))
+	}))
+	defer viaCEP.Close()
+	setViaCEP(api, viaCEP.URL)
+	search, _ := entity.NewAddressSearch("sp", "São Paulo", "Avenida Paulista")
+
+	addresses, err := api.SearchAddress(context.Background(), search)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if gotPath != "/ws/SP/S%C3%A3o%20Paulo/Avenida%20Paulista/json/" {
+		t.Errorf("Unexpected ViaCEP path %s", gotPath)
+	}
+	if len(addresses) != 2 {
+		t.Fatalf("Expected 2 addresses, got %d", len(addresses))
+	}
+	expected := entity.Address{CEP: "01310100", Street: "Avenida Paulista", Complement: "de 612 a 1510 - lado par", Neighborhood: "Bela Vista", City: "São Paulo", State: "SP", IBGE: "3550308"}
+	if addresses[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, addresses[0])
+	}
+	if addresses[1].CEP != "01311000" || addresses[1].Complement != "" {
+		t.Errorf("Unexpected second address %+v", addresses[1])
+	}
+}
+
+func TestWeatherAPI_SearchAddressError(t *testing.T) {
+	api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {})
+	viaCEP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer viaCEP.Close()
+	setViaCEP(api, viaCEP.URL)
+	search, _ := entity.NewAddressSearch("SP", "São Paulo", "Avenida Paulista")
+
+	if _, err := api.SearchAddress(context.Background(), search); err == nil {
+		t.Error("Expected an error for a ViaCEP failure")
+	}
+}
+
+func TestWeatherAPI_SearchAddressRedactsURLFromErrors(t *testing.T) {
+	api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {})
+	viaCEP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer viaCEP.Close()
+	setViaCEP(api, viaCEP.URL)
+	search, _ := entity.NewAddressSearch("SP", "São Paulo", "Avenida Paulista")
+
+	_, err := api.SearchAddress(context.Background(), search)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if strings.Contains(err.Error(), "Paulista") {
+		t.Errorf("Expected the searched address to be redacted, got %v", err)
+	}
+}
+
+func TestWeatherAPI_ErrorClassification(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   int
+		notFound bool
+	}{
+		{"NoMatchingLocation", http.StatusBadRequest, true},
+		{"InvalidKey", http.StatusForbidden, false},
+		{"Unavailable", http.StatusServiceUnavailable, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			api := newTestWeatherAPI(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+			})
+
+			_, err := api.GetCurrentWeather(context.Background(), entity.NewCEPQuery("01001000"), entity.Include{})
+
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if notFound := errors.Is(err, entity.ErrLocationNotFound); notFound != tc.notFound {
+				t.Errorf("Expected ErrLocationNotFound %v, got %v", tc.notFound, err)
+			}
+		})
+	}
+}
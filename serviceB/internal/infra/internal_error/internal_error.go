@@ -1,10 +1,16 @@
 package internalerror
 
+import "fmt"
+
 type InternalError struct {
 	MSG  string
 	Code int
 }
 
+func (e *InternalError) Error() string {
+	return e.MSG
+}
+
 func CEPInvalidError() *InternalError {
 	return &InternalError{
 		MSG:  "Invalid zipcode",
@@ -18,3 +24,100 @@ func CEPNotFoundError() *InternalError {
 		Code: 404,
 	}
 }
+
+// UpstreamUnavailableError indica que um provedor externo (CEP ou clima) falhou
+// ou não respondeu, ao contrário de uma consulta sem resultado.
+func UpstreamUnavailableError() *InternalError {
+	return &InternalError{
+		MSG:  "Upstream service unavailable",
+		Code: 502,
+	}
+}
+
+func CityInvalidError() *InternalError {
+	return &InternalError{
+		MSG:  "Invalid city or state",
+		Code: 422,
+	}
+}
+
+func CoordinatesInvalidError() *InternalError {
+	return &InternalError{
+		MSG:  "Invalid coordinates",
+		Code: 422,
+	}
+}
+
+func LocationNotFoundError() *InternalError {
+	return &InternalError{
+		MSG:  "Can not find location",
+		Code: 404,
+	}
+}
+
+// LocationMismatchError é devolvido quando o provedor de clima responde para
+// um local de outro estado ou país.
+func LocationMismatchError() *InternalError {
+	return &InternalError{
+		MSG:  "Location mismatch",
+		Code: 404,
+	}
+}
+
+func AddressSearchInvalidError() *InternalError {
+	return &InternalError{
+		MSG:  "Invalid address search",
+		Code: 422,
+	}
+}
+
+func AddressNotFoundError() *InternalError {
+	return &InternalError{
+		MSG:  "Can not find address",
+		Code: 404,
+	}
+}
+
+func IncludeInvalidError() *InternalError {
+	return &InternalError{
+		MSG:  "Invalid include",
+		Code: 422,
+	}
+}
+
+func ForecastDaysInvalidError() *InternalError {
+	return &InternalError{
+		MSG:  "Invalid forecast days",
+		Code: 422,
+	}
+}
+
+func BatchInvalidError() *InternalError {
+	return &InternalError{
+		MSG:  "Invalid batch request",
+		Code: 422,
+	}
+}
+
+// BatchBodyTooLargeError é devolvido quando o corpo do lote passa do limite
+// lido pelo handler.
+func BatchBodyTooLargeError() *InternalError {
+	return &InternalError{
+		MSG:  "Batch request body too large",
+		Code: 413,
+	}
+}
+
+func BatchEmptyError() *InternalError {
+	return &InternalError{
+		MSG:  "Batch must have at least one zipcode",
+		Code: 422,
+	}
+}
+
+func BatchTooLargeError(max int) *InternalError {
+	return &InternalError{
+		MSG:  fmt.Sprintf("Batch exceeds the limit of %d zipcodes", max),
+		Code: 422,
+	}
+}
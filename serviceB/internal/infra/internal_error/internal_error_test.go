@@ -74,3 +74,49 @@ func TestInternalError_NonNilReturn(t *testing.T) {
 		}
 	}
 }
+
+func TestForecastDaysInvalidError(t *testing.T) {
+	err := ForecastDaysInvalidError()
+
+	if err.Code != 422 {
+		t.Errorf("Expected code 422, got %d", err.Code)
+	}
+	if err.MSG != "Invalid forecast days" {
+		t.Errorf("Expected message 'Invalid forecast days', got '%s'", err.MSG)
+	}
+}
+
+func TestIncludeInvalidError(t *testing.T) {
+	err := IncludeInvalidError()
+
+	if err.Code != 422 {
+		t.Errorf("Expected code 422, got %d", err.Code)
+	}
+	if err.MSG != "Invalid include" {
+		t.Errorf("Expected message 'Invalid include', got '%s'", err.MSG)
+	}
+}
+
+func TestBatchErrors(t *testing.T) {
+	testCases := []struct {
+		err *InternalError
+		msg string
+	}{
+		{BatchInvalidError(), "Invalid batch request"},
+		{BatchEmptyError(), "Batch must have at least one zipcode"},
+		{BatchTooLargeError(100), "Batch exceeds the limit of 100 zipcodes"},
+	}
+
+	for _, tc := range testCases {
+		if tc.err.Code != 422 {
+			t.Errorf("Expected code 422, got %d", tc.err.Code)
+		}
+		if tc.err.MSG != tc.msg {
+			t.Errorf("Expected message '%s', got '%s'", tc.msg, tc.err.MSG)
+		}
+	}
+
+	if err := BatchBodyTooLargeError(); err.Code != 413 || err.MSG != "Batch request body too large" {
+		t.Errorf("Unexpected BatchBodyTooLargeError: %+v", err)
+	}
+}
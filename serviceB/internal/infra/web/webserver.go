@@ -1,24 +1,45 @@
 package web
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"time"
 
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 )
 
+// DefaultShutdownTimeout é o prazo padrão para drenar as requisições em
+// andamento; somado ao flush da telemetria cabe nos 10s do Cloud Run.
+const DefaultShutdownTimeout = 5 * time.Second
+
 type WebServer struct {
-	Router        chi.Router
-	Handlers      map[string]http.HandlerFunc
-	WebServerPort string
+	Router      chi.Router
+	Handlers    map[string]http.HandlerFunc
+	Middlewares []func(http.Handler) http.Handler
+	// RouteMiddlewares rodam depois do roteamento, com a rota já resolvida
+	RouteMiddlewares []func(http.Handler) http.Handler
+	// OperationalHandlers (probes, /metrics, /version) ficam fora do Router e
+	// dos middlewares: as chamadas não geram logs de requisição, spans HTTP
+	// nem métricas HTTP
+	OperationalHandlers map[string]http.Handler
+	WebServerPort       string
+	ShutdownTimeout     time.Duration
+	logger              *slog.Logger
 }
 
-func NewWebServer(serverPort string) *WebServer {
+func NewWebServer(serverPort string, logger *slog.Logger) *WebServer {
 	return &WebServer{
-		Router:        chi.NewRouter(),
-		Handlers:      make(map[string]http.HandlerFunc),
-		WebServerPort: serverPort,
+		Router:              chi.NewRouter(),
+		Handlers:            make(map[string]http.HandlerFunc),
+		OperationalHandlers: make(map[string]http.Handler),
+		WebServerPort:       serverPort,
+		ShutdownTimeout:     DefaultShutdownTimeout,
+		logger:              logger,
 	}
 }
 
@@ -26,21 +47,70 @@ func (s *WebServer) AddHandler(path string, handler http.HandlerFunc) {
 	s.Handlers[path] = handler
 }
 
-// loop through the handlers and add them to the router
-// register middeleware logger
-// start the server
-func (s *WebServer) Start() {
-	s.Router.Use(middleware.Logger)
-	for path, handler := range s.Handlers {
-		s.Router.Handle(path, handler)
-	}
+func (s *WebServer) AddOperationalHandler(path string, handler http.Handler) {
+	s.OperationalHandlers[path] = handler
+}
+
+func (s *WebServer) AddMiddleware(middleware func(http.Handler) http.Handler) {
+	s.Middlewares = append(s.Middlewares, middleware)
+}
+
+func (s *WebServer) AddRouteMiddleware(middleware func(http.Handler) http.Handler) {
+	s.RouteMiddlewares = append(s.RouteMiddlewares, middleware)
+}
+
+// Start atende em WebServerPort até ctx ser cancelado e então drena as
+// requisições em andamento dentro de ShutdownTimeout.
+func (s *WebServer) Start(ctx context.Context) error {
 	addr := s.WebServerPort
 	if addr == "" {
 		addr = ":8000"
 	}
-	fmt.Println("Starting web server on port:", addr)
-	err := http.ListenAndServe(addr, s.Router)
+	listener, err := net.Listen("tcp", addr)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	s.logger.Info("starting web server", slog.String("addr", addr))
+	return s.serve(ctx, listener)
+}
+
+// loop through the handlers and add them to the router
+// register the structured request logger and the custom middlewares
+// mount the operational handlers in front of the router, without middlewares
+// serve until ctx is done
+func (s *WebServer) serve(ctx context.Context, listener net.Listener) error {
+	s.Router.Use(telemetry.RequestLogger(s.logger))
+	s.Router.Use(s.Middlewares...)
+	routes := s.Router.With(s.RouteMiddlewares...)
+	for path, handler := range s.Handlers {
+		routes.Handle(path, handler)
+	}
+	mux := http.NewServeMux()
+	for path, handler := range s.OperationalHandlers {
+		mux.Handle(path, handler)
+	}
+	mux.Handle("/", s.Router)
+	server := &http.Server{Handler: mux}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Serve(listener) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Para de aceitar conexões e aguarda as requisições em andamento
+	s.logger.Info("shutting down web server", slog.Duration("timeout", s.ShutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		server.Close()
+		return fmt.Errorf("failed to drain in-flight requests: %w", err)
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
 	}
+	return nil
 }
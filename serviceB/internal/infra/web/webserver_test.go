@@ -0,0 +1,133 @@
+package web
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// startSlowServer sobe o servidor com um handler que só responde quando
+// release é fechado.
+func startSlowServer(t *testing.T, timeout time.Duration) (context.CancelFunc, string, chan struct{}, chan struct{}, chan error) {
+	t.Helper()
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	server := NewWebServer("", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	server.ShutdownTimeout = timeout
+	server.AddHandler("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.serve(ctx, listener) }()
+	return cancel, "http://" + listener.Addr().String(), started, release, done
+}
+
+func TestWebServer_DrainsInFlightRequests(t *testing.T) {
+	cancel, url, started, release, done := startSlowServer(t, time.Second)
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	<-started
+	cancel()
+
+	// Novas conexões são recusadas enquanto a requisição em andamento termina
+	time.Sleep(50 * time.Millisecond)
+	if _, err := http.Get(url + "/slow"); err == nil {
+		t.Error("expected new connections to be refused during shutdown")
+	}
+
+	close(release)
+	if got := <-status; got != http.StatusOK {
+		t.Errorf("expected in-flight request to complete with 200, got %d", got)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("expected clean shutdown, got %v", err)
+	}
+}
+
+func TestWebServer_ShutdownDeadlineExceeded(t *testing.T) {
+	cancel, url, started, release, done := startSlowServer(t, 50*time.Millisecond)
+	defer close(release)
+
+	go http.Get(url + "/slow")
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected an error when in-flight requests exceed the deadline")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not stop after the shutdown deadline")
+	}
+}
+
+// As rotas operacionais não passam pelos middlewares; as demais, inclusive
+// as desconhecidas, continuam passando.
+func TestWebServer_OperationalHandlersSkipMiddlewares(t *testing.T) {
+	server := NewWebServer("", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	server.AddMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Instrumented", "true")
+			next.ServeHTTP(w, r)
+		})
+	})
+	server.AddHandler("/", func(w http.ResponseWriter, r *http.Request) {})
+	server.AddOperationalHandler("/metrics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.serve(ctx, listener) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	testCases := []struct {
+		path         string
+		instrumented bool
+	}{
+		{"/", true},
+		{"/unknown", true},
+		{"/metrics", false},
+	}
+	for _, tc := range testCases {
+		resp, err := http.Get("http://" + listener.Addr().String() + tc.path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", tc.path, err)
+		}
+		resp.Body.Close()
+		if instrumented := resp.Header.Get("X-Instrumented") == "true"; instrumented != tc.instrumented {
+			t.Errorf("GET %s: expected instrumented=%v, got %v", tc.path, tc.instrumented, instrumented)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/pkg/utility"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	DefaultBatchConcurrency = 8
+	DefaultBatchMaxCEPs     = 100
+)
+
+// BatchConfig reúne as variáveis BATCH_*.
+type BatchConfig struct {
+	// Concurrency é BATCH_CONCURRENCY: quantos CEPs de um lote são consultados
+	// ao mesmo tempo. Padrão: 8.
+	Concurrency int
+	// MaxCEPs é BATCH_MAX_CEPS: o tamanho máximo do lote, antes de remover os
+	// duplicados. Padrão: 100.
+	MaxCEPs int
+}
+
+// BatchConfigFromEnv lê BATCH_CONCURRENCY e BATCH_MAX_CEPS. Em caso de erro,
+// devolve os padrões para os valores inválidos.
+func BatchConfigFromEnv(getenv func(string) string) (BatchConfig, error) {
+	cfg := BatchConfig{Concurrency: DefaultBatchConcurrency, MaxCEPs: DefaultBatchMaxCEPs}
+
+	var errs []error
+	settings := []struct {
+		name  string
+		value *int
+	}{
+		{"BATCH_CONCURRENCY", &cfg.Concurrency},
+		{"BATCH_MAX_CEPS", &cfg.MaxCEPs},
+	}
+	for _, setting := range settings {
+		raw := getenv(setting.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			errs = append(errs, fmt.Errorf("invalid %s %q: must be a positive integer", setting.name, raw))
+			continue
+		}
+		*setting.value = parsed
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+// BatchWeatherUseCase consulta o clima atual de vários CEPs em paralelo,
+// reaproveitando o WeatherUseCase de um CEP para cada item.
+type BatchWeatherUseCase struct {
+	weather *WeatherUseCase
+	tracer  trace.Tracer
+	logger  *slog.Logger
+	cfg     BatchConfig
+}
+
+func NewBatchWeatherUseCase(weather *WeatherUseCase, tracer trace.Tracer, logger *slog.Logger, cfg BatchConfig) *BatchWeatherUseCase {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultBatchConcurrency
+	}
+	if cfg.MaxCEPs <= 0 {
+		cfg.MaxCEPs = DefaultBatchMaxCEPs
+	}
+	return &BatchWeatherUseCase{weather: weather, tracer: tracer, logger: logger, cfg: cfg}
+}
+
+// GetCurrentWeather valida o lote, remove os CEPs duplicados e chama yield
+// uma vez por CEP único, na ordem em que as consultas terminam. index é a
+// posição do CEP no lote sem duplicados. yield é sempre chamado na goroutine
+// de quem chamou; um lote inválido retorna o erro sem chamar yield.
+func (b *BatchWeatherUseCase) GetCurrentWeather(ctx context.Context, ceps []string, include entity.Include, yield func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError)) *internalerror.InternalError {
+	if len(ceps) == 0 {
+		return internalerror.BatchEmptyError()
+	}
+	if len(ceps) > b.cfg.MaxCEPs {
+		return internalerror.BatchTooLargeError(b.cfg.MaxCEPs)
+	}
+
+	unique := uniqueCEPs(ceps)
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("batch.size", len(ceps)),
+		attribute.Int("batch.unique", len(unique)),
+	)
+
+	type result struct {
+		index   int
+		weather *entity.Weather
+		err     *internalerror.InternalError
+	}
+	jobs := make(chan int)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for range min(b.cfg.Concurrency, len(unique)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				weather, err := b.getItem(ctx, index, unique[index], include)
+				results <- result{index: index, weather: weather, err: err}
+			}
+		}()
+	}
+	go func() {
+		for index := range unique {
+			jobs <- index
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		yield(result.index, unique[result.index], result.weather, result.err)
+	}
+	return nil
+}
+
+// getItem consulta um CEP do lote dentro do seu próprio span, filho do span
+// da requisição.
+func (b *BatchWeatherUseCase) getItem(ctx context.Context, index int, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+	ctx = telemetry.WithItemCEP(ctx, cep)
+	ctx, span := b.tracer.Start(ctx, "batch_item", trace.WithAttributes(
+		attribute.Int("batch.item.index", index),
+		attribute.String("batch.item.cep", telemetry.RedactCEP(cep)),
+	))
+
+	weather, err := b.weather.GetCurrentWeather(ctx, cep, include)
+	if err != nil {
+		span.SetAttributes(attribute.Int("http.response.status_code", err.Code))
+		telemetry.EndSpan(span, err)
+		return nil, err
+	}
+	span.End()
+	return weather, nil
+}
+
+// uniqueCEPs remove os CEPs repetidos, mantendo a ordem da primeira
+// ocorrência. CEPs válidos são comparados já formatados (01001-000 e
+// 01001000 são o mesmo); os inválidos, como foram enviados.
+func uniqueCEPs(ceps []string) []string {
+	seen := make(map[string]bool, len(ceps))
+	unique := make([]string, 0, len(ceps))
+	for _, cep := range ceps {
+		cep = strings.TrimSpace(cep)
+		if formatted, err := utility.CEPFormatter(cep); err == nil {
+			cep = formatted
+		}
+		if seen[cep] {
+			continue
+		}
+		seen[cep] = true
+		unique = append(unique, cep)
+	}
+	return unique
+}
@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+type batchResult struct {
+	cep     string
+	weather *entity.Weather
+	err     *internalerror.InternalError
+}
+
+func runBatch(t *testing.T, batch *BatchWeatherUseCase, ctx context.Context, ceps []string) (map[int]batchResult, *internalerror.InternalError) {
+	t.Helper()
+	results := map[int]batchResult{}
+	err := batch.GetCurrentWeather(ctx, ceps, entity.Include{}, func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError) {
+		if _, exists := results[index]; exists {
+			t.Errorf("Index %d yielded twice", index)
+		}
+		results[index] = batchResult{cep: cep, weather: weather, err: err}
+	})
+	return results, err
+}
+
+func TestBatchGetCurrentWeather_DeduplicatesAndReportsPerItem(t *testing.T) {
+	var calls sync.Map
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			count, _ := calls.LoadOrStore(query.CEP, new(atomic.Int32))
+			count.(*atomic.Int32).Add(1)
+			if query.CEP == "99999999" {
+				return nil, entity.ErrCEPNotFound
+			}
+			return entity.NewWeather("City "+query.CEP, 20), nil
+		},
+	}
+	tracer := noop.NewTracerProvider().Tracer("test")
+	batch := NewBatchWeatherUseCase(NewWeatherUseCase(mockGateway, tracer, slog.New(slog.DiscardHandler)), tracer, slog.New(slog.DiscardHandler), BatchConfig{Concurrency: 2, MaxCEPs: 10})
+
+	results, err := runBatch(t, batch, context.Background(), []string{"01001-000", "99999999", "01001000", "123", " 01001000 "})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 unique results, got %d: %+v", len(results), results)
+	}
+	if results[0].cep != "01001000" || results[0].weather == nil || results[0].weather.City != "City 01001000" {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+	if results[1].cep != "99999999" || results[1].err == nil || results[1].err.Code != 404 {
+		t.Errorf("Expected not found for second result, got %+v", results[1])
+	}
+	if results[2].cep != "123" || results[2].err == nil || results[2].err.Code != 422 {
+		t.Errorf("Expected invalid zipcode for third result, got %+v", results[2])
+	}
+	if count, _ := calls.Load("01001000"); count.(*atomic.Int32).Load() != 1 {
+		t.Errorf("Expected duplicated CEP to be fetched once, got %d", count.(*atomic.Int32).Load())
+	}
+}
+
+func TestBatchGetCurrentWeather_BoundsConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				previous := maxInFlight.Load()
+				if current <= previous || maxInFlight.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return entity.NewWeather("City", 20), nil
+		},
+	}
+	tracer := noop.NewTracerProvider().Tracer("test")
+	batch := NewBatchWeatherUseCase(NewWeatherUseCase(mockGateway, tracer, slog.New(slog.DiscardHandler)), tracer, slog.New(slog.DiscardHandler), BatchConfig{Concurrency: 3, MaxCEPs: 20})
+
+	ceps := []string{}
+	for i := range 10 {
+		ceps = append(ceps, fmt.Sprintf("010010%02d", i))
+	}
+	results, err := runBatch(t, batch, context.Background(), ceps)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 10 {
+		t.Errorf("Expected 10 results, got %d", len(results))
+	}
+	if maxInFlight.Load() > 3 {
+		t.Errorf("Expected at most 3 concurrent lookups, got %d", maxInFlight.Load())
+	}
+}
+
+func TestBatchGetCurrentWeather_InvalidBatch(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			t.Error("Gateway should not be called for an invalid batch")
+			return nil, nil
+		},
+	}
+	tracer := noop.NewTracerProvider().Tracer("test")
+	batch := NewBatchWeatherUseCase(NewWeatherUseCase(mockGateway, tracer, slog.New(slog.DiscardHandler)), tracer, slog.New(slog.DiscardHandler), BatchConfig{MaxCEPs: 2})
+
+	results, err := runBatch(t, batch, context.Background(), nil)
+	if err == nil || err.MSG != "Batch must have at least one zipcode" || len(results) != 0 {
+		t.Errorf("Expected empty batch error, got %v", err)
+	}
+
+	results, err = runBatch(t, batch, context.Background(), []string{"01001000", "01001000", "01001000"})
+	if err == nil || err.MSG != "Batch exceeds the limit of 2 zipcodes" || len(results) != 0 {
+		t.Errorf("Expected batch too large error, got %v", err)
+	}
+}
+
+func TestBatchGetCurrentWeather_ItemSpansShareTrace(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			if query.CEP == "99999999" {
+				return nil, entity.ErrCEPNotFound
+			}
+			return entity.NewWeather("City", 20), nil
+		},
+	}
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	batch := NewBatchWeatherUseCase(NewWeatherUseCase(mockGateway, tracer, slog.New(slog.DiscardHandler)), tracer, slog.New(slog.DiscardHandler), BatchConfig{})
+
+	ctx, root := tracer.Start(context.Background(), "POST /batch")
+	runBatch(t, batch, ctx, []string{"01001000", "99999999"})
+	root.End()
+
+	items := 0
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("Span %s is not in the batch trace", span.Name())
+		}
+		if span.Name() != "batch_item" {
+			continue
+		}
+		items++
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("Expected batch_item to be a child of the request span")
+		}
+	}
+	if items != 2 {
+		t.Errorf("Expected 2 batch_item spans, got %d", items)
+	}
+}
+
+func TestBatchConfigFromEnv(t *testing.T) {
+	env := map[string]string{"BATCH_CONCURRENCY": "4", "BATCH_MAX_CEPS": "abc"}
+	cfg, err := BatchConfigFromEnv(func(key string) string { return env[key] })
+
+	if err == nil {
+		t.Error("Expected error for invalid BATCH_MAX_CEPS")
+	}
+	if cfg.Concurrency != 4 || cfg.MaxCEPs != DefaultBatchMaxCEPs {
+		t.Errorf("Expected concurrency 4 and default max, got %+v", cfg)
+	}
+}
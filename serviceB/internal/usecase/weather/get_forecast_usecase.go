@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/pkg/utility"
+)
+
+func (w *WeatherUseCase) GetForecast(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, *internalerror.InternalError) {
+	if err := entity.ValidateForecastDays(days); err != nil {
+		return nil, internalerror.ForecastDaysInvalidError()
+	}
+
+	// Span para validação do CEP
+	_, spanValidateCep := w.tracer.Start(ctx, "validate_cep")
+	cepFormated, err := utility.CEPFormatter(cep)
+	telemetry.EndSpan(spanValidateCep, err)
+	if err != nil {
+		return nil, internalerror.CEPInvalidError()
+	}
+
+	ctx, spanFetchForecastData := w.tracer.Start(ctx, "fetch_forecast_data")
+	forecast, err := w.weatherGateway.GetForecast(ctx, cepFormated, days, hourly)
+	telemetry.EndSpan(spanFetchForecastData, err)
+	if err != nil {
+		w.logger.WarnContext(ctx, "failed to fetch forecast data", slog.Any("error", err))
+		return nil, gatewayError(err, internalerror.CEPNotFoundError)
+	}
+
+	return forecast, nil
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestGetForecast_Success(t *testing.T) {
+	var gotCEP string
+	var gotDays int
+	var gotHourly bool
+	mockGateway := &MockWeatherGateway{
+		mockGetForecast: func(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error) {
+			gotCEP, gotDays, gotHourly = cep, days, hourly
+			return &entity.Forecast{
+				City: "São Paulo",
+				Days: []entity.ForecastDay{{Date: "2025-01-10", MinTemp: entity.NewTemperature(18), MaxTemp: entity.NewTemperature(28)}},
+			}, nil
+		},
+	}
+	useCase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	result, err := useCase.GetForecast(context.Background(), "04446-160", 5, true)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if gotCEP != "04446160" || gotDays != 5 || !gotHourly {
+		t.Errorf("Expected gateway call with 04446160, 5 days and hourly, got %s, %d, %v", gotCEP, gotDays, gotHourly)
+	}
+	if result.City != "São Paulo" || len(result.Days) != 1 {
+		t.Errorf("Expected one day for São Paulo, got %+v", result)
+	}
+}
+
+func TestGetForecast_InvalidDays(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetForecast: func(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error) {
+			t.Error("Gateway should not be called with invalid days")
+			return nil, nil
+		},
+	}
+	useCase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	for _, days := range []int{0, entity.MaxForecastDays + 1} {
+		_, err := useCase.GetForecast(context.Background(), "04446-160", days, false)
+		if err == nil || err.Code != 422 || err.MSG != "Invalid forecast days" {
+			t.Errorf("Expected invalid forecast days error for %d days, got %v", days, err)
+		}
+	}
+}
+
+func TestGetForecast_InvalidCEP(t *testing.T) {
+	useCase := NewWeatherUseCase(&MockWeatherGateway{}, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	_, err := useCase.GetForecast(context.Background(), "1234", 3, false)
+
+	if err == nil || err.Code != 422 {
+		t.Errorf("Expected invalid zipcode error, got %v", err)
+	}
+}
+
+func TestGetForecast_GatewayErrorEndsSpans(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetForecast: func(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error) {
+			return nil, errors.New("timeout connecting to weather API")
+		},
+	}
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	useCase := NewWeatherUseCase(mockGateway, tracer, slog.New(slog.DiscardHandler))
+
+	_, err := useCase.GetForecast(context.Background(), "04446-160", 3, false)
+
+	if err == nil || err.Code != 502 {
+		t.Errorf("Expected upstream unavailable error, got %v", err)
+	}
+	if started, ended := len(recorder.Started()), len(recorder.Ended()); started != 2 || ended != 2 {
+		t.Errorf("Expected 2 started and ended spans, got %d and %d", started, ended)
+	}
+	if last := recorder.Ended()[1]; last.Name() != "fetch_forecast_data" {
+		t.Errorf("Expected last span fetch_forecast_data, got %s", last.Name())
+	}
+}
+
+func TestGetForecast_LocationMismatch(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetForecast: func(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error) {
+			return nil, fmt.Errorf("%w: expected PI", entity.ErrLocationMismatch)
+		},
+	}
+	useCase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	_, err := useCase.GetForecast(context.Background(), "64900-000", 3, false)
+
+	if err == nil || err.Code != 404 || err.MSG != "Location mismatch" {
+		t.Errorf("Expected location mismatch error, got %v", err)
+	}
+}
@@ -2,10 +2,13 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 
 	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
 	domainGateway "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/gateway"
 	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
 
 	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/pkg/utility"
 	"go.opentelemetry.io/otel/trace"
@@ -14,31 +17,83 @@ import (
 type WeatherUseCase struct {
 	weatherGateway domainGateway.WeatherGateway
 	tracer         trace.Tracer
+	logger         *slog.Logger
 }
 
-func NewWeatherUseCase(gateway domainGateway.WeatherGateway, tracer trace.Tracer) *WeatherUseCase {
-	return &WeatherUseCase{weatherGateway: gateway, tracer: tracer}
+func NewWeatherUseCase(gateway domainGateway.WeatherGateway, tracer trace.Tracer, logger *slog.Logger) *WeatherUseCase {
+	return &WeatherUseCase{weatherGateway: gateway, tracer: tracer, logger: logger}
 }
 
-func (w *WeatherUseCase) GetCurrentWeather(ctx context.Context, cep string) (*entity.Weather, *internalerror.InternalError) {
+func (w *WeatherUseCase) GetCurrentWeather(ctx context.Context, cep string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
 	// Span para validação do CEP
-	ctx, spanValidateCep := w.tracer.Start(ctx, "validate_cep")
+	_, spanValidateCep := w.tracer.Start(ctx, "validate_cep")
 	cepFormated, err := utility.CEPFormatter(cep)
+	telemetry.EndSpan(spanValidateCep, err)
 	if err != nil {
 		return nil, internalerror.CEPInvalidError()
 	}
-	spanValidateCep.End()
 
+	return w.fetchCurrentWeather(ctx, entity.NewCEPQuery(cepFormated), include, internalerror.CEPNotFoundError)
+}
+
+// GetCurrentWeatherByCity consulta o clima pelo nome da cidade e a UF, sem
+// passar pelo ViaCEP.
+func (w *WeatherUseCase) GetCurrentWeatherByCity(ctx context.Context, city, uf string, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+	_, spanValidateCity := w.tracer.Start(ctx, "validate_city")
+	query, err := entity.NewCityQuery(city, uf)
+	telemetry.EndSpan(spanValidateCity, err)
+	if err != nil {
+		return nil, internalerror.CityInvalidError()
+	}
+
+	return w.fetchCurrentWeather(ctx, query, include, internalerror.LocationNotFoundError)
+}
+
+// GetCurrentWeatherByCoordinates consulta o clima por latitude e longitude.
+func (w *WeatherUseCase) GetCurrentWeatherByCoordinates(ctx context.Context, lat, lon float64, include entity.Include) (*entity.Weather, *internalerror.InternalError) {
+	_, spanValidateCoordinates := w.tracer.Start(ctx, "validate_coordinates")
+	query, err := entity.NewCoordinatesQuery(lat, lon)
+	telemetry.EndSpan(spanValidateCoordinates, err)
+	if err != nil {
+		return nil, internalerror.CoordinatesInvalidError()
+	}
+
+	return w.fetchCurrentWeather(ctx, query, include, internalerror.LocationNotFoundError)
+}
+
+// fetchCurrentWeather é a parte comum das consultas, depois da validação;
+// notFound é o erro devolvido quando o local consultado não existe.
+func (w *WeatherUseCase) fetchCurrentWeather(ctx context.Context, query entity.WeatherQuery, include entity.Include, notFound func() *internalerror.InternalError) (*entity.Weather, *internalerror.InternalError) {
 	ctx, spanFetchWeatherData := w.tracer.Start(ctx, "fetch_weather_data")
-	weatherData, err := w.weatherGateway.GetCurrentWeather(ctx, cepFormated)
+	weatherData, err := w.weatherGateway.GetCurrentWeather(ctx, query, include)
+	telemetry.EndSpan(spanFetchWeatherData, err)
 	if err != nil {
-		return nil, internalerror.CEPNotFoundError()
+		w.logger.WarnContext(ctx, "failed to fetch weather data", slog.Any("error", err))
+		return nil, gatewayError(err, notFound)
 	}
-	spanFetchWeatherData.End()
 
 	currentWeather := entity.NewWeather(
 		weatherData.City,
 		weatherData.Temp_c)
+	currentWeather.Conditions = weatherData.Conditions
+	currentWeather.AirQuality = weatherData.AirQuality
+	currentWeather.Location = weatherData.Location
 
 	return currentWeather, nil
 }
+
+// gatewayError separa as respostas definitivas dos provedores (CEP ou local
+// inexistente, local divergente), que viram 404, das falhas dos provedores,
+// que viram 502.
+func gatewayError(err error, notFound func() *internalerror.InternalError) *internalerror.InternalError {
+	switch {
+	case errors.Is(err, entity.ErrLocationMismatch):
+		return internalerror.LocationMismatchError()
+	case errors.Is(err, entity.ErrCEPNotFound):
+		return internalerror.CEPNotFoundError()
+	case errors.Is(err, entity.ErrLocationNotFound):
+		return notFound()
+	default:
+		return internalerror.UpstreamUnavailableError()
+	}
+}
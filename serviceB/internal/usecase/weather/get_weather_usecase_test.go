@@ -3,33 +3,49 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
 	"testing"
 
 	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // MockWeatherGateway é um mock do WeatherGateway para testes
 type MockWeatherGateway struct {
-	mockGetCurrentWeather func(ctx context.Context, cep string) (*entity.Weather, error)
+	mockGetCurrentWeather func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error)
+	mockGetForecast       func(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error)
+	mockSearchAddress     func(ctx context.Context, search entity.AddressSearch) ([]entity.Address, error)
 }
 
-func (m *MockWeatherGateway) GetCurrentWeather(ctx context.Context, cep string) (*entity.Weather, error) {
-	return m.mockGetCurrentWeather(ctx, cep)
+func (m *MockWeatherGateway) GetCurrentWeather(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+	return m.mockGetCurrentWeather(ctx, query, include)
+}
+
+func (m *MockWeatherGateway) GetForecast(ctx context.Context, cep string, days int, hourly bool) (*entity.Forecast, error) {
+	return m.mockGetForecast(ctx, cep, days, hourly)
+}
+
+func (m *MockWeatherGateway) SearchAddress(ctx context.Context, search entity.AddressSearch) ([]entity.Address, error) {
+	return m.mockSearchAddress(ctx, search)
 }
 
 func TestGetCurrentWeather_Success(t *testing.T) {
 	// Arrange
 	mockGateway := &MockWeatherGateway{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, error) {
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
 			return entity.NewWeather("São Paulo", 25.5), nil
 		},
 	}
 	mockTracer := noop.NewTracerProvider().Tracer("test")
-	useCase := NewWeatherUseCase(mockGateway, mockTracer)
+	useCase := NewWeatherUseCase(mockGateway, mockTracer, slog.New(slog.DiscardHandler))
 
 	// Act
-	result, err := useCase.GetCurrentWeather(context.Background(), "04446-160")
+	result, err := useCase.GetCurrentWeather(context.Background(), "04446-160", entity.Include{})
 
 	// Assert
 	if err != nil {
@@ -52,21 +68,55 @@ func TestGetCurrentWeather_Success(t *testing.T) {
 	}
 }
 
+func TestGetCurrentWeather_KeepsOptionalData(t *testing.T) {
+	conditions := &entity.Conditions{Humidity: 80, Text: "Light rain"}
+	airQuality := &entity.AirQuality{PM10: 20, USEPAIndex: 1}
+	location := &entity.Location{City: "Curitiba", State: "PR", IBGE: "4106902"}
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			if !include.AirQuality || !include.Location {
+				t.Errorf("Expected include to be forwarded, got %+v", include)
+			}
+			weather := entity.NewWeather("Curitiba", 15)
+			weather.Conditions = conditions
+			weather.AirQuality = airQuality
+			weather.Location = location
+			return weather, nil
+		},
+	}
+	useCase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	result, err := useCase.GetCurrentWeather(context.Background(), "80010-000", entity.Include{AirQuality: true, Location: true})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Conditions != conditions {
+		t.Errorf("Expected conditions %+v, got %+v", conditions, result.Conditions)
+	}
+	if result.AirQuality != airQuality {
+		t.Errorf("Expected air quality %+v, got %+v", airQuality, result.AirQuality)
+	}
+	if result.Location != location {
+		t.Errorf("Expected location %+v, got %+v", location, result.Location)
+	}
+}
+
 func TestGetCurrentWeather_CEPWithoutDash(t *testing.T) {
 	// Arrange
 	mockGateway := &MockWeatherGateway{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, error) {
-			if cep != "04446160" {
-				t.Errorf("Expected CEP '04446160', got '%s'", cep)
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			if query.CEP != "04446160" {
+				t.Errorf("Expected CEP '04446160', got '%s'", query.CEP)
 			}
 			return entity.NewWeather("São Paulo", 20.0), nil
 		},
 	}
 	mockTracer := noop.NewTracerProvider().Tracer("test")
-	useCase := NewWeatherUseCase(mockGateway, mockTracer)
+	useCase := NewWeatherUseCase(mockGateway, mockTracer, slog.New(slog.DiscardHandler))
 
 	// Act
-	result, err := useCase.GetCurrentWeather(context.Background(), "04446160")
+	result, err := useCase.GetCurrentWeather(context.Background(), "04446160", entity.Include{})
 
 	// Assert
 	if err != nil {
@@ -80,18 +130,18 @@ func TestGetCurrentWeather_CEPWithoutDash(t *testing.T) {
 func TestGetCurrentWeather_CEPWithDash(t *testing.T) {
 	// Arrange
 	mockGateway := &MockWeatherGateway{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, error) {
-			if cep != "04446160" {
-				t.Errorf("Expected formatted CEP '04446160', got '%s'", cep)
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			if query.CEP != "04446160" {
+				t.Errorf("Expected formatted CEP '04446160', got '%s'", query.CEP)
 			}
 			return entity.NewWeather("São Paulo", 20.0), nil
 		},
 	}
 	mockTracer := noop.NewTracerProvider().Tracer("test")
-	useCase := NewWeatherUseCase(mockGateway, mockTracer)
+	useCase := NewWeatherUseCase(mockGateway, mockTracer, slog.New(slog.DiscardHandler))
 
 	// Act
-	result, err := useCase.GetCurrentWeather(context.Background(), "04446-160")
+	result, err := useCase.GetCurrentWeather(context.Background(), "04446-160", entity.Include{})
 
 	// Assert
 	if err != nil {
@@ -105,13 +155,13 @@ func TestGetCurrentWeather_CEPWithDash(t *testing.T) {
 func TestGetCurrentWeather_InvalidCEP(t *testing.T) {
 	// Arrange
 	mockGateway := &MockWeatherGateway{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, error) {
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
 			t.Error("Gateway should not be called for invalid CEP")
 			return nil, errors.New("should not reach here")
 		},
 	}
 	mockTracer := noop.NewTracerProvider().Tracer("test")
-	useCase := NewWeatherUseCase(mockGateway, mockTracer)
+	useCase := NewWeatherUseCase(mockGateway, mockTracer, slog.New(slog.DiscardHandler))
 
 	invalidCEPs := []string{
 		"1234567",
@@ -125,7 +175,7 @@ func TestGetCurrentWeather_InvalidCEP(t *testing.T) {
 
 	// Act & Assert
 	for _, invalidCEP := range invalidCEPs {
-		result, err := useCase.GetCurrentWeather(context.Background(), invalidCEP)
+		result, err := useCase.GetCurrentWeather(context.Background(), invalidCEP, entity.Include{})
 
 		if err == nil {
 			t.Errorf("Expected error for invalid CEP '%s', got nil", invalidCEP)
@@ -146,15 +196,15 @@ func TestGetCurrentWeather_InvalidCEP(t *testing.T) {
 func TestGetCurrentWeather_CEPNotFound(t *testing.T) {
 	// Arrange
 	mockGateway := &MockWeatherGateway{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, error) {
-			return nil, errors.New("CEP not found in external API")
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			return nil, fmt.Errorf("%w: status code 404", entity.ErrCEPNotFound)
 		},
 	}
 	mockTracer := noop.NewTracerProvider().Tracer("test")
-	useCase := NewWeatherUseCase(mockGateway, mockTracer)
+	useCase := NewWeatherUseCase(mockGateway, mockTracer, slog.New(slog.DiscardHandler))
 
 	// Act
-	result, err := useCase.GetCurrentWeather(context.Background(), "99999-999")
+	result, err := useCase.GetCurrentWeather(context.Background(), "99999-999", entity.Include{})
 
 	// Assert
 	if err == nil {
@@ -171,18 +221,19 @@ func TestGetCurrentWeather_CEPNotFound(t *testing.T) {
 	}
 }
 
+// Uma falha do provedor não é um CEP inexistente: responde 502, e não 404
 func TestGetCurrentWeather_GatewayError(t *testing.T) {
 	// Arrange
 	mockGateway := &MockWeatherGateway{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, error) {
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
 			return nil, errors.New("timeout connecting to weather API")
 		},
 	}
 	mockTracer := noop.NewTracerProvider().Tracer("test")
-	useCase := NewWeatherUseCase(mockGateway, mockTracer)
+	useCase := NewWeatherUseCase(mockGateway, mockTracer, slog.New(slog.DiscardHandler))
 
 	// Act
-	result, err := useCase.GetCurrentWeather(context.Background(), "04446-160")
+	result, err := useCase.GetCurrentWeather(context.Background(), "04446-160", entity.Include{})
 
 	// Assert
 	if err == nil {
@@ -191,11 +242,11 @@ func TestGetCurrentWeather_GatewayError(t *testing.T) {
 	if result != nil {
 		t.Errorf("Expected nil result, got %v", result)
 	}
-	if err.Code != 404 {
-		t.Errorf("Expected error code 404, got %d", err.Code)
+	if err.Code != 502 {
+		t.Errorf("Expected error code 502, got %d", err.Code)
 	}
-	if err.MSG != "Can not find zipcode" {
-		t.Errorf("Expected error message 'Can not find zipcode', got '%s'", err.MSG)
+	if err.MSG != "Upstream service unavailable" {
+		t.Errorf("Expected error message 'Upstream service unavailable', got '%s'", err.MSG)
 	}
 }
 
@@ -210,17 +261,17 @@ func TestGetCurrentWeather_VariousValidCEPs(t *testing.T) {
 
 	for input, expectedFormatted := range validCEPs {
 		mockGateway := &MockWeatherGateway{
-			mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, error) {
-				if cep != expectedFormatted {
-					t.Errorf("Expected formatted CEP '%s', got '%s'", expectedFormatted, cep)
+			mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+				if query.CEP != expectedFormatted {
+					t.Errorf("Expected formatted CEP '%s', got '%s'", expectedFormatted, query.CEP)
 				}
 				return entity.NewWeather("Test City", 15.0), nil
 			},
 		}
 		mockTracer := noop.NewTracerProvider().Tracer("test")
-		useCase := NewWeatherUseCase(mockGateway, mockTracer)
+		useCase := NewWeatherUseCase(mockGateway, mockTracer, slog.New(slog.DiscardHandler))
 
-		result, err := useCase.GetCurrentWeather(context.Background(), input)
+		result, err := useCase.GetCurrentWeather(context.Background(), input, entity.Include{})
 
 		if err != nil {
 			t.Errorf("CEP '%s': Expected no error, got %v", input, err)
@@ -234,15 +285,15 @@ func TestGetCurrentWeather_VariousValidCEPs(t *testing.T) {
 func TestGetCurrentWeather_NegativeTemperature(t *testing.T) {
 	// Arrange
 	mockGateway := &MockWeatherGateway{
-		mockGetCurrentWeather: func(ctx context.Context, cep string) (*entity.Weather, error) {
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
 			return entity.NewWeather("Polo Norte", -40.0), nil
 		},
 	}
 	mockTracer := noop.NewTracerProvider().Tracer("test")
-	useCase := NewWeatherUseCase(mockGateway, mockTracer)
+	useCase := NewWeatherUseCase(mockGateway, mockTracer, slog.New(slog.DiscardHandler))
 
 	// Act
-	result, err := useCase.GetCurrentWeather(context.Background(), "00000-000")
+	result, err := useCase.GetCurrentWeather(context.Background(), "00000-000", entity.Include{})
 
 	// Assert
 	if err != nil {
@@ -261,3 +312,155 @@ func TestGetCurrentWeather_NegativeTemperature(t *testing.T) {
 		t.Errorf("Expected temp_k 233.0, got %.1f", result.Temp_k)
 	}
 }
+
+// Todos os spans internos devem ser encerrados, inclusive nos caminhos de erro.
+func TestGetCurrentWeather_EndsSpansOnErrors(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			return nil, errors.New("timeout connecting to weather API")
+		},
+	}
+
+	for _, cep := range []string{"1234", "04446-160"} {
+		recorder := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+		useCase := NewWeatherUseCase(mockGateway, tracer, slog.New(slog.DiscardHandler))
+
+		useCase.GetCurrentWeather(context.Background(), cep, entity.Include{})
+
+		started, ended := len(recorder.Started()), len(recorder.Ended())
+		if started == 0 || started != ended {
+			t.Errorf("CEP %s: expected every started span to end, got %d started and %d ended", cep, started, ended)
+		}
+		last := recorder.Ended()[ended-1]
+		if last.Status().Code != codes.Error {
+			t.Errorf("CEP %s: expected %s to have error status, got %v", cep, last.Name(), last.Status())
+		}
+	}
+}
+
+func TestGetCurrentWeatherByCity_Success(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			if query.City != "Campinas" || query.UF != "SP" || query.CEP != "" {
+				t.Errorf("Unexpected query: %+v", query)
+			}
+			return entity.NewWeather("Campinas", 22), nil
+		},
+	}
+	useCase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	result, err := useCase.GetCurrentWeatherByCity(context.Background(), "Campinas", "sp", entity.Include{})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.City != "Campinas" || result.Temp_c != 22 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+// Com todos os provedores de CEP fora do ar, a resposta é 502, e não
+// "Can not find zipcode"
+func TestGetCurrentWeather_AllCEPProvidersFail(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			return nil, fmt.Errorf("all CEP providers failed: %w", errors.Join(
+				errors.New("viacep: context deadline exceeded"),
+				errors.New("brasilapi: failed to get location data: status code 503"),
+			))
+		},
+	}
+	useCase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	_, err := useCase.GetCurrentWeather(context.Background(), "01001-000", entity.Include{})
+
+	if err == nil || err.Code != 502 || err.MSG != "Upstream service unavailable" {
+		t.Errorf("Expected upstream unavailable error, got %v", err)
+	}
+}
+
+func TestGetCurrentWeatherByCity_Errors(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			return nil, fmt.Errorf("%w: status code 400", entity.ErrLocationNotFound)
+		},
+	}
+	useCase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	_, err := useCase.GetCurrentWeatherByCity(context.Background(), "Campinas", "XX", entity.Include{})
+	if err == nil || err.Code != 422 || err.MSG != "Invalid city or state" {
+		t.Errorf("Expected invalid city error, got %v", err)
+	}
+
+	_, err = useCase.GetCurrentWeatherByCity(context.Background(), "Nowhere", "SP", entity.Include{})
+	if err == nil || err.Code != 404 || err.MSG != "Can not find location" {
+		t.Errorf("Expected location not found error, got %v", err)
+	}
+}
+
+func TestGetCurrentWeatherByCoordinates(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			if query.Coordinates == nil || query.Coordinates.Lat != -22.9 || query.Coordinates.Lon != -47.06 {
+				t.Errorf("Unexpected query: %+v", query)
+			}
+			return entity.NewWeather("Campinas", 22), nil
+		},
+	}
+	useCase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	result, err := useCase.GetCurrentWeatherByCoordinates(context.Background(), -22.9, -47.06, entity.Include{})
+	if err != nil || result.City != "Campinas" {
+		t.Errorf("Expected Campinas, got %+v (%v)", result, err)
+	}
+
+	_, err = useCase.GetCurrentWeatherByCoordinates(context.Background(), 91, 0, entity.Include{})
+	if err == nil || err.Code != 422 || err.MSG != "Invalid coordinates" {
+		t.Errorf("Expected invalid coordinates error, got %v", err)
+	}
+}
+
+// As consultas por cidade e por coordenadas seguem o mesmo pipeline de spans
+// da consulta por CEP, trocando só o span de validação.
+func TestGetCurrentWeatherByQuery_Spans(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			return entity.NewWeather("Campinas", 22), nil
+		},
+	}
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	useCase := NewWeatherUseCase(mockGateway, tracer, slog.New(slog.DiscardHandler))
+
+	useCase.GetCurrentWeatherByCity(context.Background(), "Campinas", "SP", entity.Include{})
+	useCase.GetCurrentWeatherByCoordinates(context.Background(), -22.9, -47.06, entity.Include{})
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		names = append(names, span.Name())
+	}
+	expected := []string{"validate_city", "fetch_weather_data", "validate_coordinates", "fetch_weather_data"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected spans %v, got %v", expected, names)
+	}
+}
+
+func TestGetCurrentWeather_LocationMismatch(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			return nil, fmt.Errorf("%w: expected PI", entity.ErrLocationMismatch)
+		},
+	}
+	useCase := NewWeatherUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"), slog.New(slog.DiscardHandler))
+
+	_, err := useCase.GetCurrentWeather(context.Background(), "64900-000", entity.Include{})
+	if err == nil || err.Code != 404 || err.MSG != "Location mismatch" {
+		t.Errorf("Expected location mismatch error for a CEP, got %v", err)
+	}
+
+	_, err = useCase.GetCurrentWeatherByCity(context.Background(), "Bom Jesus", "PI", entity.Include{})
+	if err == nil || err.Code != 404 || err.MSG != "Location mismatch" {
+		t.Errorf("Expected location mismatch error for a city, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	domainGateway "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/gateway"
+	internalerror "github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/infra/internal_error"
+	"github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SearchAddressUseCase busca CEPs a partir de um endereço parcial e, quando
+// pedido, consulta o clima de cada candidato pelo BatchWeatherUseCase.
+type SearchAddressUseCase struct {
+	weatherGateway domainGateway.WeatherGateway
+	batch          *BatchWeatherUseCase
+	tracer         trace.Tracer
+	logger         *slog.Logger
+}
+
+func NewSearchAddressUseCase(gateway domainGateway.WeatherGateway, batch *BatchWeatherUseCase, tracer trace.Tracer, logger *slog.Logger) *SearchAddressUseCase {
+	return &SearchAddressUseCase{weatherGateway: gateway, batch: batch, tracer: tracer, logger: logger}
+}
+
+// SearchAddress devolve os candidatos na ordem do ViaCEP. Com withWeather,
+// cada candidato traz o clima atual ou o motivo da falha; uma busca sem
+// resultados não é um erro.
+func (s *SearchAddressUseCase) SearchAddress(ctx context.Context, uf, city, street string, withWeather bool, include entity.Include) ([]entity.AddressCandidate, *internalerror.InternalError) {
+	_, spanValidateAddress := s.tracer.Start(ctx, "validate_address_search")
+	search, err := entity.NewAddressSearch(uf, city, street)
+	telemetry.EndSpan(spanValidateAddress, err)
+	if err != nil {
+		return nil, internalerror.AddressSearchInvalidError()
+	}
+
+	fetchCtx, spanFetchAddresses := s.tracer.Start(ctx, "fetch_addresses")
+	addresses, err := s.weatherGateway.SearchAddress(fetchCtx, search)
+	telemetry.EndSpan(spanFetchAddresses, err)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to search address", slog.Any("error", err))
+		return nil, gatewayError(err, internalerror.AddressNotFoundError)
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("address.candidates", len(addresses)))
+
+	candidates := make([]entity.AddressCandidate, len(addresses))
+	for i, address := range addresses {
+		candidates[i].Address = address
+	}
+	if !withWeather || len(addresses) == 0 {
+		return candidates, nil
+	}
+
+	if batchErr := s.fetchWeather(ctx, candidates, include); batchErr != nil {
+		return nil, batchErr
+	}
+	return candidates, nil
+}
+
+// fetchWeather consulta o clima dos candidatos em paralelo; cada CEP vira um
+// span batch_item abaixo de fetch_candidates_weather.
+func (s *SearchAddressUseCase) fetchWeather(ctx context.Context, candidates []entity.AddressCandidate, include entity.Include) *internalerror.InternalError {
+	ctx, span := s.tracer.Start(ctx, "fetch_candidates_weather")
+
+	ceps := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		ceps[i] = candidate.Address.CEP
+	}
+
+	type result struct {
+		weather *entity.Weather
+		err     *internalerror.InternalError
+	}
+	results := make(map[string]result, len(ceps))
+	batchErr := s.batch.GetCurrentWeather(ctx, ceps, include, func(index int, cep string, weather *entity.Weather, err *internalerror.InternalError) {
+		results[cep] = result{weather: weather, err: err}
+	})
+	if batchErr != nil {
+		telemetry.EndSpan(span, batchErr)
+		return batchErr
+	}
+	span.End()
+
+	// Vários candidatos podem ter o mesmo CEP; o clima é consultado uma vez
+	for i := range candidates {
+		result := results[candidates[i].Address.CEP]
+		candidates[i].Weather = result.weather
+		if result.err != nil {
+			candidates[i].WeatherError = result.err.MSG
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/adalbertofjr/lab-1-go-weather-cloud-run/internal/domain/entity"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func newTestSearchAddressUseCase(gateway *MockWeatherGateway, tracer trace.Tracer) *SearchAddressUseCase {
+	logger := slog.New(slog.DiscardHandler)
+	batch := NewBatchWeatherUseCase(NewWeatherUseCase(gateway, tracer, logger), tracer, logger, BatchConfig{})
+	return NewSearchAddressUseCase(gateway, batch, tracer, logger)
+}
+
+func TestSearchAddress_WithoutWeather(t *testing.T) {
+	var gotSearch entity.AddressSearch
+	mockGateway := &MockWeatherGateway{
+		mockSearchAddress: func(ctx context.Context, search entity.AddressSearch) ([]entity.Address, error) {
+			gotSearch = search
+			return []entity.Address{{CEP: "01310100", Street: "Avenida Paulista"}}, nil
+		},
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			t.Error("Weather should not be fetched without weather=true")
+			return nil, nil
+		},
+	}
+	useCase := newTestSearchAddressUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"))
+
+	candidates, err := useCase.SearchAddress(context.Background(), "sp", " São Paulo ", "Paulista", false, entity.Include{})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if gotSearch != (entity.AddressSearch{UF: "SP", City: "São Paulo", Street: "Paulista"}) {
+		t.Errorf("Unexpected search %+v", gotSearch)
+	}
+	if len(candidates) != 1 || candidates[0].Address.CEP != "01310100" || candidates[0].Weather != nil {
+		t.Errorf("Unexpected candidates %+v", candidates)
+	}
+}
+
+func TestSearchAddress_WithWeather(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	mockGateway := &MockWeatherGateway{
+		mockSearchAddress: func(ctx context.Context, search entity.AddressSearch) ([]entity.Address, error) {
+			return []entity.Address{{CEP: "01310100"}, {CEP: "99999999"}, {CEP: "01310100"}}, nil
+		},
+		mockGetCurrentWeather: func(ctx context.Context, query entity.WeatherQuery, include entity.Include) (*entity.Weather, error) {
+			mu.Lock()
+			calls[query.CEP]++
+			mu.Unlock()
+			if query.CEP == "99999999" {
+				return nil, entity.ErrCEPNotFound
+			}
+			return entity.NewWeather("São Paulo", 22), nil
+		},
+	}
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	useCase := newTestSearchAddressUseCase(mockGateway, tracer)
+
+	candidates, err := useCase.SearchAddress(context.Background(), "SP", "São Paulo", "Paulista", true, entity.Include{})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(candidates) != 3 {
+		t.Fatalf("Expected 3 candidates, got %d", len(candidates))
+	}
+	if candidates[0].Weather == nil || candidates[2].Weather == nil || candidates[0].Weather.City != "São Paulo" {
+		t.Errorf("Expected the weather for both 01310100 candidates, got %+v", candidates)
+	}
+	if candidates[1].Weather != nil || candidates[1].WeatherError != "Can not find zipcode" {
+		t.Errorf("Expected a weather error for 99999999, got %+v", candidates[1])
+	}
+	if calls["01310100"] != 1 {
+		t.Errorf("Expected duplicated CEP to be fetched once, got %d", calls["01310100"])
+	}
+
+	spans := map[string]int{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()]++
+	}
+	if spans["fetch_addresses"] != 1 || spans["fetch_candidates_weather"] != 1 || spans["batch_item"] != 2 {
+		t.Errorf("Unexpected spans %v", spans)
+	}
+	if started, ended := len(recorder.Started()), len(recorder.Ended()); started != ended {
+		t.Errorf("Expected every span to end, got %d started and %d ended", started, ended)
+	}
+}
+
+func TestSearchAddress_Invalid(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockSearchAddress: func(ctx context.Context, search entity.AddressSearch) ([]entity.Address, error) {
+			t.Error("Gateway should not be called with an invalid search")
+			return nil, nil
+		},
+	}
+	useCase := newTestSearchAddressUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"))
+
+	_, err := useCase.SearchAddress(context.Background(), "SP", "São Paulo", "Pa", false, entity.Include{})
+
+	if err == nil || err.Code != 422 || err.MSG != "Invalid address search" {
+		t.Errorf("Expected invalid address search error, got %v", err)
+	}
+}
+
+func TestSearchAddress_GatewayError(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockSearchAddress: func(ctx context.Context, search entity.AddressSearch) ([]entity.Address, error) {
+			return nil, fmt.Errorf("%w: status code 400", entity.ErrLocationNotFound)
+		},
+	}
+	useCase := newTestSearchAddressUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"))
+
+	_, err := useCase.SearchAddress(context.Background(), "SP", "São Paulo", "Paulista", false, entity.Include{})
+
+	if err == nil || err.Code != 404 || err.MSG != "Can not find address" {
+		t.Errorf("Expected address not found error, got %v", err)
+	}
+}
+
+func TestSearchAddress_ViaCEPUnavailable(t *testing.T) {
+	mockGateway := &MockWeatherGateway{
+		mockSearchAddress: func(ctx context.Context, search entity.AddressSearch) ([]entity.Address, error) {
+			return nil, errors.New("failed to search address: status code 503")
+		},
+	}
+	useCase := newTestSearchAddressUseCase(mockGateway, noop.NewTracerProvider().Tracer("test"))
+
+	_, err := useCase.SearchAddress(context.Background(), "SP", "São Paulo", "Paulista", false, entity.Include{})
+
+	if err == nil || err.Code != 502 {
+		t.Errorf("Expected upstream unavailable error, got %v", err)
+	}
+}
@@ -0,0 +1,363 @@
+package telemetry
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultDebugTracesMax      = 100
+	defaultDebugTracesMaxSpans = 256
+	debugTracesRoute           = "/debug/traces"
+)
+
+// DebugTraceProcessorFromEnv interpreta DEBUG_TRACES_ENABLED e
+// DEBUG_TRACES_MAX. Devolve nil quando o visualizador está desligado.
+func DebugTraceProcessorFromEnv(enabled, maxTraces string) (*DebugTraceProcessor, error) {
+	if on, _ := strconv.ParseBool(enabled); !on {
+		return nil, nil
+	}
+	if maxTraces == "" {
+		return NewDebugTraceProcessor(defaultDebugTracesMax), nil
+	}
+	parsed, err := strconv.Atoi(maxTraces)
+	if err != nil || parsed <= 0 {
+		return NewDebugTraceProcessor(defaultDebugTracesMax), fmt.Errorf("invalid DEBUG_TRACES_MAX %q: must be a positive integer", maxTraces)
+	}
+	return NewDebugTraceProcessor(parsed), nil
+}
+
+type debugTrace struct {
+	id       trace.TraceID
+	spans    []sdktrace.ReadOnlySpan
+	hasError bool
+	recent   *list.Element
+	errored  *list.Element
+}
+
+// DebugTraceProcessor guarda em memória os últimos traces e, separadamente,
+// os últimos traces com erro, para inspeção em /debug/traces sem depender do
+// collector e do Zipkin.
+type DebugTraceProcessor struct {
+	max int
+
+	mu      sync.Mutex
+	traces  map[trace.TraceID]*debugTrace
+	recent  *list.List
+	errored *list.List
+}
+
+// NewDebugTraceProcessor mantém até max traces recentes e max traces com erro.
+func NewDebugTraceProcessor(max int) *DebugTraceProcessor {
+	if max <= 0 {
+		max = defaultDebugTracesMax
+	}
+	return &DebugTraceProcessor{
+		max:     max,
+		traces:  make(map[trace.TraceID]*debugTrace),
+		recent:  list.New(),
+		errored: list.New(),
+	}
+}
+
+func (p *DebugTraceProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (p *DebugTraceProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	// As próprias consultas ao visualizador não são guardadas
+	for _, attr := range s.Attributes() {
+		if attr.Key == "http.route" && attr.Value.AsString() == debugTracesRoute {
+			return
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := s.SpanContext().TraceID()
+	t, ok := p.traces[id]
+	if !ok {
+		t = &debugTrace{id: id}
+		p.traces[id] = t
+	}
+	if len(t.spans) < defaultDebugTracesMaxSpans {
+		t.spans = append(t.spans, s)
+	}
+
+	if t.recent != nil {
+		p.recent.MoveToBack(t.recent)
+	} else {
+		t.recent = p.recent.PushBack(t)
+		if p.recent.Len() > p.max {
+			oldest := p.recent.Remove(p.recent.Front()).(*debugTrace)
+			oldest.recent = nil
+			p.forget(oldest)
+		}
+	}
+
+	if s.Status().Code == codes.Error && !t.hasError {
+		t.hasError = true
+		t.errored = p.errored.PushBack(t)
+		if p.errored.Len() > p.max {
+			oldest := p.errored.Remove(p.errored.Front()).(*debugTrace)
+			oldest.errored = nil
+			p.forget(oldest)
+		}
+	}
+}
+
+// forget remove o trace quando ele não está mais em nenhuma das listas.
+func (p *DebugTraceProcessor) forget(t *debugTrace) {
+	if t.recent == nil && t.errored == nil {
+		delete(p.traces, t.id)
+	}
+}
+
+func (p *DebugTraceProcessor) ForceFlush(context.Context) error { return nil }
+
+func (p *DebugTraceProcessor) Shutdown(context.Context) error { return nil }
+
+// DebugTraceSummary resume um trace na listagem de /debug/traces.
+type DebugTraceSummary struct {
+	TraceID  string
+	Root     string
+	Service  string
+	Spans    int
+	Start    time.Time
+	Duration time.Duration
+	Error    bool
+}
+
+// Recent devolve os traces guardados, do mais recente para o mais antigo.
+func (p *DebugTraceProcessor) Recent() []DebugTraceSummary {
+	return p.summaries(p.recent)
+}
+
+// Errors devolve os traces com erro, do mais recente para o mais antigo.
+func (p *DebugTraceProcessor) Errors() []DebugTraceSummary {
+	return p.summaries(p.errored)
+}
+
+func (p *DebugTraceProcessor) summaries(l *list.List) []DebugTraceSummary {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	summaries := make([]DebugTraceSummary, 0, l.Len())
+	for e := l.Back(); e != nil; e = e.Prev() {
+		summaries = append(summaries, summarize(e.Value.(*debugTrace)))
+	}
+	return summaries
+}
+
+// Spans devolve uma cópia dos spans guardados do trace.
+func (p *DebugTraceProcessor) Spans(id trace.TraceID) ([]sdktrace.ReadOnlySpan, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, ok := p.traces[id]
+	if !ok {
+		return nil, false
+	}
+	return slices.Clone(t.spans), true
+}
+
+func summarize(t *debugTrace) DebugTraceSummary {
+	summary := DebugTraceSummary{TraceID: t.id.String(), Spans: len(t.spans), Error: t.hasError}
+
+	var end time.Time
+	for _, span := range t.spans {
+		if summary.Start.IsZero() || span.StartTime().Before(summary.Start) {
+			summary.Start = span.StartTime()
+		}
+		if span.EndTime().After(end) {
+			end = span.EndTime()
+		}
+	}
+	summary.Duration = end.Sub(summary.Start)
+
+	if roots := spanTree(t.spans); len(roots) > 0 {
+		summary.Root = roots[0].Span.Name()
+		summary.Service = serviceName(roots[0].Span)
+	}
+	return summary
+}
+
+func serviceName(span sdktrace.ReadOnlySpan) string {
+	if res := span.Resource(); res != nil {
+		if value, ok := res.Set().Value(semconv.ServiceNameKey); ok {
+			return value.AsString()
+		}
+	}
+	return ""
+}
+
+// debugSpanNode é uma linha da árvore de spans, já com a profundidade.
+type debugSpanNode struct {
+	Span     sdktrace.ReadOnlySpan
+	Depth    int
+	Offset   time.Duration
+	Duration time.Duration
+}
+
+// spanTree ordena os spans em pré-ordem, filhos por horário de início. Spans
+// cujo pai não está guardado (ex.: o pai está no outro serviço) são raízes.
+func spanTree(spans []sdktrace.ReadOnlySpan) []debugSpanNode {
+	present := make(map[trace.SpanID]bool, len(spans))
+	for _, span := range spans {
+		present[span.SpanContext().SpanID()] = true
+	}
+
+	children := make(map[trace.SpanID][]sdktrace.ReadOnlySpan)
+	var roots []sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if parent := span.Parent(); parent.IsValid() && present[parent.SpanID()] {
+			children[parent.SpanID()] = append(children[parent.SpanID()], span)
+		} else {
+			roots = append(roots, span)
+		}
+	}
+
+	byStart := func(a, b sdktrace.ReadOnlySpan) int { return a.StartTime().Compare(b.StartTime()) }
+	slices.SortFunc(roots, byStart)
+
+	var start time.Time
+	if len(roots) > 0 {
+		start = roots[0].StartTime()
+	}
+
+	nodes := make([]debugSpanNode, 0, len(spans))
+	var walk func(span sdktrace.ReadOnlySpan, depth int)
+	walk = func(span sdktrace.ReadOnlySpan, depth int) {
+		nodes = append(nodes, debugSpanNode{
+			Span:     span,
+			Depth:    depth,
+			Offset:   span.StartTime().Sub(start),
+			Duration: span.EndTime().Sub(span.StartTime()),
+		})
+		next := children[span.SpanContext().SpanID()]
+		slices.SortFunc(next, byStart)
+		for _, child := range next {
+			walk(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		walk(root, 0)
+	}
+	return nodes
+}
+
+var debugTracesTemplate = template.Must(template.New("traces").Funcs(template.FuncMap{
+	"indent": func(depth int) string { return strconv.Itoa(depth*24) + "px" },
+	"ms": func(d time.Duration) string {
+		return strconv.FormatFloat(float64(d.Microseconds())/1000, 'f', 2, 64) + " ms"
+	},
+	"value":  func(v attribute.Value) string { return v.Emit() },
+	"failed": func(status sdktrace.Status) bool { return status.Code == codes.Error },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.error { color: #b00020; }
+.span { border-left: 2px solid #888; margin: 4px 0; padding: 4px 8px; }
+.span.error { border-left-color: #b00020; }
+.attrs { font-size: 0.85em; color: #444; }
+</style>
+</head>
+<body>
+{{- if .Tree}}
+<p><a href="?">&larr; all traces</a></p>
+<h1>Trace {{.TraceID}}</h1>
+{{- range .Tree}}
+<div class="span{{if failed .Span.Status}} error{{end}}" style="margin-left: {{indent .Depth}}">
+<strong>{{.Span.Name}}</strong> {{.Span.SpanKind}} &middot; +{{ms .Offset}} &middot; {{ms .Duration}}
+{{- if failed .Span.Status}} &middot; <span class="error">error: {{.Span.Status.Description}}</span>{{end}}
+<div class="attrs">span_id={{.Span.SpanContext.SpanID}}{{range .Span.Attributes}} &middot; {{.Key}}={{value .Value}}{{end}}</div>
+{{- range .Span.Events}}
+<div class="attrs">event <strong>{{.Name}}</strong> at {{.Time.Format "15:04:05.000"}}{{range .Attributes}} &middot; {{.Key}}={{value .Value}}{{end}}</div>
+{{- end}}
+</div>
+{{- end}}
+{{- else}}
+<h1>{{.Title}}</h1>
+{{- range .Lists}}
+<h2>{{.Name}} ({{len .Traces}})</h2>
+<table>
+<tr><th>Trace ID</th><th>Root span</th><th>Service</th><th>Spans</th><th>Start</th><th>Duration</th><th>Status</th></tr>
+{{- range .Traces}}
+<tr>
+<td><a href="?trace_id={{.TraceID}}">{{.TraceID}}</a></td>
+<td>{{.Root}}</td>
+<td>{{.Service}}</td>
+<td>{{.Spans}}</td>
+<td>{{.Start.Format "15:04:05.000"}}</td>
+<td>{{ms .Duration}}</td>
+<td>{{if .Error}}<span class="error">error</span>{{else}}ok{{end}}</td>
+</tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+type debugTraceList struct {
+	Name   string
+	Traces []DebugTraceSummary
+}
+
+// Handler serve /debug/traces: sem parâmetros lista os traces com erro e os
+// recentes; com ?trace_id= mostra a árvore de spans do trace com atributos e
+// eventos.
+func (p *DebugTraceProcessor) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := struct {
+			Title   string
+			TraceID string
+			Tree    []debugSpanNode
+			Lists   []debugTraceList
+		}{Title: "Recent traces"}
+
+		if raw := r.URL.Query().Get("trace_id"); raw != "" {
+			id, err := trace.TraceIDFromHex(raw)
+			if err != nil {
+				http.Error(w, "invalid trace_id", http.StatusBadRequest)
+				return
+			}
+			spans, ok := p.Spans(id)
+			if !ok {
+				http.Error(w, "trace not found", http.StatusNotFound)
+				return
+			}
+			data.Title = "Trace " + raw
+			data.TraceID = raw
+			data.Tree = spanTree(spans)
+		} else {
+			data.Lists = []debugTraceList{
+				{Name: "Errors", Traces: p.Errors()},
+				{Name: "Recent", Traces: p.Recent()},
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		debugTracesTemplate.Execute(w, data)
+	})
+}
@@ -0,0 +1,137 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newDebugTracer(p *DebugTraceProcessor) trace.Tracer {
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(p)).Tracer("test")
+}
+
+func TestDebugTraceProcessorFromEnv(t *testing.T) {
+	p, err := DebugTraceProcessorFromEnv("", "")
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	p, err = DebugTraceProcessorFromEnv("true", "10")
+	require.NoError(t, err)
+	assert.Equal(t, 10, p.max)
+
+	p, err = DebugTraceProcessorFromEnv("true", "-1")
+	assert.Error(t, err)
+	assert.Equal(t, defaultDebugTracesMax, p.max)
+}
+
+func TestDebugTraceProcessor_KeepsLastTracesAndErrors(t *testing.T) {
+	p := NewDebugTraceProcessor(2)
+	tracer := newDebugTracer(p)
+
+	_, failed := tracer.Start(context.Background(), "GET /")
+	EndSpan(failed, errors.New("service B unavailable"))
+	failedID := failed.SpanContext().TraceID()
+
+	for i := 0; i < 3; i++ {
+		_, span := tracer.Start(context.Background(), "GET /")
+		span.End()
+	}
+
+	recent := p.Recent()
+	require.Len(t, recent, 2)
+	for _, summary := range recent {
+		assert.False(t, summary.Error)
+	}
+
+	// O trace com erro saiu dos recentes, mas continua na lista de erros
+	errs := p.Errors()
+	require.Len(t, errs, 1)
+	assert.Equal(t, failedID.String(), errs[0].TraceID)
+	_, ok := p.Spans(failedID)
+	assert.True(t, ok)
+	assert.Len(t, p.traces, 3)
+}
+
+func TestDebugTraceProcessor_IgnoresViewerRequests(t *testing.T) {
+	p := NewDebugTraceProcessor(10)
+	_, span := newDebugTracer(p).Start(context.Background(), "GET /debug/traces",
+		trace.WithAttributes(attribute.String("http.route", debugTracesRoute)))
+	span.End()
+
+	assert.Empty(t, p.Recent())
+}
+
+func TestSpanTree(t *testing.T) {
+	p := NewDebugTraceProcessor(10)
+	tracer := newDebugTracer(p)
+
+	ctx, root := tracer.Start(context.Background(), "GET /")
+	childCtx, child := tracer.Start(ctx, "validate_cep")
+	_, grandchild := tracer.Start(childCtx, "call_service_b")
+	grandchild.End()
+	child.End()
+	_, sibling := tracer.Start(ctx, "encode_response")
+	sibling.End()
+	root.End()
+
+	spans, ok := p.Spans(root.SpanContext().TraceID())
+	require.True(t, ok)
+
+	tree := spanTree(spans)
+	var names []string
+	var depths []int
+	for _, node := range tree {
+		names = append(names, node.Span.Name())
+		depths = append(depths, node.Depth)
+	}
+	assert.Equal(t, []string{"GET /", "validate_cep", "call_service_b", "encode_response"}, names)
+	assert.Equal(t, []int{0, 1, 2, 1}, depths)
+
+	summary := p.Recent()[0]
+	assert.Equal(t, "GET /", summary.Root)
+	assert.Equal(t, 4, summary.Spans)
+}
+
+func TestDebugTraceProcessor_Handler(t *testing.T) {
+	p := NewDebugTraceProcessor(10)
+	tracer := newDebugTracer(p)
+
+	ctx, root := tracer.Start(context.Background(), "POST /")
+	_, child := tracer.Start(ctx, "call_service_b", trace.WithAttributes(attribute.String("cep", "01001-***")))
+	child.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", 2)))
+	EndSpan(child, errors.New("service B unavailable"))
+	root.End()
+	id := root.SpanContext().TraceID().String()
+
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/traces", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "?trace_id="+id)
+	assert.Contains(t, rec.Body.String(), "POST /")
+
+	rec = httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/traces?trace_id="+id, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "call_service_b")
+	assert.Contains(t, body, "cep=01001-***")
+	assert.Contains(t, body, "retry")
+	assert.Contains(t, body, "attempt=2")
+	assert.Contains(t, body, "service B unavailable")
+
+	rec = httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/traces?trace_id=0af7651916cd43dd8448eb211c80319c", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/traces?trace_id=xyz", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,411 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	ProtocolGRPC         = "grpc"
+	ProtocolHTTPProtobuf = "http/protobuf"
+)
+
+// ExporterConfig reúne as variáveis OTEL_* que definem para onde os sinais
+// são exportados.
+type ExporterConfig struct {
+	// TracesExporter é a lista separada por vírgulas de OTEL_TRACES_EXPORTER:
+	// otlp, zipkin, console (ou stdout), file e none. Padrão: otlp.
+	TracesExporter string
+	// MetricsExporter é a lista separada por vírgulas de
+	// OTEL_METRICS_EXPORTER: otlp, prometheus e none. Padrão: otlp. O
+	// endpoint /metrics (prometheus) fica sempre ativo; none só desliga o
+	// envio ao collector.
+	MetricsExporter string
+	// Protocol é OTEL_EXPORTER_OTLP_PROTOCOL: grpc (padrão) ou http/protobuf.
+	Protocol string
+	// Endpoint é OTEL_EXPORTER_OTLP_ENDPOINT, com ou sem esquema.
+	Endpoint string
+	// Headers vem de OTEL_EXPORTER_OTLP_HEADERS (ex.: chaves de autenticação
+	// exigidas por um collector gerenciado).
+	Headers map[string]string
+	// Timeout é OTEL_EXPORTER_OTLP_TIMEOUT. Zero usa o padrão do SDK (10s).
+	Timeout time.Duration
+	// TLS é montado de OTEL_EXPORTER_OTLP_CERTIFICATE,
+	// OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE e OTEL_EXPORTER_OTLP_CLIENT_KEY.
+	// Quando definido, o endpoint sem esquema passa a usar TLS; um esquema
+	// http:// continua sem TLS.
+	TLS *tls.Config
+	// ZipkinEndpoint é OTEL_EXPORTER_ZIPKIN_ENDPOINT.
+	ZipkinEndpoint string
+	// FilePath é o arquivo JSON lines usado pelo exporter file.
+	FilePath string
+	// Spool, quando definido, grava em disco os lotes que os exporters otlp e
+	// zipkin não conseguirem enviar, um subdiretório por exporter.
+	Spool *SpoolConfig
+}
+
+func (c ExporterConfig) protocol() string {
+	if c.Protocol == "" {
+		return ProtocolGRPC
+	}
+	return c.Protocol
+}
+
+// OTLPMetrics informa se OTEL_METRICS_EXPORTER inclui o envio das métricas
+// ao collector.
+func (c ExporterConfig) OTLPMetrics() bool {
+	names, _ := metricsExporters(c.MetricsExporter)
+	return names["otlp"]
+}
+
+// metricsExporters interpreta OTEL_METRICS_EXPORTER. Valores desconhecidos
+// são reportados no erro e ignorados.
+func metricsExporters(list string) (map[string]bool, error) {
+	if strings.TrimSpace(list) == "" {
+		list = "otlp"
+	}
+
+	names := make(map[string]bool)
+	var errs []error
+	for _, name := range strings.Split(list, ",") {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "none", "":
+			continue
+		case "otlp", "prometheus":
+			names[name] = true
+		default:
+			errs = append(errs, fmt.Errorf("unsupported OTEL_METRICS_EXPORTER %q", name))
+		}
+	}
+	return names, errors.Join(errs...)
+}
+
+// otlpEndpoint devolve host:port e se a conexão deve ser sem TLS. O esquema
+// decide: https:// usa TLS e http:// não usa, mesmo com certificados
+// configurados. Endpoints sem esquema (ex.: otel-collector:4317) só usam TLS
+// quando há certificados.
+func (c ExporterConfig) otlpEndpoint() (string, bool) {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		if c.protocol() == ProtocolHTTPProtobuf {
+			return "localhost:4318", true
+		}
+		return "localhost:4317", true
+	}
+	if !strings.Contains(endpoint, "://") {
+		return endpoint, c.TLS == nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint, c.TLS == nil
+	}
+	return u.Host, u.Scheme != "https"
+}
+
+// ExporterConfigFromEnv lê as variáveis OTEL_EXPORTER_* e TELEMETRY_SPOOL_*
+// usando getenv. Em caso de erro, devolve a configuração com os valores
+// inválidos ignorados.
+func ExporterConfigFromEnv(getenv func(string) string) (ExporterConfig, error) {
+	cfg := ExporterConfig{
+		TracesExporter:  getenv("OTEL_TRACES_EXPORTER"),
+		MetricsExporter: getenv("OTEL_METRICS_EXPORTER"),
+		Protocol:        getenv("OTEL_EXPORTER_OTLP_PROTOCOL"),
+		Endpoint:        getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		ZipkinEndpoint:  getenv("OTEL_EXPORTER_ZIPKIN_ENDPOINT"),
+		FilePath:        getenv("OTEL_EXPORTER_FILE_PATH"),
+	}
+
+	var errs []error
+	if _, err := metricsExporters(cfg.MetricsExporter); err != nil {
+		errs = append(errs, err)
+	}
+	if headers := getenv("OTEL_EXPORTER_OTLP_HEADERS"); headers != "" {
+		parsed, err := parseKeyValues(headers)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS: %w", err))
+		}
+		cfg.Headers = parsed
+	}
+	if timeout := getenv("OTEL_EXPORTER_OTLP_TIMEOUT"); timeout != "" {
+		millis, err := strconv.Atoi(timeout)
+		if err != nil || millis <= 0 {
+			errs = append(errs, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_TIMEOUT %q: must be a positive number of milliseconds", timeout))
+		} else {
+			cfg.Timeout = time.Duration(millis) * time.Millisecond
+		}
+	}
+
+	tlsConfig, err := otlpTLSConfig(getenv("OTEL_EXPORTER_OTLP_CERTIFICATE"), getenv("OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE"), getenv("OTEL_EXPORTER_OTLP_CLIENT_KEY"))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.TLS = tlsConfig
+
+	spool, err := SpoolConfigFromEnv(getenv("TELEMETRY_SPOOL_DIR"), getenv("TELEMETRY_SPOOL_MAX_MB"))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.Spool = spool
+
+	return cfg, errors.Join(errs...)
+}
+
+// parseKeyValues interpreta listas no formato chave=valor,chave2=valor2 de
+// OTEL_EXPORTER_OTLP_HEADERS e OTEL_RESOURCE_ATTRIBUTES, com valores
+// codificados como URL. Itens inválidos são ignorados e reportados no erro.
+func parseKeyValues(list string) (map[string]string, error) {
+	values := make(map[string]string)
+	var invalid []string
+	for _, item := range strings.Split(list, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		key, value, found := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			invalid = append(invalid, item)
+			continue
+		}
+		decoded, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			invalid = append(invalid, item)
+			continue
+		}
+		values[key] = decoded
+	}
+	if len(invalid) > 0 {
+		return values, fmt.Errorf("malformed entries %q", invalid)
+	}
+	return values, nil
+}
+
+// otlpTLSConfig carrega a CA e o certificado de cliente (mTLS) informados.
+// Devolve nil quando nenhum arquivo foi configurado.
+func otlpTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_CERTIFICATE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_CERTIFICATE: no certificates found in %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE/OTEL_EXPORTER_OTLP_CLIENT_KEY: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// NewSpanExporters cria um exporter para cada item de OTEL_TRACES_EXPORTER.
+// Se algum falhar, os já criados são encerrados antes de devolver o erro.
+func NewSpanExporters(ctx context.Context, cfg ExporterConfig) ([]sdktrace.SpanExporter, error) {
+	names := cfg.TracesExporter
+	if strings.TrimSpace(names) == "" {
+		names = "otlp"
+	}
+
+	var exporters []sdktrace.SpanExporter
+	fail := func(err error) ([]sdktrace.SpanExporter, error) {
+		for _, exporter := range exporters {
+			err = errors.Join(err, exporter.Shutdown(ctx))
+		}
+		return nil, err
+	}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		var exporter sdktrace.SpanExporter
+		var err error
+		switch name {
+		case "none", "":
+			continue
+		case "otlp":
+			exporter, err = newOTLPSpanExporter(ctx, cfg)
+		case "zipkin":
+			endpoint := cfg.ZipkinEndpoint
+			if endpoint == "" {
+				endpoint = "http://localhost:9411/api/v2/spans"
+			}
+			exporter, err = zipkin.New(endpoint)
+		case "console", "stdout":
+			exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+		case "file":
+			exporter, err = newFileSpanExporter(cfg.FilePath)
+		default:
+			err = fmt.Errorf("unsupported OTEL_TRACES_EXPORTER %q", name)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		if cfg.Spool != nil && (name == "otlp" || name == "zipkin") {
+			spool := *cfg.Spool
+			spool.Dir = filepath.Join(spool.Dir, name)
+			spooling, err := NewSpoolingExporter(exporter, spool)
+			if err != nil {
+				return fail(errors.Join(err, exporter.Shutdown(ctx)))
+			}
+			exporter = spooling
+		}
+		exporters = append(exporters, exporter)
+	}
+
+	return exporters, nil
+}
+
+func newOTLPSpanExporter(ctx context.Context, cfg ExporterConfig) (sdktrace.SpanExporter, error) {
+	endpoint, insecure := cfg.otlpEndpoint()
+
+	switch cfg.protocol() {
+	case ProtocolGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithHeaders(cfg.Headers)}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlptracegrpc.WithTimeout(cfg.Timeout))
+		}
+		if insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		} else if cfg.TLS != nil {
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(cfg.TLS)))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ProtocolHTTPProtobuf:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint), otlptracehttp.WithHeaders(cfg.Headers)}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlptracehttp.WithTimeout(cfg.Timeout))
+		}
+		if insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		} else if cfg.TLS != nil {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(cfg.TLS))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_EXPORTER_OTLP_PROTOCOL %q", cfg.Protocol)
+	}
+}
+
+// NewMetricExporter cria o exporter OTLP de métricas no protocolo configurado.
+func NewMetricExporter(ctx context.Context, cfg ExporterConfig) (sdkmetric.Exporter, error) {
+	endpoint, insecure := cfg.otlpEndpoint()
+
+	switch cfg.protocol() {
+	case ProtocolGRPC:
+		opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(endpoint), otlpmetricgrpc.WithHeaders(cfg.Headers)}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlpmetricgrpc.WithTimeout(cfg.Timeout))
+		}
+		if insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		} else if cfg.TLS != nil {
+			opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(cfg.TLS)))
+		}
+		return otlpmetricgrpc.New(ctx, opts...)
+	case ProtocolHTTPProtobuf:
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithEndpoint(endpoint), otlpmetrichttp.WithHeaders(cfg.Headers)}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlpmetrichttp.WithTimeout(cfg.Timeout))
+		}
+		if insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		} else if cfg.TLS != nil {
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(cfg.TLS))
+		}
+		return otlpmetrichttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_EXPORTER_OTLP_PROTOCOL %q", cfg.Protocol)
+	}
+}
+
+// NewLogExporter cria o exporter OTLP de logs no protocolo configurado.
+func NewLogExporter(ctx context.Context, cfg ExporterConfig) (sdklog.Exporter, error) {
+	endpoint, insecure := cfg.otlpEndpoint()
+
+	switch cfg.protocol() {
+	case ProtocolGRPC:
+		opts := []otlploggrpc.Option{otlploggrpc.WithEndpoint(endpoint), otlploggrpc.WithHeaders(cfg.Headers)}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlploggrpc.WithTimeout(cfg.Timeout))
+		}
+		if insecure {
+			opts = append(opts, otlploggrpc.WithInsecure())
+		} else if cfg.TLS != nil {
+			opts = append(opts, otlploggrpc.WithTLSCredentials(credentials.NewTLS(cfg.TLS)))
+		}
+		return otlploggrpc.New(ctx, opts...)
+	case ProtocolHTTPProtobuf:
+		opts := []otlploghttp.Option{otlploghttp.WithEndpoint(endpoint), otlploghttp.WithHeaders(cfg.Headers)}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlploghttp.WithTimeout(cfg.Timeout))
+		}
+		if insecure {
+			opts = append(opts, otlploghttp.WithInsecure())
+		} else if cfg.TLS != nil {
+			opts = append(opts, otlploghttp.WithTLSClientConfig(cfg.TLS))
+		}
+		return otlploghttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_EXPORTER_OTLP_PROTOCOL %q", cfg.Protocol)
+	}
+}
+
+// fileSpanExporter grava um span JSON por linha e fecha o arquivo no shutdown.
+type fileSpanExporter struct {
+	*stdouttrace.Exporter
+	file *os.File
+}
+
+func newFileSpanExporter(path string) (sdktrace.SpanExporter, error) {
+	if path == "" {
+		path = "traces.jsonl"
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open trace file: %w", err)
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &fileSpanExporter{Exporter: exporter, file: file}, nil
+}
+
+func (e *fileSpanExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.Exporter.Shutdown(ctx), e.file.Close())
+}
@@ -0,0 +1,229 @@
+package telemetry
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestExporterConfig_OTLPEndpoint(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   ExporterConfig
+		endpoint string
+		insecure bool
+	}{
+		{"Default gRPC", ExporterConfig{}, "localhost:4317", true},
+		{"Default HTTP", ExporterConfig{Protocol: ProtocolHTTPProtobuf}, "localhost:4318", true},
+		{"Without scheme", ExporterConfig{Endpoint: "otel-collector:4317"}, "otel-collector:4317", true},
+		{"HTTP scheme", ExporterConfig{Endpoint: "http://otel-collector:4318"}, "otel-collector:4318", true},
+		{"HTTPS scheme", ExporterConfig{Endpoint: "https://collector.example.com"}, "collector.example.com", false},
+		{"HTTP scheme with TLS", ExporterConfig{Endpoint: "http://otel-collector:4318", TLS: &tls.Config{}}, "otel-collector:4318", true},
+		{"HTTPS scheme with TLS", ExporterConfig{Endpoint: "https://collector.example.com", TLS: &tls.Config{}}, "collector.example.com", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			endpoint, insecure := tc.config.otlpEndpoint()
+			assert.Equal(t, tc.endpoint, endpoint)
+			assert.Equal(t, tc.insecure, insecure)
+		})
+	}
+}
+
+func TestNewSpanExporters_MultipleExporters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	exporters, err := NewSpanExporters(context.Background(), ExporterConfig{
+		TracesExporter: "otlp, zipkin ,file",
+		Protocol:       ProtocolHTTPProtobuf,
+		FilePath:       path,
+	})
+	require.NoError(t, err)
+	assert.Len(t, exporters, 3)
+
+	for _, exporter := range exporters {
+		require.NoError(t, exporter.Shutdown(context.Background()))
+	}
+}
+
+func TestNewSpanExporters_None(t *testing.T) {
+	exporters, err := NewSpanExporters(context.Background(), ExporterConfig{TracesExporter: "none"})
+	require.NoError(t, err)
+	assert.Empty(t, exporters)
+}
+
+func TestNewSpanExporters_Invalid(t *testing.T) {
+	_, err := NewSpanExporters(context.Background(), ExporterConfig{TracesExporter: "jaeger"})
+	assert.Error(t, err)
+
+	_, err = NewSpanExporters(context.Background(), ExporterConfig{Protocol: "http/json"})
+	assert.Error(t, err)
+}
+
+// Quando um exporter da lista falha, os já criados são encerrados: o arquivo
+// do exporter file não fica aberto.
+func TestNewSpanExporters_ShutsDownCreatedOnError(t *testing.T) {
+	if _, err := os.ReadDir("/proc/self/fd"); err != nil {
+		t.Skip("requires /proc/self/fd")
+	}
+
+	testCases := []struct {
+		name string
+		cfg  ExporterConfig
+	}{
+		{"UnsupportedExporter", ExporterConfig{TracesExporter: "file,bogus"}},
+		{"InvalidProtocol", ExporterConfig{TracesExporter: "file,otlp", Protocol: "http/json"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "traces.jsonl")
+			tc.cfg.FilePath = path
+
+			exporters, err := NewSpanExporters(context.Background(), tc.cfg)
+			require.Error(t, err)
+			assert.Nil(t, exporters)
+			assert.False(t, fileOpen(t, path), "trace file left open")
+		})
+	}
+}
+
+// fileOpen informa se o processo tem um descritor aberto para path.
+func fileOpen(t *testing.T, path string) bool {
+	t.Helper()
+	entries, err := os.ReadDir("/proc/self/fd")
+	require.NoError(t, err)
+	for _, entry := range entries {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", entry.Name())); err == nil && target == path {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFileSpanExporter_WritesJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	exporters, err := NewSpanExporters(context.Background(), ExporterConfig{TracesExporter: "file", FilePath: path})
+	require.NoError(t, err)
+	require.Len(t, exporters, 1)
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporters[0]))
+	tracer := provider.Tracer("test")
+	for _, name := range []string{"validate_cep", "call_service_b"} {
+		_, span := tracer.Start(context.Background(), name)
+		span.End()
+	}
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var span struct{ Name string }
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &span))
+		names = append(names, span.Name)
+	}
+	assert.Equal(t, []string{"validate_cep", "call_service_b"}, names)
+}
+
+// Vários exporters podem ser combinados; o file grava um span por linha.
+func TestNewSpanExporters_FileAndZipkin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	exporters, err := NewSpanExporters(context.Background(), ExporterConfig{TracesExporter: "file,zipkin", FilePath: path})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(exporters) != 2 {
+		t.Fatalf("Expected 2 exporters, got %d", len(exporters))
+	}
+	defer exporters[1].Shutdown(context.Background())
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporters[0]))
+	_, span := provider.Tracer("test").Start(context.Background(), "get_weather")
+	span.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if !scanner.Scan() {
+		t.Fatal("Expected one span in the trace file")
+	}
+	var exported struct{ Name string }
+	if err := json.Unmarshal(scanner.Bytes(), &exported); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if exported.Name != "get_weather" {
+		t.Errorf("Expected span get_weather, got %s", exported.Name)
+	}
+}
+
+func TestExporterConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "https://collector.example.com",
+		"OTEL_EXPORTER_OTLP_HEADERS":  "x-api-key=secret,authorization=Bearer%20token",
+		"OTEL_EXPORTER_OTLP_TIMEOUT":  "2500",
+		"TELEMETRY_SPOOL_DIR":         "/var/spool/otel",
+	}
+
+	cfg, err := ExporterConfigFromEnv(func(key string) string { return env[key] })
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"x-api-key": "secret", "authorization": "Bearer token"}, cfg.Headers)
+	assert.Equal(t, 2500*time.Millisecond, cfg.Timeout)
+	assert.Nil(t, cfg.TLS)
+	require.NotNil(t, cfg.Spool)
+	assert.Equal(t, "/var/spool/otel", cfg.Spool.Dir)
+}
+
+func TestExporterConfigFromEnv_Invalid(t *testing.T) {
+	env := map[string]string{
+		"OTEL_EXPORTER_OTLP_HEADERS":     "x-api-key=secret,broken",
+		"OTEL_EXPORTER_OTLP_TIMEOUT":     "10s",
+		"OTEL_EXPORTER_OTLP_CERTIFICATE": filepath.Join(t.TempDir(), "missing.pem"),
+		"OTEL_METRICS_EXPORTER":          "otlp,statsd",
+	}
+
+	cfg, err := ExporterConfigFromEnv(func(key string) string { return env[key] })
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "OTEL_EXPORTER_OTLP_HEADERS")
+	assert.Contains(t, err.Error(), "OTEL_EXPORTER_OTLP_TIMEOUT")
+	assert.Contains(t, err.Error(), "OTEL_EXPORTER_OTLP_CERTIFICATE")
+	assert.Contains(t, err.Error(), "OTEL_METRICS_EXPORTER")
+	assert.True(t, cfg.OTLPMetrics())
+	assert.Equal(t, map[string]string{"x-api-key": "secret"}, cfg.Headers)
+	assert.Zero(t, cfg.Timeout)
+}
+
+func TestExporterConfig_OTLPMetrics(t *testing.T) {
+	assert.True(t, ExporterConfig{}.OTLPMetrics())
+	assert.True(t, ExporterConfig{MetricsExporter: "prometheus, otlp"}.OTLPMetrics())
+	assert.False(t, ExporterConfig{MetricsExporter: "none"}.OTLPMetrics())
+	assert.False(t, ExporterConfig{MetricsExporter: "prometheus"}.OTLPMetrics())
+}
+
+// Com uma CA configurada, o endpoint sem esquema passa a usar TLS.
+func TestExporterConfig_TLSWithoutScheme(t *testing.T) {
+	endpoint, insecure := ExporterConfig{Endpoint: "otel-collector:4317", TLS: &tls.Config{}}.otlpEndpoint()
+	assert.Equal(t, "otel-collector:4317", endpoint)
+	assert.False(t, insecure)
+}
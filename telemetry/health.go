@@ -0,0 +1,344 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultHealthCheckTimeout = 2 * time.Second
+	defaultHealthCacheTTL     = 5 * time.Second
+
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// HealthCheck verifica uma dependência necessária para atender requisições.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+	// Timeout sobrepõe HealthConfig.Timeout para esta verificação.
+	Timeout time.Duration
+}
+
+// HealthConfig reúne as variáveis HEALTH_*.
+type HealthConfig struct {
+	// Timeout é HEALTH_CHECK_TIMEOUT: o prazo de cada verificação. Padrão: 2s.
+	Timeout time.Duration
+	// CacheTTL é HEALTH_CACHE_TTL: por quanto tempo o resultado do /readyz é
+	// reaproveitado, para que as probes não sobrecarreguem as dependências.
+	// Padrão: 5s.
+	CacheTTL time.Duration
+	// Checks é HEALTH_CHECKS: os nomes das verificações habilitadas, ou
+	// "none". Vazio habilita todas as registradas pelo serviço.
+	Checks []string
+}
+
+// HealthConfigFromEnv lê HEALTH_CHECK_TIMEOUT, HEALTH_CACHE_TTL e
+// HEALTH_CHECKS. Em caso de erro, devolve os padrões para os valores
+// inválidos.
+func HealthConfigFromEnv(getenv func(string) string) (HealthConfig, error) {
+	cfg := HealthConfig{Timeout: defaultHealthCheckTimeout, CacheTTL: defaultHealthCacheTTL}
+
+	var errs []error
+	if value := getenv("HEALTH_CHECK_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			errs = append(errs, fmt.Errorf("invalid HEALTH_CHECK_TIMEOUT %q: must be a positive duration such as 2s", value))
+		} else {
+			cfg.Timeout = timeout
+		}
+	}
+	if value := getenv("HEALTH_CACHE_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl < 0 {
+			errs = append(errs, fmt.Errorf("invalid HEALTH_CACHE_TTL %q: must be a duration such as 5s", value))
+		} else {
+			cfg.CacheTTL = ttl
+		}
+	}
+	if value := strings.TrimSpace(getenv("HEALTH_CHECKS")); value != "" {
+		cfg.Checks = []string{}
+		if !strings.EqualFold(value, "none") {
+			for _, name := range strings.Split(value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					cfg.Checks = append(cfg.Checks, name)
+				}
+			}
+		}
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+// HealthCheckResult é o resultado de uma verificação no relatório do /readyz.
+// Error fica fora do JSON público, já que pode expor endereços e detalhes
+// internos das dependências, e vai só para o log; Reason é a versão segura
+// dele (ex.: timeout, status 503, connection refused).
+type HealthCheckResult struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Reason     string  `json:"reason,omitempty"`
+	Error      string  `json:"-"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// HealthReport é o corpo JSON do /readyz.
+type HealthReport struct {
+	Status    string              `json:"status"`
+	CheckedAt time.Time           `json:"checked_at"`
+	Cached    bool                `json:"cached"`
+	Checks    []HealthCheckResult `json:"checks"`
+}
+
+// Health executa as verificações de prontidão e guarda o último relatório.
+type Health struct {
+	tracer   trace.Tracer
+	logger   *slog.Logger
+	cacheTTL time.Duration
+	checks   []HealthCheck
+
+	group  singleflight.Group
+	mu     sync.Mutex
+	report *HealthReport
+}
+
+// NewHealth registra as verificações habilitadas em cfg.Checks. Um nome em
+// cfg.Checks que o serviço não registrou é um erro de configuração.
+func NewHealth(tracer trace.Tracer, logger *slog.Logger, cfg HealthConfig, checks ...HealthCheck) (*Health, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultHealthCheckTimeout
+	}
+
+	registered := make(map[string]HealthCheck, len(checks))
+	for _, check := range checks {
+		if check.Timeout <= 0 {
+			check.Timeout = cfg.Timeout
+		}
+		registered[check.Name] = check
+	}
+
+	h := &Health{tracer: tracer, logger: logger, cacheTTL: cfg.CacheTTL}
+	if cfg.Checks == nil {
+		for _, check := range checks {
+			h.checks = append(h.checks, registered[check.Name])
+		}
+		return h, nil
+	}
+	for _, name := range cfg.Checks {
+		check, ok := registered[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown health check %q", name)
+		}
+		h.checks = append(h.checks, check)
+	}
+	return h, nil
+}
+
+// Report executa as verificações em paralelo, cada uma com seu próprio prazo
+// e span, ou devolve o relatório anterior se ainda estiver dentro do cache.
+// Chamadas simultâneas com o cache vencido compartilham a mesma execução.
+func (h *Health) Report(ctx context.Context) HealthReport {
+	h.mu.Lock()
+	cached := h.report
+	h.mu.Unlock()
+
+	if cached != nil && time.Since(cached.CheckedAt) < h.cacheTTL {
+		report := *cached
+		report.Cached = true
+		return report
+	}
+
+	// O resultado é compartilhado: o cancelamento de quem chamou não pode
+	// marcar as dependências como indisponíveis
+	ctx = context.WithoutCancel(ctx)
+
+	result, _, _ := h.group.Do("report", func() (any, error) {
+		report := h.check(ctx)
+		h.mu.Lock()
+		h.report = &report
+		h.mu.Unlock()
+		return report, nil
+	})
+	return result.(HealthReport)
+}
+
+// check agrupa as verificações de uma execução no mesmo trace. As probes não
+// passam pelo TracingMiddleware, então o span health_report é a raiz.
+func (h *Health) check(ctx context.Context) HealthReport {
+	ctx, span := h.tracer.Start(ctx, "health_report")
+	defer span.End()
+
+	report := HealthReport{
+		Status:    HealthStatusOK,
+		CheckedAt: time.Now(),
+		Checks:    make([]HealthCheckResult, len(h.checks)),
+	}
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = h.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != HealthStatusOK {
+			report.Status = HealthStatusFail
+		}
+	}
+	span.SetAttributes(attribute.String("health.status", report.Status))
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check HealthCheck) HealthCheckResult {
+	ctx, span := h.tracer.Start(ctx, "health_check", trace.WithAttributes(attribute.String("health.check.name", check.Name)))
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	reason := healthReason(err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", check.Timeout)
+	}
+	EndSpan(span, err)
+
+	result := HealthCheckResult{
+		Name:       check.Name,
+		Status:     HealthStatusOK,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = HealthStatusFail
+		result.Reason = reason
+		result.Error = err.Error()
+		h.logger.WarnContext(ctx, "health check failed",
+			slog.String("health.check.name", check.Name),
+			slog.Float64("duration_ms", result.DurationMs),
+			slog.Any("error", err),
+		)
+	}
+	return result
+}
+
+// healthReason resume err sem URLs, endereços ou mensagens das dependências,
+// para que possa ir na resposta pública do /readyz.
+func healthReason(err error) string {
+	var statusErr healthStatusError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &statusErr):
+		return fmt.Sprintf("status %d", int(statusErr))
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.As(err, &dnsErr):
+		return "dns lookup failed"
+	case errors.As(err, &netErr):
+		return "network error"
+	default:
+		return "check failed"
+	}
+}
+
+// healthStatusError é o status 5xx que reprovou um HTTPCheck.
+type healthStatusError int
+
+func (e healthStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", int(e))
+}
+
+// LivenessHandler responde o /healthz: só indica que o processo está de pé,
+// sem consultar dependências.
+func (h *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": HealthStatusOK})
+	})
+}
+
+// ReadinessHandler responde o /readyz com o relatório das verificações: 200
+// se todas passaram e 503 caso contrário.
+func (h *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := h.Report(r.Context())
+
+		status := http.StatusOK
+		if report.Status != HealthStatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+// HTTPCheck considera a dependência disponível se rawURL responder com
+// status abaixo de 500; um 401 sem credenciais ainda prova que ela está no ar.
+func HTTPCheck(client *http.Client, rawURL string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return err
+		}
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return healthStatusError(resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// CollectorCheck verifica se o collector OTLP aceita conexões TCP no
+// endpoint usado pelos exporters.
+func CollectorCheck(cfg ExporterConfig) func(ctx context.Context) error {
+	endpoint, insecure := cfg.otlpEndpoint()
+	if _, _, err := net.SplitHostPort(endpoint); err != nil {
+		// Endpoints como https://collector.example.com usam a porta do esquema
+		port := "443"
+		if insecure {
+			port = "80"
+		}
+		endpoint = net.JoinHostPort(endpoint, port)
+	}
+	return func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
@@ -0,0 +1,238 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHealthConfigFromEnv(t *testing.T) {
+	cfg, err := HealthConfigFromEnv(func(string) string { return "" })
+	require.NoError(t, err)
+	assert.Equal(t, defaultHealthCheckTimeout, cfg.Timeout)
+	assert.Equal(t, defaultHealthCacheTTL, cfg.CacheTTL)
+	assert.Nil(t, cfg.Checks)
+
+	env := map[string]string{"HEALTH_CHECK_TIMEOUT": "500ms", "HEALTH_CACHE_TTL": "0s", "HEALTH_CHECKS": "collector, viacep"}
+	cfg, err = HealthConfigFromEnv(func(key string) string { return env[key] })
+	require.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, cfg.Timeout)
+	assert.Zero(t, cfg.CacheTTL)
+	assert.Equal(t, []string{"collector", "viacep"}, cfg.Checks)
+
+	env = map[string]string{"HEALTH_CHECK_TIMEOUT": "soon", "HEALTH_CHECKS": "none"}
+	cfg, err = HealthConfigFromEnv(func(key string) string { return env[key] })
+	require.Error(t, err)
+	assert.Equal(t, defaultHealthCheckTimeout, cfg.Timeout)
+	assert.Empty(t, cfg.Checks)
+	assert.NotNil(t, cfg.Checks)
+}
+
+func TestHealth_ReadinessReportsEachCheckWithSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	var logs bytes.Buffer
+	health, err := NewHealth(tracer, slog.New(slog.NewJSONHandler(&logs, nil)), HealthConfig{Timeout: time.Second},
+		HealthCheck{Name: "collector", Check: func(context.Context) error { return nil }},
+		HealthCheck{Name: "viacep", Check: func(context.Context) error {
+			return errors.New(`Get "https://viacep.com.br/ws/01001000/json/": EOF`)
+		}},
+	)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	health.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var report HealthReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, HealthStatusFail, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "collector", report.Checks[0].Name)
+	assert.Equal(t, HealthStatusOK, report.Checks[0].Status)
+	assert.Equal(t, "viacep", report.Checks[1].Name)
+	assert.Equal(t, HealthStatusFail, report.Checks[1].Status)
+	assert.Equal(t, "check failed", report.Checks[1].Reason)
+	assert.Contains(t, rec.Body.String(), "duration_ms")
+
+	// O detalhe do erro vai só para o log, não para a resposta pública
+	assert.NotContains(t, rec.Body.String(), "01001000")
+	assert.Contains(t, logs.String(), "viacep.com.br/ws/01001000")
+	assert.Contains(t, logs.String(), `"health.check.name":"viacep"`)
+
+	// As verificações de uma execução ficam no mesmo trace
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	parent := spans[2]
+	assert.Equal(t, "health_report", parent.Name())
+	for _, span := range spans[:2] {
+		assert.Equal(t, "health_check", span.Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+}
+
+func TestHealth_PerCheckTimeout(t *testing.T) {
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	health, err := NewHealth(tracer, slog.New(slog.NewTextHandler(io.Discard, nil)), HealthConfig{Timeout: 20 * time.Millisecond},
+		HealthCheck{Name: "weatherapi", Check: slow},
+		HealthCheck{Name: "serviceB", Check: func(context.Context) error { return nil }, Timeout: time.Second},
+	)
+	require.NoError(t, err)
+
+	report := health.Report(context.Background())
+	assert.Equal(t, HealthStatusFail, report.Status)
+	assert.Equal(t, "timed out after 20ms", report.Checks[0].Error)
+	assert.Equal(t, "timeout", report.Checks[0].Reason)
+	assert.Equal(t, HealthStatusOK, report.Checks[1].Status)
+}
+
+func TestHealth_CachesReport(t *testing.T) {
+	var calls atomic.Int32
+	check := HealthCheck{Name: "collector", Check: func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}}
+
+	health, err := NewHealth(sdktrace.NewTracerProvider().Tracer("test"), slog.New(slog.NewTextHandler(io.Discard, nil)), HealthConfig{CacheTTL: time.Minute}, check)
+	require.NoError(t, err)
+
+	first := health.Report(context.Background())
+	second := health.Report(context.Background())
+	assert.False(t, first.Cached)
+	assert.True(t, second.Cached)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// Probes simultâneas com o cache vencido não disparam verificações em dobro
+// nem ficam presas a um lock enquanto elas rodam.
+func TestHealth_ConcurrentReportsShareOneRun(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	check := HealthCheck{Name: "collector", Check: func(context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	}}
+
+	health, err := NewHealth(sdktrace.NewTracerProvider().Tracer("test"), slog.New(slog.NewTextHandler(io.Discard, nil)), HealthConfig{CacheTTL: time.Minute}, check)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	reports := make([]HealthReport, 5)
+	for i := range reports {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reports[i] = health.Report(context.Background())
+		}()
+	}
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, 5*time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, report := range reports {
+		assert.Equal(t, HealthStatusOK, report.Status)
+	}
+}
+
+func TestNewHealth_SelectsConfiguredChecks(t *testing.T) {
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	ok := func(context.Context) error { return nil }
+	checks := []HealthCheck{{Name: "collector", Check: ok}, {Name: "viacep", Check: ok}}
+
+	health, err := NewHealth(tracer, slog.New(slog.NewTextHandler(io.Discard, nil)), HealthConfig{Checks: []string{"viacep"}}, checks...)
+	require.NoError(t, err)
+	report := health.Report(context.Background())
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "viacep", report.Checks[0].Name)
+
+	health, err = NewHealth(tracer, slog.New(slog.NewTextHandler(io.Discard, nil)), HealthConfig{Checks: []string{}}, checks...)
+	require.NoError(t, err)
+	assert.Equal(t, HealthStatusOK, health.Report(context.Background()).Status)
+
+	_, err = NewHealth(tracer, slog.New(slog.NewTextHandler(io.Discard, nil)), HealthConfig{Checks: []string{"redis"}}, checks...)
+	assert.ErrorContains(t, err, "redis")
+}
+
+func TestHealthReason(t *testing.T) {
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := closed.Addr().String()
+	closed.Close()
+	var dialer net.Dialer
+	_, refused := dialer.DialContext(context.Background(), "tcp", addr)
+
+	testCases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"Timeout", context.DeadlineExceeded, "timeout"},
+		{"Status", healthStatusError(http.StatusServiceUnavailable), "status 503"},
+		{"ConnectionRefused", refused, "connection refused"},
+		{"Other", errors.New("redis: NOAUTH secret"), "check failed"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, healthReason(tc.err))
+		})
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	check := HTTPCheck(server.Client(), server.URL)
+	assert.NoError(t, check(context.Background()))
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorContains(t, check(context.Background()), "503")
+}
+
+func TestCollectorCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+
+	check := CollectorCheck(ExporterConfig{Endpoint: addr})
+	assert.NoError(t, check(context.Background()))
+
+	listener.Close()
+	assert.Error(t, check(context.Background()))
+}
+
+func TestHealth_Liveness(t *testing.T) {
+	health, err := NewHealth(sdktrace.NewTracerProvider().Tracer("test"), slog.New(slog.NewTextHandler(io.Discard, nil)), HealthConfig{})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	health.LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
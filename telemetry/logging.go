@@ -0,0 +1,173 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type cepKey struct{}
+
+var cepDigits = regexp.MustCompile(`\D`)
+
+// RedactCEP aplica a política de mascaramento de CEP nos logs: apenas os
+// cinco primeiros dígitos (região/setor) são mantidos.
+func RedactCEP(cep string) string {
+	digits := cepDigits.ReplaceAllString(cep, "")
+	if len(digits) < 5 {
+		return "***"
+	}
+	return digits[:5] + "-***"
+}
+
+// WithCEP guarda o CEP (já mascarado) no contexto para que todos os logs da
+// requisição o incluam.
+func WithCEP(ctx context.Context, cep string) context.Context {
+	redacted := RedactCEP(cep)
+	if info := requestInfoFromContext(ctx); info != nil {
+		info.cep = redacted
+	}
+	return context.WithValue(ctx, cepKey{}, redacted)
+}
+
+// WithItemCEP é o WithCEP de um item de uma requisição com vários CEPs (ex.:
+// lote): os logs do item recebem o CEP mascarado, mas o log de acesso da
+// requisição não é alterado. Seguro para uso concorrente.
+func WithItemCEP(ctx context.Context, cep string) context.Context {
+	return context.WithValue(ctx, cepKey{}, RedactCEP(cep))
+}
+
+// TraceHandler adiciona trace_id, span_id e cep aos registros emitidos
+// com um contexto de requisição.
+type TraceHandler struct {
+	slog.Handler
+}
+
+func NewTraceHandler(handler slog.Handler) *TraceHandler {
+	return &TraceHandler{Handler: handler}
+}
+
+func (h *TraceHandler) Handle(ctx context.Context, record slog.Record) error {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	if cep, ok := ctx.Value(cepKey{}).(string); ok {
+		record.AddAttrs(slog.String("cep", cep))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *TraceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &TraceHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *TraceHandler) WithGroup(name string) slog.Handler {
+	return &TraceHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// fanoutHandler envia cada registro para todos os handlers habilitados,
+// usado para escrever em stdout e na ponte de logs OTLP ao mesmo tempo.
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+// NewLogger cria o logger JSON do serviço. Handlers extras (ex.: a ponte
+// OpenTelemetry) recebem os mesmos registros enriquecidos.
+func NewLogger(serviceName string, level slog.Level, w io.Writer, extra ...slog.Handler) *slog.Logger {
+	var handler slog.Handler = slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	if len(extra) > 0 {
+		handler = append(fanoutHandler{handler}, extra...)
+	}
+
+	return slog.New(NewTraceHandler(handler)).With(slog.String("service.name", serviceName))
+}
+
+// ParseLevel converte LOG_LEVEL (debug, info, warn, error) em slog.Level.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// RequestLogger substitui o middleware.Logger do chi por um log estruturado
+// de acesso, correlacionado com o trace da requisição.
+func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			r, info := withRequestInfo(r)
+
+			next.ServeHTTP(rec, r)
+
+			ctx := info.context(r.Context())
+			logger.LogAttrs(ctx, levelForStatus(rec.statusCode()), "request completed",
+				slog.String("http.request.method", r.Method),
+				slog.String("url.path", r.URL.Path),
+				slog.Int("http.response.status_code", rec.statusCode()),
+				slog.Duration("duration", time.Since(start)),
+			)
+		})
+	}
+}
+
+func levelForStatus(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
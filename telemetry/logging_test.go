@@ -0,0 +1,152 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRedactCEP(t *testing.T) {
+	tests := map[string]string{
+		"01153000":  "01153-***",
+		"01153-000": "01153-***",
+		"04446-160": "04446-***",
+		"0115":      "***",
+		"123":       "***",
+		"":          "***",
+	}
+
+	for input, expected := range tests {
+		assert.Equal(t, expected, RedactCEP(input), "RedactCEP(%q)", input)
+	}
+}
+
+func TestNewLogger_AddsTraceAndCEP(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger("ServiceA", slog.LevelInfo, &buf)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	ctx = WithCEP(ctx, "01153-000")
+
+	logger.InfoContext(ctx, "hello")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "ServiceA", record["service.name"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", record["span_id"])
+	assert.Equal(t, "01153-***", record["cep"])
+}
+
+func TestNewLogger_WithoutRequestContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger("ServiceA", slog.LevelInfo, &buf)
+
+	logger.Info("starting")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.NotContains(t, record, "trace_id")
+	assert.NotContains(t, record, "cep")
+}
+
+func TestRequestLogger_UsesHandlerSpanAndCEP(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger("ServiceA", slog.LevelInfo, &buf)
+
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+
+	handler := RequestLogger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := trace.ContextWithSpanContext(r.Context(), spanContext)
+		MarkRequestSpan(ctx)
+		WithCEP(ctx, "12345678")
+		http.Error(w, "invalid zipcode", http.StatusUnprocessableEntity)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", record["trace_id"])
+	assert.Equal(t, "12345-***", record["cep"])
+	assert.Equal(t, float64(http.StatusUnprocessableEntity), record["http.response.status_code"])
+}
+
+func TestRequestLogger_WithItemCEPKeepsRequestCEP(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger("ServiceA", slog.LevelInfo, &buf)
+
+	handler := RequestLogger(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		itemCtx := WithItemCEP(r.Context(), "01001000")
+		logger.InfoContext(itemCtx, "item processed")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/batch", nil))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var item, access map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &item))
+	require.NoError(t, json.Unmarshal(lines[1], &access))
+	assert.Equal(t, "01001-***", item["cep"])
+	assert.NotContains(t, access, "cep")
+}
+
+func TestNewLogger_CorrelatesWithTrace(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger("ServiceB", slog.LevelInfo, &buf)
+
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = WithCEP(ctx, "04446-160")
+
+	logger.WarnContext(ctx, "failed to fetch weather data")
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Log record is not valid JSON: %v", err)
+	}
+
+	expected := map[string]string{
+		"service.name": "ServiceB",
+		"trace_id":     "0af7651916cd43dd8448eb211c80319c",
+		"span_id":      "b7ad6b7169203331",
+		"cep":          "04446-***",
+		"level":        "WARN",
+	}
+	for field, value := range expected {
+		if record[field] != value {
+			t.Errorf("Expected %s '%s', got '%v'", field, value, record[field])
+		}
+	}
+}
+
+func TestNewLogger_RespectsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger("ServiceB", ParseLevel("warn"), &buf)
+
+	logger.Info("ignored")
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected info record to be filtered, got %s", buf.String())
+	}
+}
@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Metrics agrupa os instrumentos RED (rate, errors, duration) do serviço.
+type Metrics struct {
+	requests         metric.Int64Counter
+	errors           metric.Int64Counter
+	requestDuration  metric.Float64Histogram
+	upstreamDuration metric.Float64Histogram
+}
+
+func NewMetrics(meter metric.Meter) (*Metrics, error) {
+	requests, err := meter.Int64Counter("http.server.requests",
+		metric.WithDescription("Number of HTTP requests handled by the server."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	errors, err := meter.Int64Counter("http.server.errors",
+		metric.WithDescription("Number of HTTP requests answered with a 4xx or 5xx status."),
+		metric.WithUnit("{request}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	requestDuration, err := meter.Float64Histogram("http.server.request.duration",
+		metric.WithDescription("Duration of HTTP server requests."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	upstreamDuration, err := meter.Float64Histogram("http.client.request.duration",
+		metric.WithDescription("Duration of HTTP requests made to upstream services."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metrics{
+		requests:         requests,
+		errors:           errors,
+		requestDuration:  requestDuration,
+		upstreamDuration: upstreamDuration,
+	}, nil
+}
+
+func (m *Metrics) RecordRequest(ctx context.Context, method, route string, status int, duration time.Duration) {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", method),
+		attribute.Int("http.response.status_code", status),
+	}
+	if route != "" {
+		attrs = append(attrs, attribute.String("http.route", route))
+	}
+	opt := metric.WithAttributes(attrs...)
+
+	m.requests.Add(ctx, 1, opt)
+	if status >= http.StatusBadRequest {
+		m.errors.Add(ctx, 1, opt)
+	}
+	m.requestDuration.Record(ctx, duration.Seconds(), opt)
+}
+
+// RecordUpstream registra a latência de uma chamada a um serviço externo.
+// status deve ser 0 quando a requisição falhou antes de obter uma resposta.
+func (m *Metrics) RecordUpstream(ctx context.Context, upstream, method string, status int, err error, duration time.Duration) {
+	attrs := []attribute.KeyValue{
+		attribute.String("upstream", upstream),
+		attribute.String("http.request.method", method),
+	}
+	if status != 0 {
+		attrs = append(attrs, attribute.Int("http.response.status_code", status))
+	}
+	switch {
+	case err != nil && status == 0:
+		attrs = append(attrs, attribute.String("error.type", "transport"))
+	case status >= http.StatusBadRequest:
+		attrs = append(attrs, attribute.String("error.type", strconv.Itoa(status)))
+	}
+
+	m.upstreamDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(attrs...))
+}
@@ -0,0 +1,151 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	chiv5 "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func newTestMetrics(t *testing.T) (*Metrics, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	metrics, err := NewMetrics(provider.Meter("test"))
+	require.NoError(t, err)
+	return metrics, reader
+}
+
+func collectSum(t *testing.T, reader *sdkmetric.ManualReader, name string) []metricdata.DataPoint[int64] {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				return m.Data.(metricdata.Sum[int64]).DataPoints
+			}
+		}
+	}
+	return nil
+}
+
+func TestMetricsMiddleware_RecordsRouteAndStatus(t *testing.T) {
+	metrics, reader := newTestMetrics(t)
+
+	router := chi.NewRouter()
+	router.Use(MetricsMiddleware(metrics))
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid zipcode", http.StatusUnprocessableEntity)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	requests := collectSum(t, reader, "http.server.requests")
+	require.Len(t, requests, 1)
+	assert.Equal(t, int64(1), requests[0].Value)
+
+	route, _ := requests[0].Attributes.Value(attribute.Key("http.route"))
+	assert.Equal(t, "/", route.AsString())
+	status, _ := requests[0].Attributes.Value(attribute.Key("http.response.status_code"))
+	assert.Equal(t, int64(http.StatusUnprocessableEntity), status.AsInt64())
+
+	errors := collectSum(t, reader, "http.server.errors")
+	require.Len(t, errors, 1)
+	assert.Equal(t, int64(1), errors[0].Value)
+}
+
+func TestMetricsMiddleware_SuccessIsNotAnError(t *testing.T) {
+	metrics, reader := newTestMetrics(t)
+
+	router := chi.NewRouter()
+	router.Use(MetricsMiddleware(metrics))
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	assert.Len(t, collectSum(t, reader, "http.server.requests"), 1)
+	assert.Empty(t, collectSum(t, reader, "http.server.errors"))
+}
+
+func findMetric(t *testing.T, reader *sdkmetric.ManualReader, name string) *metricdata.Metrics {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Failed to collect metrics: %v", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for i := range sm.Metrics {
+			if sm.Metrics[i].Name == name {
+				return &sm.Metrics[i]
+			}
+		}
+	}
+	return nil
+}
+
+func TestMetricsMiddleware_CEPNotFound(t *testing.T) {
+	metrics, reader := newTestMetrics(t)
+
+	router := chiv5.NewRouter()
+	router.Use(MetricsMiddleware(metrics))
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Can not find zipcode", http.StatusNotFound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?cep=99999999", nil))
+
+	requests := findMetric(t, reader, "http.server.requests")
+	if requests == nil {
+		t.Fatal("Expected http.server.requests to be recorded")
+	}
+	points := requests.Data.(metricdata.Sum[int64]).DataPoints
+	if len(points) != 1 || points[0].Value != 1 {
+		t.Fatalf("Expected a single request data point, got %+v", points)
+	}
+	if route, _ := points[0].Attributes.Value("http.route"); route.AsString() != "/" {
+		t.Errorf("Expected http.route '/', got '%s'", route.AsString())
+	}
+	if status, _ := points[0].Attributes.Value("http.response.status_code"); status.AsInt64() != 404 {
+		t.Errorf("Expected status 404, got %d", status.AsInt64())
+	}
+
+	if findMetric(t, reader, "http.server.errors") == nil {
+		t.Error("Expected http.server.errors to be recorded")
+	}
+}
+
+func TestRecordUpstream_TransportError(t *testing.T) {
+	metrics, reader := newTestMetrics(t)
+
+	metrics.RecordUpstream(context.Background(), "viacep", http.MethodGet, 0, errors.New("timeout"), 150*time.Millisecond)
+
+	duration := findMetric(t, reader, "http.client.request.duration")
+	if duration == nil {
+		t.Fatal("Expected http.client.request.duration to be recorded")
+	}
+	points := duration.Data.(metricdata.Histogram[float64]).DataPoints
+	if len(points) != 1 {
+		t.Fatalf("Expected 1 data point, got %d", len(points))
+	}
+
+	attrs := points[0].Attributes
+	if upstream, _ := attrs.Value("upstream"); upstream.AsString() != "viacep" {
+		t.Errorf("Expected upstream 'viacep', got '%s'", upstream.AsString())
+	}
+	if errorType, _ := attrs.Value(attribute.Key("error.type")); errorType.AsString() != "transport" {
+		t.Errorf("Expected error.type 'transport', got '%s'", errorType.AsString())
+	}
+	if attrs.HasValue("http.response.status_code") {
+		t.Error("Expected no status code for a transport error")
+	}
+}
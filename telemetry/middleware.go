@@ -0,0 +1,182 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	chiv5 "github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestInfoKey struct{}
+
+// requestInfo é compartilhado entre os middlewares e o handler para que os
+// dados conhecidos só dentro do handler (span raiz, CEP) cheguem às métricas
+// e ao log de acesso.
+type requestInfo struct {
+	spanContext trace.SpanContext
+	cep         string
+}
+
+func requestInfoFromContext(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
+
+func withRequestInfo(r *http.Request) (*http.Request, *requestInfo) {
+	if info := requestInfoFromContext(r.Context()); info != nil {
+		return r, info
+	}
+	info := &requestInfo{}
+	return r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)), info
+}
+
+// context devolve ctx acrescido do span raiz e do CEP registrados pelo handler.
+func (i *requestInfo) context(ctx context.Context) context.Context {
+	if i.spanContext.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, i.spanContext)
+	}
+	if i.cep != "" {
+		ctx = context.WithValue(ctx, cepKey{}, i.cep)
+	}
+	return ctx
+}
+
+// MarkRequestSpan associa o span raiz da requisição às métricas registradas
+// pelo MetricsMiddleware, para que os exemplars carreguem o trace_id.
+func MarkRequestSpan(ctx context.Context) {
+	if info := requestInfoFromContext(ctx); info != nil {
+		info.spanContext = trace.SpanContextFromContext(ctx)
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+func (s *statusRecorder) statusCode() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
+
+// MetricsMiddleware registra contagem, erros e latência por rota e status.
+func MetricsMiddleware(metrics *Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w}
+			r, info := withRequestInfo(r)
+
+			next.ServeHTTP(rec, r)
+
+			metrics.RecordRequest(info.context(r.Context()), r.Method, routePattern(r), rec.statusCode(), time.Since(start))
+		})
+	}
+}
+
+// routePattern devolve o padrão da rota do chi (ex.: /), disponível somente
+// depois do roteamento. O Serviço A usa o chi v1 e o Serviço B o chi v5.
+func routePattern(r *http.Request) string {
+	if rctx := chiv5.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+func serverAttributes(r *http.Request) []attribute.KeyValue {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", r.Method),
+		attribute.String("url.path", r.URL.Path),
+		attribute.String("url.scheme", scheme),
+		attribute.String("server.address", r.Host),
+		attribute.String("network.protocol.version", fmt.Sprintf("%d.%d", r.ProtoMajor, r.ProtoMinor)),
+	}
+	if userAgent := r.UserAgent(); userAgent != "" {
+		attrs = append(attrs, attribute.String("user_agent.original", userAgent))
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		attrs = append(attrs, attribute.String("client.address", host))
+	}
+	return attrs
+}
+
+// TracingMiddleware cria o span SERVER de cada requisição, continuando o trace
+// recebido nos headers. O span é nomeado pelo método e pela rota (ex.: GET /),
+// nunca pela URL, e marcado como erro em respostas 5xx e panics.
+func TracingMiddleware(tracer trace.Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(serverAttributes(r)...),
+			)
+			// Em caso de panic, End registra o evento exception com a stack trace
+			defer span.End(trace.WithStackTrace(true))
+
+			r = r.WithContext(ctx)
+			MarkRequestSpan(ctx)
+			rec := &statusRecorder{ResponseWriter: w}
+
+			defer func() {
+				if route := routePattern(r); route != "" {
+					span.SetName(r.Method + " " + route)
+					span.SetAttributes(attribute.String("http.route", route))
+				}
+
+				status := rec.statusCode()
+				p := recover()
+				if p != nil {
+					status = http.StatusInternalServerError
+				}
+				span.SetAttributes(attribute.Int("http.response.status_code", status))
+				if status >= http.StatusInternalServerError {
+					span.SetStatus(codes.Error, http.StatusText(status))
+					span.SetAttributes(attribute.String("error.type", strconv.Itoa(status)))
+				}
+				if p != nil {
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
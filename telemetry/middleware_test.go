@@ -0,0 +1,123 @@
+package telemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	chiv5 "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTracingRouter(handler http.HandlerFunc) (*chi.Mux, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	router := chi.NewRouter()
+	router.Use(TracingMiddleware(tracer))
+	router.HandleFunc("/", handler)
+	return router, recorder
+}
+
+func TestTracingMiddleware_ServerSpan(t *testing.T) {
+	router, recorder := newTracingRouter(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, trace.SpanContextFromContext(r.Context()).IsValid())
+		http.Error(w, "invalid zipcode", http.StatusUnprocessableEntity)
+	})
+
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(previous)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "POST /", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", span.SpanContext().TraceID().String())
+	assert.Contains(t, span.Attributes(), attribute.String("http.route", "/"))
+	assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusUnprocessableEntity))
+	// Erros 4xx são do cliente: o span SERVER não é marcado como erro
+	assert.Equal(t, codes.Unset, span.Status().Code)
+}
+
+func TestTracingMiddleware_ServerError(t *testing.T) {
+	router, recorder := newTracingRouter(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("error.type", "500"))
+}
+
+func TestTracingMiddleware_Panic(t *testing.T) {
+	router, recorder := newTracingRouter(func(w http.ResponseWriter, r *http.Request) {
+		panic("invalid json")
+	})
+
+	assert.Panics(t, func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "exception", spans[0].Events()[0].Name)
+}
+
+// O nome do span usa a rota, e não a query string com o CEP.
+func TestTracingMiddleware_SpanNamedByRoute(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	router := chiv5.NewRouter()
+	router.Use(TracingMiddleware(tracer))
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Can not find zipcode", http.StatusNotFound)
+	})
+	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	for _, cep := range []string{"01153000", "04446160"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?cep="+cep, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	for _, span := range spans[:2] {
+		if span.Name() != "GET /" {
+			t.Errorf("Expected span name 'GET /', got %q", span.Name())
+		}
+		if span.SpanKind() != trace.SpanKindServer {
+			t.Errorf("Expected SERVER span, got %s", span.SpanKind())
+		}
+		if span.Status().Code != codes.Unset {
+			t.Errorf("Expected 404 to leave the status unset, got %v", span.Status())
+		}
+	}
+	if spans[2].Name() != "GET /health" || spans[2].Status().Code != codes.Error {
+		t.Errorf("Expected GET /health with error status, got %q (%v)", spans[2].Name(), spans[2].Status())
+	}
+}
@@ -0,0 +1,252 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"regexp"
+	runtimepprof "runtime/pprof"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultProfileCaptureDuration = 10 * time.Second
+	defaultProfileCaptureCooldown = 5 * time.Minute
+)
+
+// ProfilingConfig reúne as variáveis PPROF_*.
+type ProfilingConfig struct {
+	// Addr é PPROF_ADDR: o listener administrativo com o net/http/pprof (ex.:
+	// localhost:6060). Vazio desliga o listener.
+	Addr string
+	// SlowThreshold é PPROF_SLOW_THRESHOLD: uma requisição ainda em andamento
+	// após esse tempo dispara uma captura. Zero desliga a captura automática.
+	// Exige Addr, já que os perfis só são servidos pelo listener administrativo.
+	SlowThreshold time.Duration
+	// CaptureDuration é PPROF_CAPTURE_DURATION: a duração do perfil de CPU
+	// capturado. Padrão: 10s.
+	CaptureDuration time.Duration
+	// Cooldown é PPROF_CAPTURE_COOLDOWN: o intervalo mínimo entre capturas.
+	// Padrão: 5m.
+	Cooldown time.Duration
+	// Dir é PPROF_CAPTURE_DIR: onde os perfis capturados são gravados. Padrão:
+	// pprof dentro do diretório temporário do sistema.
+	Dir string
+}
+
+// ProfilingConfigFromEnv lê as variáveis PPROF_*. Em caso de erro, devolve os
+// padrões para os valores inválidos.
+func ProfilingConfigFromEnv(getenv func(string) string) (ProfilingConfig, error) {
+	cfg := ProfilingConfig{
+		Addr:            getenv("PPROF_ADDR"),
+		CaptureDuration: defaultProfileCaptureDuration,
+		Cooldown:        defaultProfileCaptureCooldown,
+		Dir:             getenv("PPROF_CAPTURE_DIR"),
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = filepath.Join(os.TempDir(), "pprof")
+	}
+
+	var errs []error
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"PPROF_SLOW_THRESHOLD", &cfg.SlowThreshold},
+		{"PPROF_CAPTURE_DURATION", &cfg.CaptureDuration},
+		{"PPROF_CAPTURE_COOLDOWN", &cfg.Cooldown},
+	}
+	for _, d := range durations {
+		raw := getenv(d.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			errs = append(errs, fmt.Errorf("invalid %s %q: must be a duration such as 2s", d.name, raw))
+			continue
+		}
+		*d.value = parsed
+	}
+	if cfg.SlowThreshold > 0 && cfg.Addr == "" {
+		errs = append(errs, errors.New("PPROF_SLOW_THRESHOLD requires PPROF_ADDR: captured profiles are only served by the admin listener"))
+		cfg.SlowThreshold = 0
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+// Profiler marca as goroutines de cada requisição com labels de pprof e
+// captura perfis automaticamente quando uma requisição passa do limite.
+type Profiler struct {
+	cfg    ProfilingConfig
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	capturing bool
+	last      time.Time
+}
+
+func NewProfiler(cfg ProfilingConfig, logger *slog.Logger) *Profiler {
+	if cfg.CaptureDuration <= 0 {
+		cfg.CaptureDuration = defaultProfileCaptureDuration
+	}
+	// Sem o listener administrativo, ninguém consegue ler os perfis capturados
+	if cfg.Addr == "" {
+		cfg.SlowThreshold = 0
+	}
+	return &Profiler{cfg: cfg, logger: logger}
+}
+
+// Middleware aplica os labels http.route e trace_id à goroutine da
+// requisição, para que os perfis de CPU possam ser filtrados por endpoint
+// (ex.: go tool pprof -tagfocus http.route=/). Precisa ser registrado por
+// rota (chi: router.With), onde a rota já foi resolvida, e depois do
+// TracingMiddleware.
+func (p *Profiler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routePattern(r)
+		if route == "" {
+			route = "unknown"
+		}
+		labels := []string{"http.route", route}
+		span := trace.SpanFromContext(r.Context())
+		if sc := span.SpanContext(); sc.HasTraceID() {
+			labels = append(labels, "trace_id", sc.TraceID().String())
+		}
+
+		if p.cfg.SlowThreshold > 0 {
+			// Captura enquanto a requisição lenta ainda está em andamento
+			timer := time.AfterFunc(p.cfg.SlowThreshold, func() { p.capture(span, route) })
+			defer timer.Stop()
+		}
+
+		runtimepprof.Do(r.Context(), runtimepprof.Labels(labels...), func(ctx context.Context) {
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// capture grava os perfis de goroutines e heap na hora e o de CPU durante
+// CaptureDuration. Só uma captura roda por vez, respeitando Cooldown.
+func (p *Profiler) capture(span trace.Span, route string) {
+	p.mu.Lock()
+	if p.capturing || (!p.last.IsZero() && time.Since(p.last) < p.cfg.Cooldown) {
+		p.mu.Unlock()
+		return
+	}
+	p.capturing = true
+	p.last = time.Now()
+	p.mu.Unlock()
+
+	prefix := p.last.UTC().Format("20060102T150405") + "-" + unsafeFileChars.ReplaceAllString(route, "_")
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		prefix += "-" + sc.TraceID().String()
+	}
+
+	span.AddEvent("pprof.capture", trace.WithAttributes(
+		attribute.String("pprof.capture.prefix", prefix),
+		attribute.String("pprof.capture.threshold", p.cfg.SlowThreshold.String()),
+	))
+	p.logger.Info("request exceeded slow threshold, capturing profiles",
+		slog.String("http.route", route),
+		slog.String("pprof.capture.prefix", prefix),
+		slog.Duration("threshold", p.cfg.SlowThreshold),
+	)
+
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			p.capturing = false
+			p.mu.Unlock()
+		}()
+		if err := p.writeProfiles(prefix); err != nil {
+			p.logger.Warn("failed to capture profiles", slog.String("pprof.capture.prefix", prefix), slog.Any("error", err))
+		}
+	}()
+}
+
+func (p *Profiler) writeProfiles(prefix string) error {
+	if err := os.MkdirAll(p.cfg.Dir, 0o755); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, name := range []string{"goroutine", "heap"} {
+		errs = append(errs, p.writeFile(prefix+"-"+name+".pprof", func(f *os.File) error {
+			return runtimepprof.Lookup(name).WriteTo(f, 0)
+		}))
+	}
+	// Falha se já houver um perfil de CPU em andamento (ex.: via /debug/pprof/profile)
+	errs = append(errs, p.writeFile(prefix+"-cpu.pprof", func(f *os.File) error {
+		if err := runtimepprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+		time.Sleep(p.cfg.CaptureDuration)
+		runtimepprof.StopCPUProfile()
+		return nil
+	}))
+	return errors.Join(errs...)
+}
+
+func (p *Profiler) writeFile(name string, write func(*os.File) error) error {
+	f, err := os.Create(filepath.Join(p.cfg.Dir, name))
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	return f.Close()
+}
+
+// AdminHandler expõe o net/http/pprof em /debug/pprof/ e os perfis
+// capturados automaticamente em /debug/pprof/captures/.
+func (p *Profiler) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/pprof/captures/", http.StripPrefix("/debug/pprof/captures/", http.FileServer(http.Dir(p.cfg.Dir))))
+	return mux
+}
+
+// ServeAdmin atende o AdminHandler em Addr, fora do listener público, até ctx
+// ser cancelado. Sem Addr, retorna imediatamente.
+func (p *Profiler) ServeAdmin(ctx context.Context) error {
+	if p.cfg.Addr == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", p.cfg.Addr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: p.AdminHandler()}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	p.logger.Info("starting pprof admin server", slog.String("addr", listener.Addr().String()))
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
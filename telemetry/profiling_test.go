@@ -0,0 +1,158 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	runtimepprof "runtime/pprof"
+	"testing"
+	"time"
+
+	chiv5 "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestProfilingConfigFromEnv(t *testing.T) {
+	cfg, err := ProfilingConfigFromEnv(func(string) string { return "" })
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Addr)
+	assert.Zero(t, cfg.SlowThreshold)
+	assert.Equal(t, defaultProfileCaptureDuration, cfg.CaptureDuration)
+	assert.Equal(t, defaultProfileCaptureCooldown, cfg.Cooldown)
+	assert.Equal(t, filepath.Join(os.TempDir(), "pprof"), cfg.Dir)
+
+	env := map[string]string{"PPROF_ADDR": "localhost:6060", "PPROF_SLOW_THRESHOLD": "2s", "PPROF_CAPTURE_DURATION": "soon"}
+	cfg, err = ProfilingConfigFromEnv(func(key string) string { return env[key] })
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PPROF_CAPTURE_DURATION")
+	assert.Equal(t, "localhost:6060", cfg.Addr)
+	assert.Equal(t, 2*time.Second, cfg.SlowThreshold)
+	assert.Equal(t, defaultProfileCaptureDuration, cfg.CaptureDuration)
+
+	// Sem PPROF_ADDR os perfis capturados não teriam como ser lidos
+	env = map[string]string{"PPROF_SLOW_THRESHOLD": "2s"}
+	cfg, err = ProfilingConfigFromEnv(func(key string) string { return env[key] })
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PPROF_ADDR")
+	assert.Zero(t, cfg.SlowThreshold)
+}
+
+func TestProfiler_MiddlewareSetsLabels(t *testing.T) {
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+	profiler := NewProfiler(ProfilingConfig{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var route, traceID string
+	router := chiv5.NewRouter()
+	router.Use(TracingMiddleware(tracer))
+	router.With(profiler.Middleware).Get("/weather/{cep}", func(w http.ResponseWriter, r *http.Request) {
+		route, _ = runtimepprof.Label(r.Context(), "http.route")
+		traceID, _ = runtimepprof.Label(r.Context(), "trace_id")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/weather/01001000", nil))
+
+	assert.Equal(t, "/weather/{cep}", route)
+	assert.Len(t, traceID, 32)
+}
+
+func TestProfiler_CapturesSlowRequests(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	dir := t.TempDir()
+	profiler := NewProfiler(ProfilingConfig{
+		Addr:            "127.0.0.1:0",
+		SlowThreshold:   10 * time.Millisecond,
+		CaptureDuration: 50 * time.Millisecond,
+		Cooldown:        time.Hour,
+		Dir:             dir,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	router := chiv5.NewRouter()
+	router.Use(TracingMiddleware(tracer))
+	router.With(profiler.Middleware).Get("/", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	})
+
+	// A segunda requisição lenta cai no cooldown
+	for i := 0; i < 2; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	require.Eventually(t, func() bool {
+		files, _ := filepath.Glob(filepath.Join(dir, "*.pprof"))
+		return len(files) == 3
+	}, 2*time.Second, 10*time.Millisecond)
+	for _, kind := range []string{"cpu", "heap", "goroutine"} {
+		files, _ := filepath.Glob(filepath.Join(dir, "*-"+kind+".pprof"))
+		assert.Len(t, files, 1, kind)
+	}
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Len(t, spans[0].Events(), 1)
+	assert.Equal(t, "pprof.capture", spans[0].Events()[0].Name)
+	assert.Empty(t, spans[1].Events())
+}
+
+func TestProfiler_SkipsCaptureWithoutAdminListener(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	dir := t.TempDir()
+	profiler := NewProfiler(ProfilingConfig{
+		SlowThreshold:   10 * time.Millisecond,
+		CaptureDuration: 50 * time.Millisecond,
+		Dir:             dir,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	router := chiv5.NewRouter()
+	router.Use(TracingMiddleware(tracer))
+	router.With(profiler.Middleware).Get("/", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pprof"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Empty(t, spans[0].Events())
+}
+
+func TestProfiler_AdminHandler(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "capture-cpu.pprof"), []byte("profile"), 0o644))
+	profiler := NewProfiler(ProfilingConfig{Dir: dir}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	rec := httptest.NewRecorder()
+	profiler.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine")
+
+	rec = httptest.NewRecorder()
+	profiler.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/captures/capture-cpu.pprof", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "profile", rec.Body.String())
+}
+
+func TestProfiler_ServeAdminStopsWithContext(t *testing.T) {
+	profiler := NewProfiler(ProfilingConfig{Addr: "127.0.0.1:0"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- profiler.ServeAdmin(ctx) }()
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("admin server did not stop")
+	}
+}
@@ -0,0 +1,28 @@
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// NewPrometheusExporter cria um reader que expõe as métricas do MeterProvider
+// no formato Prometheus e o handler HTTP que deve ser servido em /metrics.
+// O handler negocia OpenMetrics para que os exemplars (trace_id) sejam enviados.
+func NewPrometheusExporter() (sdkmetric.Reader, http.Handler, error) {
+	registry := prometheus.NewRegistry()
+
+	exporter, err := otelprom.New(otelprom.WithRegisterer(registry))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+
+	return exporter, handler, nil
+}
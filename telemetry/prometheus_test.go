@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	chiv5 "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPrometheusExporter_ServesExemplarsWithTraceID(t *testing.T) {
+	reader, handler, err := NewPrometheusExporter()
+	require.NoError(t, err)
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithExemplarFilter(exemplar.TraceBasedFilter),
+	)
+	metrics, err := NewMetrics(provider.Meter("test"))
+	require.NoError(t, err)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	metrics.RecordRequest(ctx, http.MethodPost, "/", http.StatusOK, 120*time.Millisecond)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	body, _ := io.ReadAll(w.Body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, string(body), "http_server_request_duration_seconds_bucket")
+	assert.Contains(t, string(body), `trace_id="4bf92f3577b34da6a3ce929d0e0e4736"`)
+}
+
+func TestPrometheusExporter_MiddlewareExemplar(t *testing.T) {
+	reader, handler, err := NewPrometheusExporter()
+	if err != nil {
+		t.Fatalf("Failed to create prometheus exporter: %v", err)
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithExemplarFilter(exemplar.TraceBasedFilter),
+	)
+	metrics, err := NewMetrics(provider.Meter("test"))
+	if err != nil {
+		t.Fatalf("Failed to create metrics: %v", err)
+	}
+
+	traceID, _ := trace.TraceIDFromHex("0af7651916cd43dd8448eb211c80319c")
+	spanID, _ := trace.SpanIDFromHex("b7ad6b7169203331")
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	router := chiv5.NewRouter()
+	router.Use(MetricsMiddleware(metrics))
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		MarkRequestSpan(trace.ContextWithSpanContext(r.Context(), spanContext))
+		w.WriteHeader(http.StatusOK)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?cep=01153000", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `http_server_requests_total{`) {
+		t.Errorf("Expected request counter in output, got:\n%s", body)
+	}
+	if !strings.Contains(body, `trace_id="0af7651916cd43dd8448eb211c80319c"`) {
+		t.Errorf("Expected exemplar with trace_id in output, got:\n%s", body)
+	}
+}
@@ -0,0 +1,169 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PropagatorsFromEnv monta o propagador composto a partir da lista separada por
+// vírgulas de OTEL_PROPAGATORS: tracecontext, baggage, b3, b3multi, jaeger,
+// xcloudtrace e none. O padrão é tracecontext,baggage. Na extração, o último
+// propagador da lista que encontrar um contexto válido prevalece.
+func PropagatorsFromEnv(names string) (propagation.TextMapPropagator, error) {
+	if strings.TrimSpace(names) == "" {
+		names = "tracecontext,baggage"
+	}
+
+	var propagators []propagation.TextMapPropagator
+	for _, name := range strings.Split(names, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "none", "":
+			continue
+		case "tracecontext":
+			propagators = append(propagators, propagation.TraceContext{})
+		case "baggage":
+			propagators = append(propagators, propagation.Baggage{})
+		case "b3":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case "b3multi":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case "jaeger":
+			propagators = append(propagators, jaeger.Jaeger{})
+		case "xcloudtrace":
+			propagators = append(propagators, CloudTraceContext{})
+		default:
+			return nil, fmt.Errorf("unsupported OTEL_PROPAGATORS %q", name)
+		}
+	}
+
+	return propagation.NewCompositeTextMapPropagator(propagators...), nil
+}
+
+const cloudTraceHeader = "X-Cloud-Trace-Context"
+
+// CloudTraceContext propaga o header X-Cloud-Trace-Context enviado pelo Cloud
+// Run e pelo load balancer do Google Cloud, no formato
+// TRACE_ID/SPAN_ID;o=OPTIONS, com o span ID em decimal.
+type CloudTraceContext struct{}
+
+func (CloudTraceContext) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	spanID := sc.SpanID()
+	sampled := 0
+	if sc.IsSampled() {
+		sampled = 1
+	}
+	carrier.Set(cloudTraceHeader, fmt.Sprintf("%s/%d;o=%d", sc.TraceID(), binary.BigEndian.Uint64(spanID[:]), sampled))
+}
+
+func (CloudTraceContext) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	header := carrier.Get(cloudTraceHeader)
+	if header == "" {
+		return ctx
+	}
+
+	traceHex, rest, found := strings.Cut(header, "/")
+	if !found {
+		return ctx
+	}
+	spanDecimal, options, _ := strings.Cut(rest, ";")
+
+	traceID, err := trace.TraceIDFromHex(traceHex)
+	if err != nil {
+		return ctx
+	}
+	decimal, err := strconv.ParseUint(spanDecimal, 10, 64)
+	if err != nil || decimal == 0 {
+		return ctx
+	}
+	var spanID trace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], decimal)
+
+	var flags trace.TraceFlags
+	if options == "o=1" {
+		flags = trace.FlagsSampled
+	}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	})
+	return trace.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+func (CloudTraceContext) Fields() []string {
+	return []string{cloudTraceHeader}
+}
+
+// defaultBaggageKeys são as entradas de baggage copiadas quando
+// OTEL_BAGGAGE_SPAN_ATTRIBUTES não é definida.
+const defaultBaggageKeys = "client.app"
+
+// BaggageKeysFromEnv lê a lista separada por vírgulas de
+// OTEL_BAGGAGE_SPAN_ATTRIBUTES com as chaves de baggage que viram atributos
+// dos spans. O padrão é client.app; none desliga a cópia.
+func BaggageKeysFromEnv(list string) []string {
+	if strings.TrimSpace(list) == "" {
+		list = defaultBaggageKeys
+	}
+
+	var keys []string
+	for _, key := range strings.Split(list, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" || strings.EqualFold(key, "none") {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+type baggageSpanProcessor struct {
+	keys map[string]bool
+}
+
+// NewBaggageSpanProcessor copia as entradas de baggage do contexto cujas
+// chaves estão em keys (ex.: client.app) como atributos de cada span
+// iniciado. As demais são ignoradas: o header baggage vem do cliente e não
+// deve decidir quais atributos os spans recebem.
+func NewBaggageSpanProcessor(keys ...string) sdktrace.SpanProcessor {
+	allowed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		allowed[key] = true
+	}
+	return baggageSpanProcessor{keys: allowed}
+}
+
+func (p baggageSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if len(p.keys) == 0 {
+		return
+	}
+	for _, member := range baggage.FromContext(parent).Members() {
+		if p.keys[member.Key()] {
+			s.SetAttributes(attribute.String(member.Key(), member.Value()))
+		}
+	}
+}
+
+func (baggageSpanProcessor) OnEnd(sdktrace.ReadOnlySpan) {}
+
+func (baggageSpanProcessor) Shutdown(context.Context) error { return nil }
+
+func (baggageSpanProcessor) ForceFlush(context.Context) error { return nil }
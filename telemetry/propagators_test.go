@@ -0,0 +1,183 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPropagatorsFromEnv_Default(t *testing.T) {
+	propagator, err := PropagatorsFromEnv("")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, propagator.Fields())
+}
+
+func TestPropagatorsFromEnv_Headers(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+	}{
+		{"b3", "b3"},
+		{"b3multi", "x-b3-traceid"},
+		{"jaeger", "uber-trace-id"},
+		{"xcloudtrace", "X-Cloud-Trace-Context"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			propagator, err := PropagatorsFromEnv("tracecontext," + tc.name)
+			require.NoError(t, err)
+			assert.Contains(t, propagator.Fields(), tc.header)
+		})
+	}
+}
+
+func TestPropagatorsFromEnv_Invalid(t *testing.T) {
+	_, err := PropagatorsFromEnv("tracecontext,xray")
+	assert.Error(t, err)
+
+	_, err = PropagatorsFromEnv("tracecontext,ottrace")
+	assert.Error(t, err)
+}
+
+func TestCloudTraceContext_Extract(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+
+	ctx := CloudTraceContext{}.Extract(context.Background(), propagation.HeaderCarrier(header))
+	sc := trace.SpanContextFromContext(ctx)
+
+	require.True(t, sc.IsValid())
+	assert.True(t, sc.IsRemote())
+	assert.True(t, sc.IsSampled())
+	assert.Equal(t, "105445aa7843bc8bf206b12000100000", sc.TraceID().String())
+	assert.Equal(t, "0000000000000001", sc.SpanID().String())
+
+	out := http.Header{}
+	CloudTraceContext{}.Inject(ctx, propagation.HeaderCarrier(out))
+	assert.Equal(t, "105445aa7843bc8bf206b12000100000/1;o=1", out.Get("X-Cloud-Trace-Context"))
+}
+
+func TestCloudTraceContext_ExtractInvalid(t *testing.T) {
+	for _, value := range []string{"invalid", "105445aa7843bc8bf206b12000100000/0;o=1", "xyz/1;o=1"} {
+		header := http.Header{}
+		header.Set("X-Cloud-Trace-Context", value)
+
+		ctx := CloudTraceContext{}.Extract(context.Background(), propagation.HeaderCarrier(header))
+		assert.False(t, trace.SpanContextFromContext(ctx).IsValid(), value)
+	}
+}
+
+func TestBaggageSpanProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(NewBaggageSpanProcessor("client.app")),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+
+	member, err := baggage.NewMember("client.app", "mobile")
+	require.NoError(t, err)
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+
+	_, span := provider.Tracer("test").Start(baggage.ContextWithBaggage(context.Background(), bag), "call_service_b")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("client.app", "mobile"))
+}
+
+// Chaves fora da lista não viram atributos, mesmo vindas no header.
+func TestBaggageSpanProcessor_IgnoresKeysOutsideAllowlist(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(NewBaggageSpanProcessor("client.app")),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+
+	bag, err := baggage.Parse("client.app=mobile,user.email=ana@example.com")
+	require.NoError(t, err)
+
+	_, span := provider.Tracer("test").Start(baggage.ContextWithBaggage(context.Background(), bag), "call_service_b")
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("client.app", "mobile")}, spans[0].Attributes())
+}
+
+func TestBaggageKeysFromEnv(t *testing.T) {
+	assert.Equal(t, []string{"client.app"}, BaggageKeysFromEnv(""))
+	assert.Equal(t, []string{"client.app", "tenant.id"}, BaggageKeysFromEnv(" client.app , tenant.id,"))
+	assert.Empty(t, BaggageKeysFromEnv("none"))
+}
+
+// O Cloud Run envia X-Cloud-Trace-Context; o span do Serviço B deve continuar
+// esse trace.
+func TestPropagatorsFromEnv_CloudTraceContext(t *testing.T) {
+	propagator, err := PropagatorsFromEnv("tracecontext,baggage,xcloudtrace")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/?cep=01153000", nil)
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/12345;o=1")
+
+	ctx := propagator.Extract(context.Background(), propagation.HeaderCarrier(req.Header))
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.IsSampled() {
+		t.Fatalf("Expected a sampled remote span context, got %v", sc)
+	}
+	if sc.TraceID().String() != "105445aa7843bc8bf206b12000100000" {
+		t.Errorf("Expected trace ID from X-Cloud-Trace-Context, got %s", sc.TraceID())
+	}
+	if sc.SpanID().String() != "0000000000003039" {
+		t.Errorf("Expected decimal span ID 12345 converted to hex, got %s", sc.SpanID())
+	}
+}
+
+// Entradas de baggage repassadas pelo Serviço A viram atributos dos spans.
+func TestBaggageSpanProcessor_FromHeader(t *testing.T) {
+	propagator, err := PropagatorsFromEnv("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(NewBaggageSpanProcessor(BaggageKeysFromEnv("")...)),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/?cep=01153000", nil)
+	req.Header.Set("baggage", "client.app=mobile")
+
+	ctx := propagator.Extract(context.Background(), propagation.HeaderCarrier(req.Header))
+	_, span := provider.Tracer("test").Start(ctx, "get_weather")
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	found := false
+	for _, attr := range spans[0].Attributes() {
+		if attr == attribute.String("client.app", "mobile") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected client.app attribute, got %v", spans[0].Attributes())
+	}
+}
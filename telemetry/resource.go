@@ -0,0 +1,159 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Preenchidos via -ldflags no build das imagens Docker, onde o diretório .git
+// não está disponível para o debug.ReadBuildInfo.
+var (
+	buildVersion  string
+	buildRevision string
+)
+
+// BuildInfo identifica o binário em execução.
+type BuildInfo struct {
+	Version   string
+	Revision  string
+	Time      string
+	Modified  bool
+	GoVersion string
+}
+
+// ReadBuildInfo combina debug.ReadBuildInfo com os valores injetados via
+// -ldflags. Sem nenhum dos dois, a versão é "dev".
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{Version: "dev", GoVersion: runtime.Version()}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Revision = setting.Value
+			case "vcs.time":
+				info.Time = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+
+	if buildVersion != "" {
+		info.Version = buildVersion
+	}
+	if buildRevision != "" {
+		info.Revision = buildRevision
+	}
+	return info
+}
+
+type buildInfoDetector struct {
+	info BuildInfo
+}
+
+func (d buildInfoDetector) Detect(context.Context) (*resource.Resource, error) {
+	attrs := []attribute.KeyValue{semconv.ServiceVersion(d.info.Version)}
+	if d.info.Revision != "" {
+		attrs = append(attrs, semconv.VCSRefHeadRevision(d.info.Revision))
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...), nil
+}
+
+// cloudRunDetector lê as variáveis que o Cloud Run define em cada container.
+type cloudRunDetector struct {
+	getenv func(string) string
+}
+
+func (d cloudRunDetector) Detect(context.Context) (*resource.Resource, error) {
+	service := d.getenv("K_SERVICE")
+	if service == "" {
+		return resource.Empty(), nil
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.CloudProviderGCP,
+		semconv.CloudPlatformGCPCloudRun,
+		semconv.FaaSName(service),
+	}
+	if revision := d.getenv("K_REVISION"); revision != "" {
+		attrs = append(attrs, semconv.FaaSVersion(revision))
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...), nil
+}
+
+// newResource monta o resource com build, host, processo, container e Cloud
+// Run. Em caso de conflito, OTEL_RESOURCE_ATTRIBUTES e OTEL_SERVICE_NAME
+// prevalecem sobre o que foi detectado. Uma detecção parcial devolve o
+// resource junto com o erro.
+func newResource(ctx context.Context, cfg Config, getenv func(string) string) (*resource.Resource, error) {
+	var environment []attribute.KeyValue
+	if cfg.Environment != "" {
+		environment = append(environment, semconv.DeploymentEnvironmentName(cfg.Environment))
+	}
+
+	return resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithProcessPID(),
+		resource.WithProcessExecutableName(),
+		resource.WithProcessRuntimeName(),
+		resource.WithProcessRuntimeVersion(),
+		resource.WithContainer(),
+		resource.WithDetectors(buildInfoDetector{info: ReadBuildInfo()}, cloudRunDetector{getenv: getenv}),
+		resource.WithAttributes(environment...),
+		resource.WithAttributes(cfg.ResourceAttributes...),
+		resource.WithAttributes(semconv.ServiceName(cfg.ServiceName)),
+	)
+}
+
+type versionResponse struct {
+	Service   string            `json:"service"`
+	Version   string            `json:"version"`
+	Revision  string            `json:"revision,omitempty"`
+	BuildTime string            `json:"build_time,omitempty"`
+	Modified  bool              `json:"modified"`
+	GoVersion string            `json:"go_version"`
+	Resource  map[string]string `json:"resource"`
+}
+
+// VersionHandler expõe em /version os mesmos dados do resource anexado aos
+// spans, para correlacionar uma regressão com o deploy que a introduziu.
+func VersionHandler(res *resource.Resource) http.Handler {
+	build := ReadBuildInfo()
+	body := versionResponse{
+		Version:   build.Version,
+		Revision:  build.Revision,
+		BuildTime: build.Time,
+		Modified:  build.Modified,
+		GoVersion: build.GoVersion,
+		Resource:  make(map[string]string),
+	}
+
+	for _, attr := range res.Attributes() {
+		body.Resource[string(attr.Key)] = attr.Value.Emit()
+		switch attr.Key {
+		case semconv.ServiceNameKey:
+			body.Service = attr.Value.AsString()
+		case semconv.ServiceVersionKey:
+			body.Version = attr.Value.AsString()
+		case semconv.VCSRefHeadRevisionKey:
+			body.Revision = attr.Value.AsString()
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	})
+}
@@ -0,0 +1,87 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func resourceValue(res *resource.Resource, key string) string {
+	value, ok := res.Set().Value(attribute.Key(key))
+	if !ok {
+		return ""
+	}
+	return value.Emit()
+}
+
+func TestNewResource_DetectsDeployAttributes(t *testing.T) {
+	env := map[string]string{"K_SERVICE": "service-b", "K_REVISION": "service-b-00042-xyz"}
+
+	res, err := newResource(context.Background(), Config{
+		ServiceName: "ServiceB",
+		Environment: "staging",
+	}, func(key string) string { return env[key] })
+	require.NoError(t, err)
+
+	assert.Equal(t, "ServiceB", resourceValue(res, "service.name"))
+	assert.NotEmpty(t, resourceValue(res, "service.version"))
+	assert.Equal(t, "staging", resourceValue(res, "deployment.environment.name"))
+	assert.NotEmpty(t, resourceValue(res, "host.name"))
+	assert.NotEmpty(t, resourceValue(res, "process.pid"))
+	assert.Equal(t, "gcp_cloud_run", resourceValue(res, "cloud.platform"))
+	assert.Equal(t, "service-b", resourceValue(res, "faas.name"))
+	assert.Equal(t, "service-b-00042-xyz", resourceValue(res, "faas.version"))
+}
+
+// Atributos de OTEL_RESOURCE_ATTRIBUTES prevalecem sobre os detectados.
+func TestNewResource_EnvironmentOverridesDetection(t *testing.T) {
+	res, err := newResource(context.Background(), Config{
+		ServiceName:        "ServiceA",
+		ResourceAttributes: []attribute.KeyValue{attribute.String("service.version", "1.4.2")},
+	}, func(string) string { return "" })
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.4.2", resourceValue(res, "service.version"))
+	assert.Empty(t, resourceValue(res, "cloud.platform"))
+}
+
+func TestReadBuildInfo_LinkerOverrides(t *testing.T) {
+	defer func(version, revision string) { buildVersion, buildRevision = version, revision }(buildVersion, buildRevision)
+	buildVersion, buildRevision = "v1.2.0", "9f3c2a1"
+
+	info := ReadBuildInfo()
+	assert.Equal(t, "v1.2.0", info.Version)
+	assert.Equal(t, "9f3c2a1", info.Revision)
+	assert.NotEmpty(t, info.GoVersion)
+}
+
+func TestVersionHandler(t *testing.T) {
+	res, err := newResource(context.Background(), Config{
+		ServiceName:        "ServiceA",
+		Environment:        "prod",
+		ResourceAttributes: []attribute.KeyValue{attribute.String("service.version", "1.4.2"), attribute.String("vcs.ref.head.revision", "abc123")},
+	}, func(string) string { return "" })
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	VersionHandler(res).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body versionResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "ServiceA", body.Service)
+	assert.Equal(t, "1.4.2", body.Version)
+	assert.Equal(t, "abc123", body.Revision)
+	assert.NotEmpty(t, body.GoVersion)
+	assert.Equal(t, "prod", body.Resource["deployment.environment.name"])
+	assert.Contains(t, body.Resource, "host.name")
+}
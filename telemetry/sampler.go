@@ -0,0 +1,89 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// DebugTraceHeader força a amostragem de uma requisição, independente do
+// sampler configurado. O Serviço A repassa o header ao Serviço B.
+const DebugTraceHeader = "X-Debug-Trace"
+
+type forceSampleKey struct{}
+
+func WithForceSample(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceSampleKey{}, true)
+}
+
+func ForceSampled(ctx context.Context) bool {
+	forced, _ := ctx.Value(forceSampleKey{}).(bool)
+	return forced
+}
+
+// DebugTraceMiddleware marca o contexto da requisição para amostragem forçada
+// quando o header X-Debug-Trace estiver presente.
+func DebugTraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.ToLower(r.Header.Get(DebugTraceHeader)) {
+		case "1", "true":
+			r = r.WithContext(WithForceSample(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type forceSampler struct {
+	base sdktrace.Sampler
+}
+
+// NewForceSampler decora base para sempre amostrar spans iniciados em um
+// contexto marcado por WithForceSample.
+func NewForceSampler(base sdktrace.Sampler) sdktrace.Sampler {
+	return forceSampler{base: base}
+}
+
+func (s forceSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if p.ParentContext != nil && ForceSampled(p.ParentContext) {
+		return sdktrace.AlwaysSample().ShouldSample(p)
+	}
+	return s.base.ShouldSample(p)
+}
+
+func (s forceSampler) Description() string {
+	return fmt.Sprintf("ForceSampler{%s}", s.base.Description())
+}
+
+// SamplerFromEnv interpreta OTEL_TRACES_SAMPLER e OTEL_TRACES_SAMPLER_ARG
+// conforme a especificação do OpenTelemetry. O padrão é parentbased_always_on.
+func SamplerFromEnv(name, arg string) (sdktrace.Sampler, error) {
+	ratio := 1.0
+	if arg != "" {
+		parsed, err := strconv.ParseFloat(arg, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return nil, fmt.Errorf("invalid OTEL_TRACES_SAMPLER_ARG %q: must be a number between 0 and 1", arg)
+		}
+		ratio = parsed
+	}
+
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "always_on":
+		return sdktrace.AlwaysSample(), nil
+	case "always_off":
+		return sdktrace.NeverSample(), nil
+	case "traceidratio":
+		return sdktrace.TraceIDRatioBased(ratio), nil
+	case "", "parentbased_always_on":
+		return sdktrace.ParentBased(sdktrace.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return sdktrace.ParentBased(sdktrace.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+	default:
+		return nil, fmt.Errorf("unsupported OTEL_TRACES_SAMPLER %q", name)
+	}
+}
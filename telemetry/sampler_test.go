@@ -0,0 +1,146 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSamplerFromEnv(t *testing.T) {
+	testCases := []struct {
+		name        string
+		sampler     string
+		arg         string
+		description string
+	}{
+		{"Default", "", "", "ParentBased{root:AlwaysOnSampler"},
+		{"Always on", "always_on", "", "AlwaysOnSampler"},
+		{"Always off", "always_off", "", "AlwaysOffSampler"},
+		{"Ratio", "traceidratio", "0.25", "TraceIDRatioBased{0.25}"},
+		{"Parent based ratio", "parentbased_traceidratio", "0.1", "ParentBased{root:TraceIDRatioBased{0.1}"},
+		{"Parent based off", "parentbased_always_off", "", "ParentBased{root:AlwaysOffSampler"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sampler, err := SamplerFromEnv(tc.sampler, tc.arg)
+			require.NoError(t, err)
+			assert.Contains(t, sampler.Description(), tc.description)
+		})
+	}
+}
+
+func TestSamplerFromEnv_Invalid(t *testing.T) {
+	_, err := SamplerFromEnv("probabilistic", "")
+	assert.Error(t, err)
+
+	_, err = SamplerFromEnv("traceidratio", "1.5")
+	assert.Error(t, err)
+
+	_, err = SamplerFromEnv("traceidratio", "abc")
+	assert.Error(t, err)
+}
+
+func TestForceSampler_OverridesBaseSampler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(NewForceSampler(sdktrace.NeverSample())),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	tracer := provider.Tracer("test")
+
+	_, dropped := tracer.Start(context.Background(), "not forced")
+	dropped.End()
+
+	_, forced := tracer.Start(WithForceSample(context.Background()), "forced")
+	forced.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "forced", spans[0].Name())
+	assert.True(t, spans[0].SpanContext().IsSampled())
+}
+
+func TestDebugTraceMiddleware(t *testing.T) {
+	var forced bool
+	handler := DebugTraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forced = ForceSampled(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.False(t, forced)
+
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(DebugTraceHeader, "1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, forced)
+}
+
+func TestSamplerFromEnv_Names(t *testing.T) {
+	valid := []string{"", "always_on", "always_off", "traceidratio", "parentbased_always_on", "parentbased_always_off", "parentbased_traceidratio", "PARENTBASED_TRACEIDRATIO"}
+	for _, name := range valid {
+		if _, err := SamplerFromEnv(name, "0.5"); err != nil {
+			t.Errorf("Expected sampler %q to be valid, got %v", name, err)
+		}
+	}
+
+	if _, err := SamplerFromEnv("jaeger_remote", ""); err == nil {
+		t.Error("Expected unsupported sampler to return an error")
+	}
+	if _, err := SamplerFromEnv("traceidratio", "-0.1"); err == nil {
+		t.Error("Expected negative ratio to return an error")
+	}
+}
+
+// Um trace não amostrado pelo Serviço A deve ser amostrado pelo Serviço B
+// quando o header X-Debug-Trace é repassado.
+func TestForceSampler_DebugHeaderOverridesUnsampledParent(t *testing.T) {
+	sampler, err := SamplerFromEnv("parentbased_always_on", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(NewForceSampler(sampler)),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	tracer := provider.Tracer("test")
+
+	handler := DebugTraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagation.TraceContext{}.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		_, span := tracer.Start(ctx, "GET /")
+		span.End()
+	}))
+
+	unsampledParent := "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00"
+
+	req := httptest.NewRequest(http.MethodGet, "/?cep=01153000", nil)
+	req.Header.Set("traceparent", unsampledParent)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(recorder.Ended()) != 0 {
+		t.Fatalf("Expected unsampled parent to be respected, got %d spans", len(recorder.Ended()))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/?cep=01153000", nil)
+	req.Header.Set("traceparent", unsampledParent)
+	req.Header.Set(DebugTraceHeader, "1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected forced span to be recorded, got %d spans", len(spans))
+	}
+	if spans[0].SpanContext().TraceID().String() != "0af7651916cd43dd8448eb211c80319c" {
+		t.Errorf("Expected forced span to keep the propagated trace ID, got %s", spans[0].SpanContext().TraceID())
+	}
+}
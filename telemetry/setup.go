@@ -0,0 +1,277 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/runtime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/log/global"
+	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// instrumentationName identifica as métricas criadas pelo próprio pacote
+// (spool e tail sampling).
+const instrumentationName = "github.com/adalbertofjr/lab-2-observabilidade-e-opentelemetry/telemetry"
+
+const defaultShutdownTimeout = 5 * time.Second
+
+// Config reúne tudo o que Setup precisa para montar os providers.
+type Config struct {
+	// ServiceName é OTEL_SERVICE_NAME, com o nome do serviço como padrão.
+	ServiceName string
+	// ResourceAttributes vem de OTEL_RESOURCE_ATTRIBUTES (ex.:
+	// deployment.environment.name=prod).
+	ResourceAttributes []attribute.KeyValue
+	// Environment é DEPLOYMENT_ENVIRONMENT (ex.: local, staging, prod).
+	Environment string
+	// Resource é montado por ConfigFromEnv com os atributos detectados. Se
+	// nil, Setup o monta a partir dos campos acima.
+	Resource *resource.Resource
+	// LogsEnabled é OTEL_LOGS_EXPORTER=otlp.
+	LogsEnabled bool
+	Sampler     sdktrace.Sampler
+	Propagator  propagation.TextMapPropagator
+	// BaggageKeys vem de OTEL_BAGGAGE_SPAN_ATTRIBUTES: as chaves de baggage
+	// copiadas como atributos dos spans.
+	BaggageKeys []string
+	// TailSampling só é definido com TAIL_SAMPLING_ENABLED=true.
+	TailSampling *TailSamplingConfig
+	Exporter     ExporterConfig
+	// DebugTraces só é definido com DEBUG_TRACES_ENABLED=true; o serviço
+	// expõe DebugTraces.Handler() em /debug/traces.
+	DebugTraces *DebugTraceProcessor
+	// ShutdownTimeout é TELEMETRY_SHUTDOWN_TIMEOUT: o prazo para descarregar
+	// os sinais pendentes no shutdown. Padrão: 5s.
+	ShutdownTimeout time.Duration
+}
+
+// ConfigFromEnv lê as variáveis OTEL_*, TAIL_SAMPLING_*, DEBUG_TRACES_* e TELEMETRY_SPOOL_*
+// usando getenv (ex.: os.Getenv). Valores inválidos são substituídos pelos
+// padrões e reportados juntos no erro, para que o serviço suba mesmo assim.
+func ConfigFromEnv(serviceName string, getenv func(string) string) (Config, error) {
+	var errs []error
+
+	cfg := Config{
+		ServiceName:     serviceName,
+		Environment:     getenv("DEPLOYMENT_ENVIRONMENT"),
+		LogsEnabled:     strings.EqualFold(getenv("OTEL_LOGS_EXPORTER"), "otlp"),
+		ShutdownTimeout: defaultShutdownTimeout,
+	}
+
+	if timeout := getenv("TELEMETRY_SHUTDOWN_TIMEOUT"); timeout != "" {
+		parsed, err := time.ParseDuration(timeout)
+		if err != nil || parsed <= 0 {
+			errs = append(errs, fmt.Errorf("invalid TELEMETRY_SHUTDOWN_TIMEOUT %q: must be a positive duration such as 5s", timeout))
+		} else {
+			cfg.ShutdownTimeout = parsed
+		}
+	}
+
+	if list := getenv("OTEL_RESOURCE_ATTRIBUTES"); list != "" {
+		attrs, err := parseKeyValues(list)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid OTEL_RESOURCE_ATTRIBUTES: %w", err))
+		}
+		for key, value := range attrs {
+			if key == string(semconv.ServiceNameKey) {
+				cfg.ServiceName = value
+				continue
+			}
+			cfg.ResourceAttributes = append(cfg.ResourceAttributes, attribute.String(key, value))
+		}
+	}
+	if name := getenv("OTEL_SERVICE_NAME"); name != "" {
+		cfg.ServiceName = name
+	}
+
+	sampler, err := SamplerFromEnv(getenv("OTEL_TRACES_SAMPLER"), getenv("OTEL_TRACES_SAMPLER_ARG"))
+	if err != nil {
+		errs = append(errs, err)
+		sampler = sdktrace.ParentBased(sdktrace.AlwaysSample())
+	}
+	cfg.Sampler = sampler
+
+	propagator, err := PropagatorsFromEnv(getenv("OTEL_PROPAGATORS"))
+	if err != nil {
+		errs = append(errs, err)
+		propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+	cfg.Propagator = propagator
+	cfg.BaggageKeys = BaggageKeysFromEnv(getenv("OTEL_BAGGAGE_SPAN_ATTRIBUTES"))
+
+	if enabled, _ := strconv.ParseBool(getenv("TAIL_SAMPLING_ENABLED")); enabled {
+		tailSampling, err := TailSamplingConfigFromEnv(getenv("TAIL_SAMPLING_RATIO"), getenv("TAIL_SAMPLING_LATENCY_THRESHOLD"), getenv("TAIL_SAMPLING_MAX_TRACES"))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		cfg.TailSampling = &tailSampling
+	}
+
+	debugTraces, err := DebugTraceProcessorFromEnv(getenv("DEBUG_TRACES_ENABLED"), getenv("DEBUG_TRACES_MAX"))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.DebugTraces = debugTraces
+
+	exporter, err := ExporterConfigFromEnv(getenv)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.Exporter = exporter
+
+	// Uma detecção parcial (ex.: sem acesso ao cgroup) ainda gera o resource
+	res, err := newResource(context.Background(), cfg, getenv)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to detect resource attributes: %w", err))
+	}
+	cfg.Resource = res
+
+	return cfg, errors.Join(errs...)
+}
+
+// Setup registra os providers globais de traces, métricas e logs e o
+// propagador, e devolve uma única função de shutdown que descarrega todos
+// eles, além do handler do endpoint /metrics. O shutdown respeita
+// ShutdownTimeout mesmo que ctx já tenha sido cancelado pelo sinal de parada.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, http.Handler, error) {
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	var shutdowns []func(context.Context) error
+	shutdown := func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.ShutdownTimeout)
+		defer cancel()
+
+		var errs []error
+		for _, fn := range shutdowns {
+			errs = append(errs, fn(ctx))
+		}
+		return errors.Join(errs...)
+	}
+	// Em caso de erro, encerra o que já foi criado
+	fail := func(err error) (func(context.Context) error, http.Handler, error) {
+		return nil, nil, errors.Join(err, shutdown(context.Background()))
+	}
+
+	res := cfg.Resource
+	if res == nil {
+		var err error
+		res, err = newResource(ctx, cfg, os.Getenv)
+		if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+			return nil, nil, fmt.Errorf("Failed to create Resource: %w", err)
+		}
+	}
+
+	if cfg.Propagator != nil {
+		otel.SetTextMapPropagator(cfg.Propagator)
+	}
+	sampler := cfg.Sampler
+	if sampler == nil {
+		sampler = sdktrace.ParentBased(sdktrace.AlwaysSample())
+	}
+	meter := otel.Meter(instrumentationName)
+	if cfg.Exporter.Spool != nil && cfg.Exporter.Spool.Meter == nil {
+		spool := *cfg.Exporter.Spool
+		spool.Meter = meter
+		cfg.Exporter.Spool = &spool
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	spanExporters, err := NewSpanExporters(ctx, cfg.Exporter)
+	if err != nil {
+		return fail(fmt.Errorf("Failed to create the trace exporters: %w", err))
+	}
+
+	tracerOptions := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(NewForceSampler(sampler)),
+		sdktrace.WithResource(res),
+		sdktrace.WithSpanProcessor(NewBaggageSpanProcessor(cfg.BaggageKeys...)),
+	}
+	// Antes do tail sampling: o visualizador mostra todos os traces amostrados
+	if cfg.DebugTraces != nil {
+		tracerOptions = append(tracerOptions, sdktrace.WithSpanProcessor(cfg.DebugTraces))
+	}
+	var processors []sdktrace.SpanProcessor
+	for _, exporter := range spanExporters {
+		processors = append(processors, sdktrace.NewBatchSpanProcessor(exporter))
+	}
+	if cfg.TailSampling != nil {
+		tailProcessor, err := NewTailSamplingProcessor(*cfg.TailSampling, meter, processors...)
+		if err != nil {
+			// Os BatchSpanProcessors já criados encerram os exporters
+			for _, processor := range processors {
+				shutdowns = append(shutdowns, processor.Shutdown)
+			}
+			return fail(fmt.Errorf("Failed to create the tail sampling processor: %w", err))
+		}
+		processors = []sdktrace.SpanProcessor{tailProcessor}
+	}
+	for _, processor := range processors {
+		tracerOptions = append(tracerOptions, sdktrace.WithSpanProcessor(processor))
+	}
+	tracerProvider := sdktrace.NewTracerProvider(tracerOptions...)
+	shutdowns = append(shutdowns, tracerProvider.Shutdown)
+
+	otel.SetTracerProvider(tracerProvider)
+
+	prometheusReader, metricsHandler, err := NewPrometheusExporter()
+	if err != nil {
+		return fail(fmt.Errorf("Failed to create the prometheus exporter: %w", err))
+	}
+
+	meterOptions := []sdkmetric.Option{
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(prometheusReader),
+		sdkmetric.WithExemplarFilter(exemplar.TraceBasedFilter),
+	}
+	// Com OTEL_METRICS_EXPORTER=none só o /metrics fica ativo
+	if cfg.Exporter.OTLPMetrics() {
+		metricExporter, err := NewMetricExporter(ctx, cfg.Exporter)
+		if err != nil {
+			return fail(fmt.Errorf("Failed to create the collector metric exporter: %w", err))
+		}
+		meterOptions = append(meterOptions, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)))
+	}
+	meterProvider := sdkmetric.NewMeterProvider(meterOptions...)
+	shutdowns = append(shutdowns, meterProvider.Shutdown)
+
+	otel.SetMeterProvider(meterProvider)
+
+	if err := runtime.Start(runtime.WithMeterProvider(meterProvider)); err != nil {
+		return fail(fmt.Errorf("Failed to start runtime instrumentation: %w", err))
+	}
+
+	if cfg.LogsEnabled {
+		logExporter, err := NewLogExporter(ctx, cfg.Exporter)
+		if err != nil {
+			return fail(fmt.Errorf("Failed to create the collector log exporter: %w", err))
+		}
+
+		loggerProvider := sdklog.NewLoggerProvider(
+			sdklog.WithResource(res),
+			sdklog.WithProcessor(sdklog.NewBatchProcessor(logExporter)),
+		)
+		shutdowns = append(shutdowns, loggerProvider.Shutdown)
+
+		global.SetLoggerProvider(loggerProvider)
+	}
+
+	return shutdown, metricsHandler, nil
+}
@@ -0,0 +1,205 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"OTEL_RESOURCE_ATTRIBUTES":     "service.name=ignored,deployment.environment.name=prod",
+		"OTEL_SERVICE_NAME":            "weather-input",
+		"OTEL_LOGS_EXPORTER":           "otlp",
+		"TAIL_SAMPLING_ENABLED":        "true",
+		"TELEMETRY_SHUTDOWN_TIMEOUT":   "8s",
+		"OTEL_BAGGAGE_SPAN_ATTRIBUTES": "client.app,tenant.id",
+	}
+
+	cfg, err := ConfigFromEnv("ServiceA", func(key string) string { return env[key] })
+	require.NoError(t, err)
+	assert.Equal(t, "weather-input", cfg.ServiceName)
+	assert.Equal(t, []attribute.KeyValue{attribute.String("deployment.environment.name", "prod")}, cfg.ResourceAttributes)
+	assert.True(t, cfg.LogsEnabled)
+	assert.NotNil(t, cfg.Sampler)
+	assert.NotNil(t, cfg.Propagator)
+	assert.Equal(t, []string{"client.app", "tenant.id"}, cfg.BaggageKeys)
+	require.NotNil(t, cfg.TailSampling)
+	assert.Equal(t, defaultTailSamplingRatio, cfg.TailSampling.Ratio)
+	assert.Equal(t, 8*time.Second, cfg.ShutdownTimeout)
+}
+
+// OTEL_SERVICE_NAME tem precedência, mas service.name em
+// OTEL_RESOURCE_ATTRIBUTES substitui o nome padrão.
+func TestConfigFromEnv_ServiceNameFromResourceAttributes(t *testing.T) {
+	env := map[string]string{"OTEL_RESOURCE_ATTRIBUTES": "service.name=weather-input"}
+
+	cfg, err := ConfigFromEnv("ServiceA", func(key string) string { return env[key] })
+	require.NoError(t, err)
+	assert.Equal(t, "weather-input", cfg.ServiceName)
+	assert.Empty(t, cfg.ResourceAttributes)
+}
+
+func TestConfigFromEnv_InvalidUsesDefaults(t *testing.T) {
+	env := map[string]string{
+		"OTEL_TRACES_SAMPLER":        "sometimes",
+		"OTEL_PROPAGATORS":           "xray",
+		"TELEMETRY_SHUTDOWN_TIMEOUT": "soon",
+	}
+
+	cfg, err := ConfigFromEnv("ServiceB", func(key string) string { return env[key] })
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "OTEL_TRACES_SAMPLER")
+	assert.Contains(t, err.Error(), "OTEL_PROPAGATORS")
+	assert.Contains(t, err.Error(), "TELEMETRY_SHUTDOWN_TIMEOUT")
+	assert.Equal(t, defaultShutdownTimeout, cfg.ShutdownTimeout)
+	assert.Equal(t, "ServiceB", cfg.ServiceName)
+	assert.Equal(t, sdktrace.ParentBased(sdktrace.AlwaysSample()).Description(), cfg.Sampler.Description())
+	assert.NotNil(t, cfg.Propagator)
+	assert.Nil(t, cfg.TailSampling)
+}
+
+// Setup exporta via OTLP/HTTP com os headers configurados e o shutdown único
+// descarrega traces e métricas.
+func TestSetup_ShutdownFlushesWithHeaders(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]string{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received[r.URL.Path] = r.Header.Get("x-api-key")
+		if r.URL.Path == "/v1/traces" {
+			received["body"] = string(body)
+		}
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	tracerProvider, meterProvider := otel.GetTracerProvider(), otel.GetMeterProvider()
+	defer func() {
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetMeterProvider(meterProvider)
+	}()
+
+	shutdown, metricsHandler, err := Setup(context.Background(), Config{
+		ServiceName:        "weather-input",
+		ResourceAttributes: []attribute.KeyValue{attribute.String("deployment.environment.name", "test")},
+		Exporter: ExporterConfig{
+			Protocol: ProtocolHTTPProtobuf,
+			Endpoint: collector.URL,
+			Headers:  map[string]string{"x-api-key": "secret"},
+		},
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, metricsHandler)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "validate_cep")
+	span.End()
+
+	// O contexto do sinal de parada já chega cancelado ao shutdown
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, shutdown(canceled))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "secret", received["/v1/traces"])
+	assert.Equal(t, "secret", received["/v1/metrics"])
+	assert.Contains(t, received["body"], "validate_cep")
+	assert.Contains(t, received["body"], "weather-input")
+	assert.Contains(t, received["body"], "deployment.environment.name")
+}
+
+// Com OTEL_METRICS_EXPORTER=none as métricas não vão ao collector, mas o
+// /metrics continua respondendo.
+func TestSetup_MetricsExporterNone(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	tracerProvider, meterProvider := otel.GetTracerProvider(), otel.GetMeterProvider()
+	defer func() {
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetMeterProvider(meterProvider)
+	}()
+
+	shutdown, metricsHandler, err := Setup(context.Background(), Config{
+		ServiceName: "weather-input",
+		Exporter: ExporterConfig{
+			TracesExporter:  "none",
+			MetricsExporter: "none",
+			Protocol:        ProtocolHTTPProtobuf,
+			Endpoint:        collector.URL,
+		},
+	})
+	require.NoError(t, err)
+
+	counter, err := otel.Meter("test").Int64Counter("cep_lookups")
+	require.NoError(t, err)
+	counter.Add(context.Background(), 1)
+
+	recorder := httptest.NewRecorder()
+	metricsHandler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, recorder.Body.String(), "cep_lookups")
+
+	require.NoError(t, shutdown(context.Background()))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotContains(t, paths, "/v1/metrics")
+}
+
+type failingMeterProvider struct{ noop.MeterProvider }
+
+func (failingMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter { return failingMeter{} }
+
+type failingMeter struct{ noop.Meter }
+
+func (failingMeter) Int64Counter(string, ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return nil, errors.New("instrument rejected")
+}
+
+// Se o tail sampling não puder ser criado, os exporters de traces já abertos
+// são encerrados.
+func TestSetup_ShutsDownExportersWhenTailSamplingFails(t *testing.T) {
+	if _, err := os.ReadDir("/proc/self/fd"); err != nil {
+		t.Skip("requires /proc/self/fd")
+	}
+
+	tracerProvider, meterProvider := otel.GetTracerProvider(), otel.GetMeterProvider()
+	defer func() {
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetMeterProvider(meterProvider)
+	}()
+	otel.SetMeterProvider(failingMeterProvider{})
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	_, _, err := Setup(context.Background(), Config{
+		ServiceName:  "weather-input",
+		TailSampling: &TailSamplingConfig{Ratio: 0.1},
+		Exporter:     ExporterConfig{TracesExporter: "file", FilePath: path, MetricsExporter: "none"},
+	})
+	require.ErrorContains(t, err, "tail sampling")
+	assert.False(t, fileOpen(t, path), "trace file left open")
+}
@@ -0,0 +1,16 @@
+package telemetry
+
+import (
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EndSpan encerra span, registrando err e marcando o status de erro quando
+// err não for nil. Uso típico: defer func() { telemetry.EndSpan(span, err) }().
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
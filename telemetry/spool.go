@@ -0,0 +1,740 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultSpoolMaxBytes       = 50 << 20
+	defaultSpoolInitialBackoff = time.Second
+	defaultSpoolMaxBackoff     = time.Minute
+	spoolReplayTimeout         = 10 * time.Second
+)
+
+// SpoolConfig controla o SpoolingExporter.
+type SpoolConfig struct {
+	// Dir é o diretório onde os lotes que falharam são gravados.
+	Dir string
+	// MaxBytes limita o tamanho do diretório; ao atingi-lo, os lotes mais
+	// antigos são descartados. Padrão: 50 MiB.
+	MaxBytes int64
+	// InitialBackoff e MaxBackoff controlam o intervalo entre tentativas de
+	// reenvio. Padrão: 1s, dobrando até 1min.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Meter recebe as métricas de profundidade da fila e descartes.
+	Meter metric.Meter
+}
+
+// SpoolConfigFromEnv interpreta TELEMETRY_SPOOL_DIR e TELEMETRY_SPOOL_MAX_MB.
+// Sem diretório o spool fica desativado e nil é devolvido.
+func SpoolConfigFromEnv(dir, maxMB string) (*SpoolConfig, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	cfg := &SpoolConfig{Dir: dir}
+	if maxMB != "" {
+		parsed, err := strconv.ParseInt(maxMB, 10, 64)
+		if err != nil || parsed <= 0 {
+			return cfg, fmt.Errorf("invalid TELEMETRY_SPOOL_MAX_MB %q: must be a positive integer", maxMB)
+		}
+		cfg.MaxBytes = parsed << 20
+	}
+	return cfg, nil
+}
+
+type spoolFile struct {
+	path  string
+	size  int64
+	spans int
+}
+
+// SpoolingExporter grava em disco os lotes que o exporter não conseguiu
+// enviar (ex.: collector reiniciando) e os reenvia em segundo plano, com
+// backoff exponencial, quando o destino volta a responder.
+type SpoolingExporter struct {
+	exporter sdktrace.SpanExporter
+	cfg      SpoolConfig
+
+	mu    sync.Mutex
+	files []spoolFile
+	bytes int64
+	seq   uint64
+
+	depth          atomic.Int64
+	dropped        atomic.Int64
+	spooledCounter metric.Int64Counter
+	droppedCounter metric.Int64Counter
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewSpoolingExporter(exporter sdktrace.SpanExporter, cfg SpoolConfig) (*SpoolingExporter, error) {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultSpoolMaxBytes
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = defaultSpoolInitialBackoff
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = max(defaultSpoolMaxBackoff, cfg.InitialBackoff)
+	}
+	if cfg.Meter == nil {
+		cfg.Meter = noop.NewMeterProvider().Meter("spool")
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	e := &SpoolingExporter{
+		exporter: exporter,
+		cfg:      cfg,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+	if err := e.registerMetrics(); err != nil {
+		return nil, err
+	}
+
+	go e.replay()
+	return e, nil
+}
+
+func (e *SpoolingExporter) registerMetrics() error {
+	var err error
+	e.spooledCounter, err = e.cfg.Meter.Int64Counter("telemetry.spool.spans.spooled",
+		metric.WithDescription("Number of spans written to the local spool after a failed export."),
+		metric.WithUnit("{span}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	e.droppedCounter, err = e.cfg.Meter.Int64Counter("telemetry.spool.spans.dropped",
+		metric.WithDescription("Number of spooled spans discarded because the spool was full or unreadable."),
+		metric.WithUnit("{span}"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.cfg.Meter.Int64ObservableGauge("telemetry.spool.spans.queued",
+		metric.WithDescription("Number of spans waiting in the local spool to be exported."),
+		metric.WithUnit("{span}"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(e.depth.Load())
+			return nil
+		}),
+	)
+	return err
+}
+
+// load recupera os lotes deixados por uma execução anterior. O nome de cada
+// arquivo carrega a quantidade de spans: <unixnano>-<seq>-<spans>.json.
+func (e *SpoolingExporter) load() error {
+	entries, err := os.ReadDir(e.cfg.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spool directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		parts := strings.Split(strings.TrimSuffix(entry.Name(), ".json"), "-")
+		if len(parts) != 3 {
+			continue
+		}
+		spans, err := strconv.Atoi(parts[2])
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		e.files = append(e.files, spoolFile{path: filepath.Join(e.cfg.Dir, entry.Name()), size: info.Size(), spans: spans})
+		e.bytes += info.Size()
+		e.depth.Add(int64(spans))
+	}
+	sort.Slice(e.files, func(i, j int) bool { return e.files[i].path < e.files[j].path })
+	return nil
+}
+
+// Depth devolve quantos spans aguardam reenvio.
+func (e *SpoolingExporter) Depth() int64 {
+	return e.depth.Load()
+}
+
+// Dropped devolve quantos spans foram descartados pelo spool.
+func (e *SpoolingExporter) Dropped() int64 {
+	return e.dropped.Load()
+}
+
+func (e *SpoolingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.exporter.ExportSpans(ctx, spans)
+	if err == nil || len(spans) == 0 {
+		return err
+	}
+
+	if spoolErr := e.spool(spans); spoolErr != nil {
+		return errors.Join(err, spoolErr)
+	}
+	return nil
+}
+
+func (e *SpoolingExporter) spool(spans []sdktrace.ReadOnlySpan) error {
+	encoded := make([]spooledSpan, 0, len(spans))
+	for _, span := range spans {
+		encoded = append(encoded, newSpooledSpan(span))
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return fmt.Errorf("failed to encode spans for the spool: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	size := int64(len(data))
+	if size > e.cfg.MaxBytes {
+		e.drop(len(spans))
+		return fmt.Errorf("batch of %d bytes exceeds the spool limit", size)
+	}
+	for e.bytes+size > e.cfg.MaxBytes && len(e.files) > 0 {
+		oldest := e.files[0]
+		e.files = e.files[1:]
+		e.bytes -= oldest.size
+		e.depth.Add(-int64(oldest.spans))
+		os.Remove(oldest.path)
+		e.drop(oldest.spans)
+	}
+
+	e.seq++
+	name := fmt.Sprintf("%020d-%06d-%d.json", time.Now().UnixNano(), e.seq%1000000, len(spans))
+	path := filepath.Join(e.cfg.Dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		e.drop(len(spans))
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+
+	e.files = append(e.files, spoolFile{path: path, size: size, spans: len(spans)})
+	e.bytes += size
+	e.depth.Add(int64(len(spans)))
+	e.spooledCounter.Add(context.Background(), int64(len(spans)))
+	return nil
+}
+
+// drop deve ser chamado com mu travado.
+func (e *SpoolingExporter) drop(spans int) {
+	e.dropped.Add(int64(spans))
+	e.droppedCounter.Add(context.Background(), int64(spans))
+}
+
+func (e *SpoolingExporter) replay() {
+	defer close(e.done)
+
+	backoff := e.cfg.InitialBackoff
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-timer.C:
+		}
+
+		replayed, err := e.replayOldest()
+		switch {
+		case err != nil:
+			backoff = min(backoff*2, e.cfg.MaxBackoff)
+		case replayed:
+			// Ainda pode haver lotes na fila: tenta o próximo em seguida
+			backoff = e.cfg.InitialBackoff
+			timer.Reset(0)
+			continue
+		default:
+			backoff = e.cfg.InitialBackoff
+		}
+		timer.Reset(backoff)
+	}
+}
+
+// replayOldest reenvia o lote mais antigo do spool e o remove em caso de
+// sucesso.
+func (e *SpoolingExporter) replayOldest() (bool, error) {
+	e.mu.Lock()
+	if len(e.files) == 0 {
+		e.mu.Unlock()
+		return false, nil
+	}
+	file := e.files[0]
+	e.mu.Unlock()
+
+	spans, err := readSpoolFile(file.path)
+	if err != nil {
+		// Lote corrompido nunca será enviado: descarta para não travar a fila
+		e.remove(file, true)
+		return true, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), spoolReplayTimeout)
+	defer cancel()
+	if err := e.exporter.ExportSpans(ctx, spans); err != nil {
+		return false, err
+	}
+
+	e.remove(file, false)
+	return true, nil
+}
+
+func (e *SpoolingExporter) remove(file spoolFile, dropped bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// O arquivo pode ter sido descartado pelo limite enquanto era reenviado
+	if len(e.files) == 0 || e.files[0].path != file.path {
+		return
+	}
+	e.files = e.files[1:]
+	e.bytes -= file.size
+	e.depth.Add(-int64(file.spans))
+	os.Remove(file.path)
+	if dropped {
+		e.drop(file.spans)
+	}
+}
+
+// Shutdown interrompe o reenvio; os lotes pendentes ficam no diretório e são
+// reenviados na próxima execução.
+func (e *SpoolingExporter) Shutdown(ctx context.Context) error {
+	select {
+	case <-e.stop:
+	default:
+		close(e.stop)
+	}
+
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return e.exporter.Shutdown(ctx)
+}
+
+func readSpoolFile(path string) ([]sdktrace.ReadOnlySpan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var encoded []spooledSpan
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+
+	spans := make([]sdktrace.ReadOnlySpan, 0, len(encoded))
+	for _, span := range encoded {
+		decoded, err := span.readOnly()
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, decoded)
+	}
+	return spans, nil
+}
+
+// spooledSpan é a forma serializável de um ReadOnlySpan. attribute.Value e
+// trace.SpanContext não suportam json.Unmarshal, por isso são convertidos.
+type spooledSpan struct {
+	Name              string           `json:"name"`
+	SpanContext       spooledContext   `json:"span_context"`
+	Parent            spooledContext   `json:"parent"`
+	Kind              trace.SpanKind   `json:"kind"`
+	StartTime         time.Time        `json:"start_time"`
+	EndTime           time.Time        `json:"end_time"`
+	Attributes        []spooledAttr    `json:"attributes,omitempty"`
+	Events            []spooledEvent   `json:"events,omitempty"`
+	Links             []spooledLink    `json:"links,omitempty"`
+	StatusCode        codes.Code       `json:"status_code"`
+	StatusDescription string           `json:"status_description,omitempty"`
+	DroppedAttributes int              `json:"dropped_attributes,omitempty"`
+	DroppedEvents     int              `json:"dropped_events,omitempty"`
+	DroppedLinks      int              `json:"dropped_links,omitempty"`
+	ChildSpanCount    int              `json:"child_span_count,omitempty"`
+	Resource          []spooledAttr    `json:"resource,omitempty"`
+	ResourceSchemaURL string           `json:"resource_schema_url,omitempty"`
+	Scope             spooledScopeInfo `json:"scope"`
+}
+
+type spooledContext struct {
+	TraceID    string `json:"trace_id,omitempty"`
+	SpanID     string `json:"span_id,omitempty"`
+	TraceFlags byte   `json:"trace_flags,omitempty"`
+	TraceState string `json:"trace_state,omitempty"`
+	Remote     bool   `json:"remote,omitempty"`
+}
+
+type spooledAttr struct {
+	Key   string          `json:"key"`
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type spooledEvent struct {
+	Name              string        `json:"name"`
+	Time              time.Time     `json:"time"`
+	Attributes        []spooledAttr `json:"attributes,omitempty"`
+	DroppedAttributes int           `json:"dropped_attributes,omitempty"`
+}
+
+type spooledLink struct {
+	SpanContext       spooledContext `json:"span_context"`
+	Attributes        []spooledAttr  `json:"attributes,omitempty"`
+	DroppedAttributes int            `json:"dropped_attributes,omitempty"`
+}
+
+type spooledScopeInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	SchemaURL string `json:"schema_url,omitempty"`
+}
+
+func newSpooledSpan(s sdktrace.ReadOnlySpan) spooledSpan {
+	span := spooledSpan{
+		Name:              s.Name(),
+		SpanContext:       newSpooledContext(s.SpanContext()),
+		Parent:            newSpooledContext(s.Parent()),
+		Kind:              s.SpanKind(),
+		StartTime:         s.StartTime(),
+		EndTime:           s.EndTime(),
+		Attributes:        newSpooledAttrs(s.Attributes()),
+		StatusCode:        s.Status().Code,
+		StatusDescription: s.Status().Description,
+		DroppedAttributes: s.DroppedAttributes(),
+		DroppedEvents:     s.DroppedEvents(),
+		DroppedLinks:      s.DroppedLinks(),
+		ChildSpanCount:    s.ChildSpanCount(),
+		Scope: spooledScopeInfo{
+			Name:      s.InstrumentationScope().Name,
+			Version:   s.InstrumentationScope().Version,
+			SchemaURL: s.InstrumentationScope().SchemaURL,
+		},
+	}
+	for _, event := range s.Events() {
+		span.Events = append(span.Events, spooledEvent{
+			Name:              event.Name,
+			Time:              event.Time,
+			Attributes:        newSpooledAttrs(event.Attributes),
+			DroppedAttributes: event.DroppedAttributeCount,
+		})
+	}
+	for _, link := range s.Links() {
+		span.Links = append(span.Links, spooledLink{
+			SpanContext:       newSpooledContext(link.SpanContext),
+			Attributes:        newSpooledAttrs(link.Attributes),
+			DroppedAttributes: link.DroppedAttributeCount,
+		})
+	}
+	if res := s.Resource(); res != nil {
+		span.Resource = newSpooledAttrs(res.Attributes())
+		span.ResourceSchemaURL = res.SchemaURL()
+	}
+	return span
+}
+
+func (s spooledSpan) readOnly() (sdktrace.ReadOnlySpan, error) {
+	spanContext, err := s.SpanContext.spanContext()
+	if err != nil {
+		return nil, err
+	}
+	parent, err := s.Parent.spanContext()
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := spooledAttrs(s.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	resourceAttrs, err := spooledAttrs(s.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	span := &replayedSpan{
+		name:              s.Name,
+		spanContext:       spanContext,
+		parent:            parent,
+		spanKind:          s.Kind,
+		startTime:         s.StartTime,
+		endTime:           s.EndTime,
+		attributes:        attrs,
+		status:            sdktrace.Status{Code: s.StatusCode, Description: s.StatusDescription},
+		droppedAttributes: s.DroppedAttributes,
+		droppedEvents:     s.DroppedEvents,
+		droppedLinks:      s.DroppedLinks,
+		childSpanCount:    s.ChildSpanCount,
+		resource:          resource.NewWithAttributes(s.ResourceSchemaURL, resourceAttrs...),
+		scope: instrumentation.Scope{
+			Name:      s.Scope.Name,
+			Version:   s.Scope.Version,
+			SchemaURL: s.Scope.SchemaURL,
+		},
+	}
+	for _, event := range s.Events {
+		eventAttrs, err := spooledAttrs(event.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		span.events = append(span.events, sdktrace.Event{
+			Name:                  event.Name,
+			Time:                  event.Time,
+			Attributes:            eventAttrs,
+			DroppedAttributeCount: event.DroppedAttributes,
+		})
+	}
+	for _, link := range s.Links {
+		linkContext, err := link.SpanContext.spanContext()
+		if err != nil {
+			return nil, err
+		}
+		linkAttrs, err := spooledAttrs(link.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		span.links = append(span.links, sdktrace.Link{
+			SpanContext:           linkContext,
+			Attributes:            linkAttrs,
+			DroppedAttributeCount: link.DroppedAttributes,
+		})
+	}
+	return span, nil
+}
+
+// replayedSpan é o ReadOnlySpan reconstruído a partir do spool. A interface
+// embutida só supre o método privado exigido pelo SDK.
+type replayedSpan struct {
+	sdktrace.ReadOnlySpan
+
+	name              string
+	spanContext       trace.SpanContext
+	parent            trace.SpanContext
+	spanKind          trace.SpanKind
+	startTime         time.Time
+	endTime           time.Time
+	attributes        []attribute.KeyValue
+	events            []sdktrace.Event
+	links             []sdktrace.Link
+	status            sdktrace.Status
+	droppedAttributes int
+	droppedEvents     int
+	droppedLinks      int
+	childSpanCount    int
+	resource          *resource.Resource
+	scope             instrumentation.Scope
+}
+
+func (s *replayedSpan) Name() string                                { return s.name }
+func (s *replayedSpan) SpanContext() trace.SpanContext              { return s.spanContext }
+func (s *replayedSpan) Parent() trace.SpanContext                   { return s.parent }
+func (s *replayedSpan) SpanKind() trace.SpanKind                    { return s.spanKind }
+func (s *replayedSpan) StartTime() time.Time                        { return s.startTime }
+func (s *replayedSpan) EndTime() time.Time                          { return s.endTime }
+func (s *replayedSpan) Attributes() []attribute.KeyValue            { return s.attributes }
+func (s *replayedSpan) Links() []sdktrace.Link                      { return s.links }
+func (s *replayedSpan) Events() []sdktrace.Event                    { return s.events }
+func (s *replayedSpan) Status() sdktrace.Status                     { return s.status }
+func (s *replayedSpan) InstrumentationScope() instrumentation.Scope { return s.scope }
+func (s *replayedSpan) Resource() *resource.Resource                { return s.resource }
+func (s *replayedSpan) DroppedAttributes() int                      { return s.droppedAttributes }
+func (s *replayedSpan) DroppedLinks() int                           { return s.droppedLinks }
+func (s *replayedSpan) DroppedEvents() int                          { return s.droppedEvents }
+func (s *replayedSpan) ChildSpanCount() int                         { return s.childSpanCount }
+
+//nolint:staticcheck // Exigido pela interface por compatibilidade.
+func (s *replayedSpan) InstrumentationLibrary() instrumentation.Library {
+	return instrumentation.Library(s.scope)
+}
+
+func newSpooledContext(sc trace.SpanContext) spooledContext {
+	if !sc.IsValid() {
+		return spooledContext{}
+	}
+	return spooledContext{
+		TraceID:    sc.TraceID().String(),
+		SpanID:     sc.SpanID().String(),
+		TraceFlags: byte(sc.TraceFlags()),
+		TraceState: sc.TraceState().String(),
+		Remote:     sc.IsRemote(),
+	}
+}
+
+func (c spooledContext) spanContext() (trace.SpanContext, error) {
+	if c.TraceID == "" {
+		return trace.SpanContext{}, nil
+	}
+	traceID, err := trace.TraceIDFromHex(c.TraceID)
+	if err != nil {
+		return trace.SpanContext{}, err
+	}
+	spanID, err := trace.SpanIDFromHex(c.SpanID)
+	if err != nil {
+		return trace.SpanContext{}, err
+	}
+	state, err := trace.ParseTraceState(c.TraceState)
+	if err != nil {
+		return trace.SpanContext{}, err
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.TraceFlags(c.TraceFlags),
+		TraceState: state,
+		Remote:     c.Remote,
+	}), nil
+}
+
+func newSpooledAttrs(attrs []attribute.KeyValue) []spooledAttr {
+	encoded := make([]spooledAttr, 0, len(attrs))
+	for _, attr := range attrs {
+		value, err := json.Marshal(spooledValue(attr.Value))
+		if err != nil {
+			continue
+		}
+		encoded = append(encoded, spooledAttr{Key: string(attr.Key), Type: attr.Value.Type().String(), Value: value})
+	}
+	return encoded
+}
+
+// spooledValue troca os float64 por spooledFloat para que NaN e ±Inf, que o
+// JSON não representa, sobrevivam ao spool.
+func spooledValue(v attribute.Value) any {
+	switch v.Type() {
+	case attribute.FLOAT64:
+		return spooledFloat(v.AsFloat64())
+	case attribute.FLOAT64SLICE:
+		floats := v.AsFloat64Slice()
+		encoded := make([]spooledFloat, len(floats))
+		for i, f := range floats {
+			encoded[i] = spooledFloat(f)
+		}
+		return encoded
+	default:
+		return v.AsInterface()
+	}
+}
+
+// spooledFloat grava NaN e ±Inf como string ("NaN", "+Inf", "-Inf") e os
+// demais valores como número.
+type spooledFloat float64
+
+func (f spooledFloat) MarshalJSON() ([]byte, error) {
+	v := float64(f)
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return json.Marshal(strconv.FormatFloat(v, 'g', -1, 64))
+	}
+	return json.Marshal(v)
+}
+
+func (f *spooledFloat) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*f = spooledFloat(v)
+		return nil
+	}
+	var v float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*f = spooledFloat(v)
+	return nil
+}
+
+func spooledAttrs(encoded []spooledAttr) ([]attribute.KeyValue, error) {
+	attrs := make([]attribute.KeyValue, 0, len(encoded))
+	for _, attr := range encoded {
+		kv, err := attr.keyValue()
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, kv)
+	}
+	return attrs, nil
+}
+
+func (a spooledAttr) keyValue() (attribute.KeyValue, error) {
+	key := attribute.Key(a.Key)
+	var err error
+	switch a.Type {
+	case "BOOL":
+		var v bool
+		err = json.Unmarshal(a.Value, &v)
+		return key.Bool(v), err
+	case "INT64":
+		var v int64
+		err = json.Unmarshal(a.Value, &v)
+		return key.Int64(v), err
+	case "FLOAT64":
+		var v spooledFloat
+		err = json.Unmarshal(a.Value, &v)
+		return key.Float64(float64(v)), err
+	case "STRING":
+		var v string
+		err = json.Unmarshal(a.Value, &v)
+		return key.String(v), err
+	case "BOOLSLICE":
+		var v []bool
+		err = json.Unmarshal(a.Value, &v)
+		return key.BoolSlice(v), err
+	case "INT64SLICE":
+		var v []int64
+		err = json.Unmarshal(a.Value, &v)
+		return key.Int64Slice(v), err
+	case "FLOAT64SLICE":
+		var v []spooledFloat
+		err = json.Unmarshal(a.Value, &v)
+		floats := make([]float64, len(v))
+		for i, f := range v {
+			floats[i] = float64(f)
+		}
+		return key.Float64Slice(floats), err
+	case "STRINGSLICE":
+		var v []string
+		err = json.Unmarshal(a.Value, &v)
+		return key.StringSlice(v), err
+	default:
+		return attribute.KeyValue{}, fmt.Errorf("unsupported attribute type %q", a.Type)
+	}
+}
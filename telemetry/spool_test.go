@@ -0,0 +1,160 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// flakyExporter simula um collector fora do ar enquanto fail for true.
+type flakyExporter struct {
+	mu    sync.Mutex
+	fail  bool
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (f *flakyExporter) setFail(fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = fail
+}
+
+func (f *flakyExporter) exported() []sdktrace.ReadOnlySpan {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sdktrace.ReadOnlySpan(nil), f.spans...)
+}
+
+func (f *flakyExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return errors.New("connection refused")
+	}
+	f.spans = append(f.spans, spans...)
+	return nil
+}
+
+func (f *flakyExporter) Shutdown(context.Context) error { return nil }
+
+func testSpans(t *testing.T, n int) []sdktrace.ReadOnlySpan {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	for i := 0; i < n; i++ {
+		_, span := tracer.Start(context.Background(), "call_service_b")
+		span.SetAttributes(attribute.String("cep", "01001-***"), attribute.Int("attempt", i), attribute.StringSlice("tags", []string{"a", "b"}))
+		span.AddEvent("retry", trace.WithAttributes(attribute.Float64("delay", 0.5)))
+		span.SetStatus(codes.Error, "service B unavailable")
+		span.End()
+	}
+	return recorder.Ended()
+}
+
+func TestSpoolingExporter_SpoolsAndReplays(t *testing.T) {
+	flaky := &flakyExporter{fail: true}
+	exporter, err := NewSpoolingExporter(flaky, SpoolConfig{Dir: t.TempDir(), InitialBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond})
+	require.NoError(t, err)
+	defer exporter.Shutdown(context.Background())
+
+	spans := testSpans(t, 3)
+	require.NoError(t, exporter.ExportSpans(context.Background(), spans))
+	assert.Equal(t, int64(3), exporter.Depth())
+
+	flaky.setFail(false)
+	require.Eventually(t, func() bool { return exporter.Depth() == 0 }, 2*time.Second, 10*time.Millisecond)
+
+	replayed := flaky.exported()
+	require.Len(t, replayed, 3)
+	for i, span := range replayed {
+		assert.Equal(t, spans[i].Name(), span.Name())
+		assert.Equal(t, spans[i].SpanContext(), span.SpanContext())
+		assert.Equal(t, spans[i].Attributes(), span.Attributes())
+		assert.Equal(t, spans[i].Status(), span.Status())
+		assert.Equal(t, spans[i].Events()[0].Attributes, span.Events()[0].Attributes)
+		assert.True(t, spans[i].StartTime().Equal(span.StartTime()))
+	}
+	assert.Zero(t, exporter.Dropped())
+}
+
+func TestSpoolingExporter_BoundedDirectory(t *testing.T) {
+	flaky := &flakyExporter{fail: true}
+	exporter, err := NewSpoolingExporter(flaky, SpoolConfig{Dir: t.TempDir(), InitialBackoff: time.Hour})
+	require.NoError(t, err)
+	defer exporter.Shutdown(context.Background())
+
+	batch := testSpans(t, 1)
+	require.NoError(t, exporter.ExportSpans(context.Background(), batch))
+	exporter.cfg.MaxBytes = exporter.bytes * 2
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, exporter.ExportSpans(context.Background(), batch))
+	}
+
+	assert.Equal(t, int64(2), exporter.Depth())
+	assert.Equal(t, int64(3), exporter.Dropped())
+}
+
+func TestSpoolingExporter_ReloadsPendingBatches(t *testing.T) {
+	dir := t.TempDir()
+	flaky := &flakyExporter{fail: true}
+
+	first, err := NewSpoolingExporter(flaky, SpoolConfig{Dir: dir, InitialBackoff: time.Hour})
+	require.NoError(t, err)
+	require.NoError(t, first.ExportSpans(context.Background(), testSpans(t, 2)))
+	require.NoError(t, first.Shutdown(context.Background()))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	flaky.setFail(false)
+	second, err := NewSpoolingExporter(flaky, SpoolConfig{Dir: dir, InitialBackoff: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer second.Shutdown(context.Background())
+
+	require.Eventually(t, func() bool { return len(flaky.exported()) == 2 }, 2*time.Second, 10*time.Millisecond)
+}
+
+// NaN e ±Inf não têm representação em JSON, mas não podem sumir do span.
+func TestSpooledAttrs_NonFiniteFloats(t *testing.T) {
+	attrs := []attribute.KeyValue{
+		attribute.Float64("ratio", math.Inf(1)),
+		attribute.Float64("delta", math.Inf(-1)),
+		attribute.Float64Slice("samples", []float64{1.5, math.NaN()}),
+	}
+
+	decoded, err := spooledAttrs(newSpooledAttrs(attrs))
+	require.NoError(t, err)
+	require.Len(t, decoded, 3)
+	assert.Equal(t, attrs[0], decoded[0])
+	assert.Equal(t, attrs[1], decoded[1])
+	samples := decoded[2].Value.AsFloat64Slice()
+	require.Len(t, samples, 2)
+	assert.Equal(t, 1.5, samples[0])
+	assert.True(t, math.IsNaN(samples[1]))
+}
+
+func TestSpoolConfigFromEnv(t *testing.T) {
+	cfg, err := SpoolConfigFromEnv("", "")
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	cfg, err = SpoolConfigFromEnv("/var/spool/serviceA", "10")
+	require.NoError(t, err)
+	assert.Equal(t, int64(10<<20), cfg.MaxBytes)
+
+	_, err = SpoolConfigFromEnv("/var/spool/serviceA", "-1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,293 @@
+package telemetry
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultTailSamplingRatio     = 0.1
+	defaultTailSamplingLatency   = time.Second
+	defaultTailSamplingMaxTraces = 1000
+	defaultTailSamplingMaxSpans  = 256
+)
+
+// TailSamplingConfig controla o TailSamplingProcessor.
+type TailSamplingConfig struct {
+	// Ratio é a proporção mantida dos traces sem erro e abaixo da latência.
+	Ratio float64
+	// LatencyThreshold mantém traces cujo span raiz durou ao menos esse tempo.
+	LatencyThreshold time.Duration
+	// MaxTraces limita quantos traces ficam em memória aguardando decisão.
+	MaxTraces int
+	// MaxSpansPerTrace limita os spans guardados de cada trace.
+	MaxSpansPerTrace int
+}
+
+// TailSamplingConfigFromEnv interpreta TAIL_SAMPLING_RATIO,
+// TAIL_SAMPLING_LATENCY_THRESHOLD e TAIL_SAMPLING_MAX_TRACES. Valores vazios
+// usam os padrões: 0.1, 1s e 1000.
+func TailSamplingConfigFromEnv(ratio, latency, maxTraces string) (TailSamplingConfig, error) {
+	cfg := TailSamplingConfig{
+		Ratio:            defaultTailSamplingRatio,
+		LatencyThreshold: defaultTailSamplingLatency,
+		MaxTraces:        defaultTailSamplingMaxTraces,
+		MaxSpansPerTrace: defaultTailSamplingMaxSpans,
+	}
+
+	if ratio != "" {
+		parsed, err := strconv.ParseFloat(ratio, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return cfg, fmt.Errorf("invalid TAIL_SAMPLING_RATIO %q: must be a number between 0 and 1", ratio)
+		}
+		cfg.Ratio = parsed
+	}
+	if latency != "" {
+		parsed, err := time.ParseDuration(latency)
+		if err != nil || parsed < 0 {
+			return cfg, fmt.Errorf("invalid TAIL_SAMPLING_LATENCY_THRESHOLD %q: must be a duration such as 500ms", latency)
+		}
+		cfg.LatencyThreshold = parsed
+	}
+	if maxTraces != "" {
+		parsed, err := strconv.Atoi(maxTraces)
+		if err != nil || parsed <= 0 {
+			return cfg, fmt.Errorf("invalid TAIL_SAMPLING_MAX_TRACES %q: must be a positive integer", maxTraces)
+		}
+		cfg.MaxTraces = parsed
+	}
+
+	return cfg, nil
+}
+
+type pendingTrace struct {
+	id       trace.TraceID
+	spans    []sdktrace.ReadOnlySpan
+	hasError bool
+	// forced indica que algum span do trace foi iniciado com X-Debug-Trace.
+	forced bool
+}
+
+// TailSamplingProcessor guarda os spans de cada trace até o fim do span raiz
+// local e só então decide: traces com erro, acima do limite de latência ou
+// forçados por X-Debug-Trace são sempre mantidos e os demais são amostrados
+// por Ratio. Os traces mantidos são
+// repassados aos processors seguintes (ex.: BatchSpanProcessor).
+type TailSamplingProcessor struct {
+	next    []sdktrace.SpanProcessor
+	cfg     TailSamplingConfig
+	ratio   sdktrace.Sampler
+	dropped metric.Int64Counter
+	total   atomic.Int64
+
+	mu       sync.Mutex
+	pending  map[trace.TraceID]*list.Element
+	order    *list.List
+	decided  map[trace.TraceID]bool
+	decision *list.List
+	// roots conta os spans raiz locais ainda abertos de cada trace. Um
+	// cliente pode propagar o mesmo traceparent em várias requisições, e cada
+	// uma abre a sua raiz.
+	roots map[trace.TraceID]int
+}
+
+func NewTailSamplingProcessor(cfg TailSamplingConfig, meter metric.Meter, next ...sdktrace.SpanProcessor) (*TailSamplingProcessor, error) {
+	if cfg.MaxTraces <= 0 {
+		cfg.MaxTraces = defaultTailSamplingMaxTraces
+	}
+	if cfg.MaxSpansPerTrace <= 0 {
+		cfg.MaxSpansPerTrace = defaultTailSamplingMaxSpans
+	}
+
+	dropped, err := meter.Int64Counter("tail_sampling.traces.dropped",
+		metric.WithDescription("Number of traces discarded by the tail sampler."),
+		metric.WithUnit("{trace}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TailSamplingProcessor{
+		next:     next,
+		cfg:      cfg,
+		ratio:    sdktrace.TraceIDRatioBased(cfg.Ratio),
+		dropped:  dropped,
+		pending:  make(map[trace.TraceID]*list.Element),
+		order:    list.New(),
+		decided:  make(map[trace.TraceID]bool),
+		decision: list.New(),
+		roots:    make(map[trace.TraceID]int),
+	}, nil
+}
+
+// Dropped devolve o total de traces descartados desde a criação.
+func (p *TailSamplingProcessor) Dropped() int64 {
+	return p.total.Load()
+}
+
+func (p *TailSamplingProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if s.SpanContext().IsSampled() {
+		id := s.SpanContext().TraceID()
+		p.mu.Lock()
+		if isLocalRoot(s) {
+			p.roots[id]++
+		}
+		// O forceSampler já manteve o trace na cabeça; o descarte por Ratio
+		// no fim anularia o X-Debug-Trace
+		if keep := p.decided[id]; ForceSampled(parent) && !keep {
+			p.pendingTrace(id).forced = true
+		}
+		p.mu.Unlock()
+	}
+	for _, next := range p.next {
+		next.OnStart(parent, s)
+	}
+}
+
+func (p *TailSamplingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+	id := s.SpanContext().TraceID()
+
+	root := isLocalRoot(s)
+
+	p.mu.Lock()
+	if root {
+		if p.roots[id]--; p.roots[id] <= 0 {
+			delete(p.roots, id)
+		}
+	}
+	// Um trace mantido continua mantido. Um trace descartado só descarta de
+	// imediato os spans que terminam depois da sua raiz; se outra raiz local
+	// do mesmo trace está aberta, os spans aguardam a avaliação dela.
+	if keep, ok := p.decided[id]; ok && (keep || (!root && p.roots[id] == 0)) {
+		p.mu.Unlock()
+		if keep {
+			p.forward(s)
+		}
+		return
+	}
+
+	t := p.pendingTrace(id)
+	if len(t.spans) < p.cfg.MaxSpansPerTrace {
+		t.spans = append(t.spans, s)
+	}
+	if s.Status().Code == codes.Error {
+		t.hasError = true
+	}
+
+	if !root {
+		p.mu.Unlock()
+		return
+	}
+
+	keep := t.hasError || t.forced ||
+		(p.cfg.LatencyThreshold > 0 && s.EndTime().Sub(s.StartTime()) >= p.cfg.LatencyThreshold) ||
+		p.ratio.ShouldSample(sdktrace.SamplingParameters{TraceID: id}).Decision == sdktrace.RecordAndSample
+	p.order.Remove(p.pending[id])
+	delete(p.pending, id)
+	p.remember(id, keep)
+	p.mu.Unlock()
+
+	if !keep {
+		p.drop("sampled_out")
+		return
+	}
+	for _, span := range t.spans {
+		p.forward(span)
+	}
+}
+
+// pendingTrace devolve o buffer do trace, descartando o mais antigo quando o
+// limite de traces em memória é atingido. Deve ser chamado com mu travado.
+func (p *TailSamplingProcessor) pendingTrace(id trace.TraceID) *pendingTrace {
+	if elem, ok := p.pending[id]; ok {
+		return elem.Value.(*pendingTrace)
+	}
+
+	for p.order.Len() >= p.cfg.MaxTraces {
+		oldest := p.order.Remove(p.order.Front()).(*pendingTrace)
+		delete(p.pending, oldest.id)
+		p.drop("evicted")
+	}
+
+	t := &pendingTrace{id: id}
+	p.pending[id] = p.order.PushBack(t)
+	return t
+}
+
+// remember guarda as decisões mais recentes, limitadas a MaxTraces. Deve ser
+// chamado com mu travado.
+func (p *TailSamplingProcessor) remember(id trace.TraceID, keep bool) {
+	p.decided[id] = keep
+	p.decision.PushBack(id)
+	for p.decision.Len() > p.cfg.MaxTraces {
+		delete(p.decided, p.decision.Remove(p.decision.Front()).(trace.TraceID))
+	}
+}
+
+// isLocalRoot indica se o span é a raiz do trace neste processo: sem pai ou
+// com o pai vindo de outro serviço.
+func isLocalRoot(s interface{ Parent() trace.SpanContext }) bool {
+	return !s.Parent().IsValid() || s.Parent().IsRemote()
+}
+
+func (p *TailSamplingProcessor) forward(s sdktrace.ReadOnlySpan) {
+	for _, next := range p.next {
+		next.OnEnd(s)
+	}
+}
+
+func (p *TailSamplingProcessor) drop(reason string) {
+	p.total.Add(1)
+	p.dropped.Add(context.Background(), 1, metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+func (p *TailSamplingProcessor) ForceFlush(ctx context.Context) error {
+	var errs []error
+	for _, next := range p.next {
+		errs = append(errs, next.ForceFlush(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown repassa os traces pendentes com erro ou forçados e descarta os demais antes de
+// encerrar os processors seguintes.
+func (p *TailSamplingProcessor) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	var keep []sdktrace.ReadOnlySpan
+	for elem := p.order.Front(); elem != nil; elem = elem.Next() {
+		t := elem.Value.(*pendingTrace)
+		if t.hasError || t.forced {
+			keep = append(keep, t.spans...)
+		} else {
+			p.drop("shutdown")
+		}
+	}
+	p.pending = make(map[trace.TraceID]*list.Element)
+	p.order.Init()
+	p.mu.Unlock()
+
+	for _, span := range keep {
+		p.forward(span)
+	}
+
+	var errs []error
+	for _, next := range p.next {
+		errs = append(errs, next.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,209 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTailSamplingProcessor_KeepsSlowTraces(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	recorder := tracetest.NewSpanRecorder()
+	processor, err := NewTailSamplingProcessor(TailSamplingConfig{Ratio: 0, LatencyThreshold: 500 * time.Millisecond}, meter, recorder)
+	require.NoError(t, err)
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor)).Tracer("test")
+
+	start := time.Now()
+	_, fast := tracer.Start(context.Background(), "POST /", trace.WithTimestamp(start))
+	fast.End(trace.WithTimestamp(start.Add(100 * time.Millisecond)))
+
+	_, slow := tracer.Start(context.Background(), "POST /", trace.WithTimestamp(start))
+	slow.End(trace.WithTimestamp(start.Add(2 * time.Second)))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, slow.SpanContext().TraceID(), spans[0].SpanContext().TraceID())
+	assert.Equal(t, int64(1), processor.Dropped())
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	dropped := rm.ScopeMetrics[0].Metrics[0]
+	assert.Equal(t, "tail_sampling.traces.dropped", dropped.Name)
+	point := dropped.Data.(metricdata.Sum[int64]).DataPoints[0]
+	assert.Equal(t, int64(1), point.Value)
+	reason, _ := point.Attributes.Value(attribute.Key("reason"))
+	assert.Equal(t, "sampled_out", reason.AsString())
+}
+
+func TestTailSamplingProcessor_RatioAndLateSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	processor, err := NewTailSamplingProcessor(TailSamplingConfig{Ratio: 1}, sdkmetric.NewMeterProvider().Meter("test"), recorder)
+	require.NoError(t, err)
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor)).Tracer("test")
+
+	ctx, root := tracer.Start(context.Background(), "POST /")
+	_, late := tracer.Start(ctx, "call_service_b")
+	root.End()
+	late.End()
+
+	assert.Len(t, recorder.Ended(), 2)
+	assert.Zero(t, processor.Dropped())
+}
+
+func TestTailSamplingProcessor_ShutdownKeepsPendingErrors(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	processor, err := NewTailSamplingProcessor(TailSamplingConfig{Ratio: 0}, sdkmetric.NewMeterProvider().Meter("test"), recorder)
+	require.NoError(t, err)
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+	tracer := provider.Tracer("test")
+
+	ctx, _ := tracer.Start(context.Background(), "POST /")
+	_, failed := tracer.Start(ctx, "call_service_b")
+	failed.RecordError(assert.AnError)
+	failed.SetStatus(codes.Error, "service B unavailable")
+	failed.End()
+
+	require.NoError(t, provider.Shutdown(context.Background()))
+	require.Len(t, recorder.Ended(), 1)
+	assert.Equal(t, "call_service_b", recorder.Ended()[0].Name())
+}
+
+func newTailSamplingTracer(t *testing.T, cfg TailSamplingConfig) (trace.Tracer, *TailSamplingProcessor, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	processor, err := NewTailSamplingProcessor(cfg, noop.NewMeterProvider().Meter("test"), recorder)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(processor))
+	return provider.Tracer("test"), processor, recorder
+}
+
+// Com ratio 0, só traces com erro (ex.: CEP não encontrado) são mantidos, e
+// os spans filhos acompanham a decisão do span raiz.
+func TestTailSamplingProcessor_KeepsErrorTraces(t *testing.T) {
+	tracer, processor, recorder := newTailSamplingTracer(t, TailSamplingConfig{Ratio: 0, LatencyThreshold: time.Hour})
+
+	for _, fail := range []bool{false, true, false} {
+		ctx, root := tracer.Start(context.Background(), "GET /")
+		_, child := tracer.Start(ctx, "fetch_weather_data")
+		if fail {
+			child.SetStatus(codes.Error, "Can not find zipcode")
+		}
+		child.End()
+		root.End()
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected the 2 spans of the failing trace, got %d", len(spans))
+	}
+	if spans[0].Name() != "fetch_weather_data" || spans[1].Name() != "GET /" {
+		t.Errorf("Expected child and root of the failing trace, got %s and %s", spans[0].Name(), spans[1].Name())
+	}
+	if processor.Dropped() != 2 {
+		t.Errorf("Expected 2 dropped traces, got %d", processor.Dropped())
+	}
+}
+
+// Um cliente pode propagar o mesmo traceparent em várias requisições. O
+// descarte da primeira não pode esconder o erro de uma requisição seguinte,
+// mas spans atrasados da primeira seguem a decisão dela.
+func TestTailSamplingProcessor_ReevaluatesLaterLocalRoots(t *testing.T) {
+	tracer, processor, recorder := newTailSamplingTracer(t, TailSamplingConfig{Ratio: 0, LatencyThreshold: time.Hour})
+	remote := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01},
+		SpanID:     trace.SpanID{0x01},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+
+	ctx, first := tracer.Start(remote, "POST /")
+	_, late := tracer.Start(ctx, "fetch_weather_data")
+	first.End()
+	late.End()
+	if len(recorder.Ended()) != 0 {
+		t.Fatalf("Expected the first request to be dropped, got %d spans", len(recorder.Ended()))
+	}
+
+	ctx, second := tracer.Start(remote, "POST /")
+	_, child := tracer.Start(ctx, "fetch_weather_data")
+	child.SetStatus(codes.Error, "Can not find zipcode")
+	child.End()
+	second.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected the 2 spans of the failing request, got %d", len(spans))
+	}
+	if spans[0].SpanContext().SpanID() != child.SpanContext().SpanID() || spans[1].SpanContext().SpanID() != second.SpanContext().SpanID() {
+		t.Errorf("Expected child and root of the failing request, got %s and %s", spans[0].Name(), spans[1].Name())
+	}
+
+	// Depois do erro o trace passa a ser mantido
+	ctx, third := tracer.Start(remote, "POST /")
+	_, thirdChild := tracer.Start(ctx, "fetch_weather_data")
+	thirdChild.End()
+	third.End()
+	if len(recorder.Ended()) != 4 {
+		t.Errorf("Expected the kept trace to forward later requests, got %d spans", len(recorder.Ended()))
+	}
+	if processor.Dropped() != 1 {
+		t.Errorf("Expected 1 dropped trace, got %d", processor.Dropped())
+	}
+}
+
+func TestTailSamplingProcessor_BoundedMemory(t *testing.T) {
+	tracer, processor, recorder := newTailSamplingTracer(t, TailSamplingConfig{Ratio: 1, MaxTraces: 2})
+
+	// Spans filhos cujos spans raiz nunca terminam ocupam o buffer
+	var roots []trace.Span
+	for i := 0; i < 5; i++ {
+		ctx, root := tracer.Start(context.Background(), "GET /")
+		_, child := tracer.Start(ctx, "validate_cep")
+		child.End()
+		roots = append(roots, root)
+	}
+
+	if processor.Dropped() != 3 {
+		t.Errorf("Expected 3 evicted traces, got %d", processor.Dropped())
+	}
+
+	roots[4].End()
+	if len(recorder.Ended()) != 2 {
+		t.Errorf("Expected the buffered child and the root to be exported, got %d spans", len(recorder.Ended()))
+	}
+}
+
+func TestTailSamplingConfigFromEnv(t *testing.T) {
+	cfg, err := TailSamplingConfigFromEnv("", "", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cfg.Ratio != 0.1 || cfg.LatencyThreshold != time.Second || cfg.MaxTraces != 1000 {
+		t.Errorf("Unexpected defaults: %+v", cfg)
+	}
+
+	if _, err := TailSamplingConfigFromEnv("2", "", ""); err == nil {
+		t.Error("Expected invalid ratio to return an error")
+	}
+	if _, err := TailSamplingConfigFromEnv("", "fast", ""); err == nil {
+		t.Error("Expected invalid latency to return an error")
+	}
+	if _, err := TailSamplingConfigFromEnv("", "", "0"); err == nil {
+		t.Error("Expected invalid max traces to return an error")
+	}
+}
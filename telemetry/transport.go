@@ -0,0 +1,146 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sensitiveQueryParams são parâmetros cujo valor nunca deve aparecer em spans
+// ou logs (ex.: a API key da WeatherAPI).
+var sensitiveQueryParams = map[string]bool{
+	"key":          true,
+	"apikey":       true,
+	"api_key":      true,
+	"token":        true,
+	"access_token": true,
+}
+
+// RedactURL devolve a URL com senha e parâmetros sensíveis substituídos por
+// REDACTED.
+func RedactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	changed := false
+	for name := range query {
+		if sensitiveQueryParams[strings.ToLower(name)] {
+			query.Set(name, "REDACTED")
+			changed = true
+		}
+	}
+	if changed {
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.Redacted()
+}
+
+type upstreamKey struct{}
+
+type upstream struct {
+	name     string
+	template string
+}
+
+// WithUpstream nomeia o upstream e o template de URL (ex.: /ws/{cep}/json/)
+// usados pelo Transport no span e na métrica da chamada.
+func WithUpstream(ctx context.Context, name, template string) context.Context {
+	return context.WithValue(ctx, upstreamKey{}, upstream{name: name, template: template})
+}
+
+// Transport instrumenta chamadas HTTP de saída: cria um span CLIENT, injeta os
+// headers de propagação e registra http.client.request.duration.
+type Transport struct {
+	base    http.RoundTripper
+	tracer  trace.Tracer
+	metrics *Metrics
+}
+
+func NewTransport(base http.RoundTripper, tracer trace.Tracer, metrics *Metrics) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{base: base, tracer: tracer, metrics: metrics}
+}
+
+// NewHTTPClient cria o client compartilhado pelos gateways.
+func NewHTTPClient(tracer trace.Tracer, metrics *Metrics) *http.Client {
+	return &http.Client{Transport: NewTransport(http.DefaultTransport, tracer, metrics)}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	info, ok := ctx.Value(upstreamKey{}).(upstream)
+	if !ok {
+		info = upstream{name: req.URL.Hostname()}
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", req.Method),
+		attribute.String("url.full", RedactURL(req.URL)),
+		attribute.String("server.address", req.URL.Hostname()),
+		attribute.String("upstream", info.name),
+	}
+	if port := serverPort(req.URL); port != 0 {
+		attrs = append(attrs, attribute.Int("server.port", port))
+	}
+
+	spanName := req.Method
+	if info.template != "" {
+		spanName = req.Method + " " + info.template
+		attrs = append(attrs, attribute.String("url.template", info.template))
+	}
+
+	ctx, span := t.tracer.Start(ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	defer span.End()
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	duration := time.Since(start)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.SetAttributes(attribute.String("error.type", fmt.Sprintf("%T", err)))
+		t.metrics.RecordUpstream(ctx, info.name, req.Method, 0, err, duration)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		span.SetAttributes(attribute.String("error.type", strconv.Itoa(resp.StatusCode)))
+	}
+	t.metrics.RecordUpstream(ctx, info.name, req.Method, resp.StatusCode, nil, duration)
+
+	return resp, nil
+}
+
+func serverPort(u *url.URL) int {
+	if port := u.Port(); port != "" {
+		p, _ := strconv.Atoi(port)
+		return p
+	}
+	switch u.Scheme {
+	case "http":
+		return 80
+	case "https":
+		return 443
+	}
+	return 0
+}